@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterReference points to a Kind or Openshift cluster in the same namespace.
+type ClusterReference struct {
+	// Kind is the type of the cluster.
+	// +kubebuilder:validation:Enum=Kind;Openshift
+	// +kubebuilder:validation:Required
+	Kind string `json:"kind"`
+
+	// Name is the name of the cluster.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// ClusterBootstrapSpec defines the Job run against a cluster once it is up.
+type ClusterBootstrapSpec struct {
+	// ClusterRef is the cluster the Job sets up. It cannot be changed.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="clusterRef is immutable"
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Template is the pod template of the Job. The access Secret of the cluster is mounted in
+	// every container at /etc/mapt/cluster, and KUBECONFIG points to its kubeconfig unless the
+	// container sets it. The restart policy defaults to Never.
+	// The schema of the template is not published in the CRD to keep it small enough for
+	// client-side apply; the API server still validates the pods of the Job.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template corev1.PodTemplateSpec `json:"template"`
+
+	// BackoffLimit is the number of retries before the Job is considered failed.
+	// +optional
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=0
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// ActiveDeadlineSeconds bounds how long the Job may run, retries included.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// BootstrapPhase represents the progress of a ClusterBootstrap.
+// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed
+type BootstrapPhase string
+
+const (
+	// BootstrapPhasePending indicates that the cluster is not running yet.
+	BootstrapPhasePending BootstrapPhase = "Pending"
+	// BootstrapPhaseRunning indicates that the Job is running.
+	BootstrapPhaseRunning BootstrapPhase = "Running"
+	// BootstrapPhaseSucceeded indicates that the Job completed.
+	BootstrapPhaseSucceeded BootstrapPhase = "Succeeded"
+	// BootstrapPhaseFailed indicates that the Job failed.
+	BootstrapPhaseFailed BootstrapPhase = "Failed"
+)
+
+// ClusterBootstrapStatus defines the observed state of ClusterBootstrap.
+type ClusterBootstrapStatus struct {
+	// Phase indicates the progress of the bootstrap.
+	// +optional
+	Phase BootstrapPhase `json:"phase,omitempty"`
+
+	// Message provides a human-readable status message.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// JobName is the name of the Job bootstrapping the cluster.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// ProvisionId is the provision id of the cluster the Job ran against. The Job runs again when
+	// the cluster is provisioned anew, e.g. when it is recreated to apply spec changes.
+	// +optional
+	ProvisionId string `json:"provisionId,omitempty"`
+
+	// StartTime records when the Job was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime records when the Job succeeded or failed.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.clusterRef.name`,description="Bootstrapped cluster"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="Bootstrap phase"
+// +kubebuilder:printcolumn:name="Job",type=string,JSONPath=`.status.jobName`,description="Bootstrap Job"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterBootstrap is the Schema for the clusterbootstraps API.
+// It runs a Job against a Kind or Openshift cluster once it is running, e.g. to seed test data.
+type ClusterBootstrap struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterBootstrapSpec   `json:"spec,omitempty"`
+	Status ClusterBootstrapStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterBootstrapList contains a list of ClusterBootstrap.
+type ClusterBootstrapList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterBootstrap `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterBootstrap{}, &ClusterBootstrapList{})
+}
@@ -1,6 +1,9 @@
 package v1alpha1
 
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 // MachineConfig contains parameters for configuring the EC2 spot machine.
+// +kubebuilder:validation:XValidation:rule="!has(self.gpuRequirements) || (has(self.gpu) && self.gpu)",message="gpuRequirements requires gpu"
 type MachineConfig struct {
 	// Architecture for the EC2 instance.
 	// +kubebuilder:validation:Enum=x86_64;arm64
@@ -11,15 +14,42 @@ type MachineConfig struct {
 	CPUs int32 `json:"cpus,omitempty"`
 
 	// Indicates if the EC2 instance should have GPU support.
-	// In case GPU is true, the instance type will be selected from the list of supported GPU instances.
+	// In case GPU is true, the instance type is selected among the GPU instances meeting
+	// gpuRequirements, or with a single GPU of any model without them, and cpus and memoryGiB are
+	// minimums of the instance. The selected instance type is reported in status.instanceType.
 	// +optional
 	// +kubebuilder:default=false
 	GPU bool `json:"gpu,omitempty"`
 
+	// GPURequirements describes the GPUs requested by gpu.
+	// +optional
+	GPURequirements *GPURequirements `json:"gpuRequirements,omitempty"`
+
 	// MemoryGiB is the amount of RAM for the EC2 instance in GiB.
 	MemoryGiB int32 `json:"memoryGiB,omitempty"`
 
+	// InstanceTypes is an allow-list of EC2 instance types, in order of preference. When set, the
+	// cluster is provisioned on the first of them meeting the requirements, with a spot offer in the
+	// allowed availability zones and within maxHourlyPrice for a spot instance, instead of an
+	// instance type derived from cpus and memoryGiB. Combined with gpu, only the listed types meeting
+	// the GPU requirements are considered.
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	InstanceTypes []string `json:"instanceTypes,omitempty"`
+
+	// ExcludedInstanceTypes are EC2 instance types the cluster is never provisioned on.
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	ExcludedInstanceTypes []string `json:"excludedInstanceTypes,omitempty"`
+
+	// Disk configures the root volume of the EC2 instance. When unset, the volume created by the
+	// provisioning tool is kept as is.
+	// +optional
+	Disk *DiskConfig `json:"disk,omitempty"`
+
 	// NestedVirtualizationEnabled specifies if the EC2 instance should have nested virtualization support.
+	// The instance is then a bare metal instance or one of a family exposing the virtualization
+	// extensions of its CPUs, and provisioning fails when /dev/kvm is not available on it.
 	// +optional
 	// +kubebuilder:default=false
 	NestedVirtualizationEnabled bool `json:"nestedVirtualizationEnabled,omitempty"`
@@ -37,9 +67,563 @@ type MachineConfig struct {
 	// +optional
 	SpotPriceIncreasePercentage *int `json:"spotPriceIncreasePercentage,omitempty"`
 
+	// MaxHourlyPrice is the highest hourly price, in USD, paid for a spot instance, e.g. "0.85".
+	// A spot attempt fails without provisioning anything when the best offer is above it.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	MaxHourlyPrice string `json:"maxHourlyPrice,omitempty"`
+
+	// Fallback is the ordered list of strategies tried to provision the cluster. When an attempt
+	// fails for lack of capacity or because the best spot offer is above maxHourlyPrice, its
+	// resources are destroyed and the next strategy is tried; any other failure ends provisioning.
+	// Every attempt is recorded in status.provisioningAttempts. Defaults to a single Spot strategy,
+	// or OnDemand when useSpotInstances is false.
+	// +optional
+	// +kubebuilder:validation:MaxItems=8
+	Fallback []FallbackStrategy `json:"fallback,omitempty"`
+
 	// Tags to apply to the AWS resources created by the provisioning tool.
 	// The operator will convert this map into the string format the tool expects (e.g., "key1=value1,key2=value2").
 	// Corresponds to the Tekton 'tags' param.
 	// +optional
 	Tags map[string]string `json:"tags,omitempty"`
 }
+
+// GPUModel is the model of the NVIDIA GPUs of an EC2 instance.
+// +kubebuilder:validation:Enum=L4;L40S;A10G;A100;H100
+type GPUModel string
+
+const (
+	// GPUModelL4 is the NVIDIA L4 of G6 instances, with 24 GiB of memory.
+	GPUModelL4 GPUModel = "L4"
+	// GPUModelL40S is the NVIDIA L40S of G6e instances, with 48 GiB of memory.
+	GPUModelL40S GPUModel = "L40S"
+	// GPUModelA10G is the NVIDIA A10G of G5 instances, with 24 GiB of memory.
+	GPUModelA10G GPUModel = "A10G"
+	// GPUModelA100 is the NVIDIA A100 of P4d and P4de instances, with 40 and 80 GiB of memory.
+	GPUModelA100 GPUModel = "A100"
+	// GPUModelH100 is the NVIDIA H100 of P5 instances, with 80 GiB of memory.
+	GPUModelH100 GPUModel = "H100"
+)
+
+// RequestedGPUs returns the GPUs the machine requests: its gpuRequirements, a single GPU of any
+// model when it has none, or nil when gpu is false.
+func (m MachineConfig) RequestedGPUs() *GPURequirements {
+	if !m.GPU {
+		return nil
+	}
+	if m.GPURequirements == nil {
+		return &GPURequirements{Count: 1}
+	}
+	return m.GPURequirements
+}
+
+// GPURequirements describes the GPUs a cluster needs. The candidate instance types are the GPU
+// instances meeting every requirement with the fewest GPUs; the cheapest of them is provisioned.
+type GPURequirements struct {
+	// Model of the GPUs. When empty, any model meeting the other requirements is accepted.
+	// +optional
+	Model GPUModel `json:"model,omitempty"`
+
+	// Count is the minimum number of GPUs of the instance. GPU instances come with 1, 4 or 8 GPUs,
+	// so the instance may have more GPUs than requested.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8
+	Count int32 `json:"count,omitempty"`
+
+	// MinMemoryGiB is the minimum memory of each GPU, in GiB.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MinMemoryGiB int32 `json:"minMemoryGiB,omitempty"`
+}
+
+// VolumeType is the EBS volume type of a root volume.
+// +kubebuilder:validation:Enum=gp3;io2
+type VolumeType string
+
+const (
+	// VolumeTypeGP3 is the general purpose SSD volume type, with 3000 IOPS and 125 MiB/s included.
+	VolumeTypeGP3 VolumeType = "gp3"
+	// VolumeTypeIO2 is the provisioned IOPS SSD volume type.
+	VolumeTypeIO2 VolumeType = "io2"
+)
+
+// DiskConfig describes the root volume of the instance of a cluster. The volume is modified once
+// the instance runs, and its partition and filesystem are grown to the new size.
+type DiskConfig struct {
+	// SizeGiB is the size of the root volume. Volumes are never shrunk: a size below the one of the
+	// volume created by the provisioning tool is ignored.
+	// +kubebuilder:validation:Minimum=8
+	// +kubebuilder:validation:Maximum=16384
+	SizeGiB int32 `json:"sizeGiB"`
+
+	// Type is the EBS volume type.
+	// +optional
+	// +kubebuilder:default=gp3
+	Type VolumeType `json:"type,omitempty"`
+
+	// IOPS provisioned for the volume: 3000 to 16000 for gp3, where it defaults to 3000, and 100 to
+	// 256000 for io2, where it is required. At most 500 IOPS per GiB for gp3 and 1000 for io2.
+	// +optional
+	// +kubebuilder:validation:Minimum=100
+	// +kubebuilder:validation:Maximum=256000
+	IOPS *int32 `json:"iops,omitempty"`
+
+	// Throughput of a gp3 volume, in MiB/s, from 125, the default, to 1000 and at most a quarter
+	// of its IOPS.
+	// +optional
+	// +kubebuilder:validation:Minimum=125
+	// +kubebuilder:validation:Maximum=1000
+	Throughput *int32 `json:"throughput,omitempty"`
+}
+
+// NetworkConfig restricts how the instance of a cluster can be reached, by replacing the ingress
+// rules of the API server and SSH in the security groups of the instance.
+type NetworkConfig struct {
+	// AllowedCIDRs are the IPv4 and IPv6 ranges allowed to reach the API server and SSH of the
+	// instance. They are applied as soon as the instance is launched, before either is up. When
+	// empty, both stay open to any address. The addresses the operator connects from must be
+	// included, since it provisions the cluster over SSH.
+	// +optional
+	// +kubebuilder:validation:MaxItems=32
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+
+	// DisableSSH closes the SSH port of the instance once the cluster is provisioned.
+	// +optional
+	DisableSSH bool `json:"disableSSH,omitempty"`
+}
+
+// InstanceMarket is the market the EC2 instance of a cluster is bought on.
+// +kubebuilder:validation:Enum=Spot;OnDemand
+type InstanceMarket string
+
+const (
+	// InstanceMarketSpot buys a spot instance, at the price of the best offer.
+	InstanceMarketSpot InstanceMarket = "Spot"
+	// InstanceMarketOnDemand buys an on-demand instance.
+	InstanceMarketOnDemand InstanceMarket = "OnDemand"
+)
+
+// PlacementScope selects the availability zones a fallback strategy looks for an instance in.
+// Instances are always provisioned in the region of the operator credentials.
+// +kubebuilder:validation:Enum=Preferred;AnyZone
+type PlacementScope string
+
+const (
+	// PlacementScopePreferred honours cloudConfig.availabilityZones.
+	PlacementScopePreferred PlacementScope = "Preferred"
+	// PlacementScopeAnyZone lifts cloudConfig.availabilityZones: the instance may be provisioned in
+	// any availability zone of the region of the operator credentials. It never falls back to
+	// another region, so without availability zones, e.g. for Openshift clusters, it tries the same
+	// placement as Preferred.
+	PlacementScopeAnyZone PlacementScope = "AnyZone"
+)
+
+// FallbackStrategy is one way of provisioning the instance of a cluster.
+type FallbackStrategy struct {
+	// Market the instance is bought on.
+	Market InstanceMarket `json:"market"`
+
+	// Placement restricts the instance to the preferred availability zones, or lifts the
+	// restriction.
+	// +optional
+	// +kubebuilder:default=Preferred
+	Placement PlacementScope `json:"placement,omitempty"`
+}
+
+// ProvisioningAttempt records a fallback strategy tried to provision the cluster.
+type ProvisioningAttempt struct {
+	// Strategy is the fallback strategy tried.
+	Strategy FallbackStrategy `json:"strategy"`
+
+	// Succeeded reports whether the cluster was provisioned with the strategy.
+	// +optional
+	Succeeded bool `json:"succeeded,omitempty"`
+
+	// Reason is a CamelCase reason the attempt failed: InsufficientCapacity, PriceAboveMaximum or
+	// ProvisioningFailed.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message details why the attempt failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is when the attempt started.
+	StartTime metav1.Time `json:"startTime"`
+}
+
+// ClusterCost reports the cost incurred by a provisioned cluster.
+// Amounts are plain decimal strings (e.g. "0.1235") so they can be parsed by billing exports
+// without unit handling; the human-readable hourly price is still reported in averagePrice.
+type ClusterCost struct {
+	// HourlyRateUSD is the hourly price paid for the running cluster, in USD: the price of the
+	// instance plus that of its EBS root volume.
+	// +optional
+	HourlyRateUSD string `json:"hourlyRateUSD,omitempty"`
+
+	// StorageHourlyRateUSD is the part of hourlyRateUSD paid for the EBS root volume, in USD. The
+	// volume is still paid while the cluster is hibernated. It is empty when the root volume is not
+	// configured in machineConfig.disk.
+	// +optional
+	StorageHourlyRateUSD string `json:"storageHourlyRateUSD,omitempty"`
+
+	// RunningHours is the number of hours elapsed since provisioning started, to the minute and
+	// excluding hibernated time and the time the cluster had no infrastructure. Provisioning time
+	// is included, since the instance is billed while the cluster is being provisioned.
+	// +optional
+	RunningHours string `json:"runningHours,omitempty"`
+
+	// AccruedUSD is the cost accrued so far, in USD, including the root volume while the cluster
+	// was hibernated.
+	// +optional
+	AccruedUSD string `json:"accruedUSD,omitempty"`
+
+	// ProjectedTotalUSD is the expected total cost once the cluster reaches its expiration, in USD.
+	// It is empty when the cluster has no expiration.
+	// +optional
+	ProjectedTotalUSD string `json:"projectedTotalUSD,omitempty"`
+}
+
+// ClusterUsage reports the running hours and cost of infrastructure a cluster no longer runs on.
+type ClusterUsage struct {
+	// RunningHours is the number of hours the infrastructure ran, excluding hibernated time.
+	// +optional
+	RunningHours string `json:"runningHours,omitempty"`
+
+	// AccruedUSD is the cost of the infrastructure, in USD.
+	// +optional
+	AccruedUSD string `json:"accruedUSD,omitempty"`
+}
+
+// StateBackendType identifies where the provisioning tool stores the state of a cluster.
+// +kubebuilder:validation:Enum=S3;File;AzureBlob
+type StateBackendType string
+
+const (
+	// StateBackendS3 stores the state in an S3 bucket.
+	StateBackendS3 StateBackendType = "S3"
+	// StateBackendFile stores the state in a local directory of the operator, e.g. a PVC mount.
+	StateBackendFile StateBackendType = "File"
+	// StateBackendAzureBlob stores the state in an Azure Blob Storage container.
+	StateBackendAzureBlob StateBackendType = "AzureBlob"
+)
+
+// StateBackend selects where the provisioning state of a cluster is stored.
+type StateBackend struct {
+	// Type is the kind of storage holding the state.
+	// +kubebuilder:validation:Required
+	Type StateBackendType `json:"type"`
+
+	// Bucket is the S3 bucket or Azure Blob container holding the state.
+	// When empty, the operator default is used; for S3 this falls back to the bucket of the cloud credentials.
+	// It is ignored by the File backend, whose directory is configured on the operator.
+	// +optional
+	Bucket string `json:"bucket,omitempty"`
+}
+
+// Schedule defines the recurring window a cluster should be up in. The window opens each time the
+// start expression fires and closes the next time the stop expression fires. Expressions use the
+// five cron fields: minute, hour, day of month, month and day of week.
+type Schedule struct {
+	// Start is the cron expression bringing the cluster up (e.g. "0 8 * * MON-FRI").
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Stop is the cron expression bringing the cluster down (e.g. "0 19 * * MON-FRI").
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Stop string `json:"stop"`
+
+	// TimeZone is the IANA time zone the expressions are evaluated in (e.g. "Europe/Madrid").
+	// When empty, UTC is used.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// TTLExtension records an extension of the expiration of a cluster.
+type TTLExtension struct {
+	// Duration is how much the expiration was postponed by.
+	Duration metav1.Duration `json:"duration"`
+
+	// AppliedAt is when the operator applied the extension.
+	AppliedAt metav1.Time `json:"appliedAt"`
+}
+
+// AddonPhase represents the installation phase of an add-on or of the GPU stack of a cluster.
+// +kubebuilder:validation:Enum=Pending;Installing;Ready;Failed
+type AddonPhase string
+
+const (
+	// AddonPhasePending indicates that the add-on waits for the previous add-ons to be ready.
+	AddonPhasePending AddonPhase = "Pending"
+	// AddonPhaseInstalling indicates that the resources of the add-on are applied and not ready yet.
+	AddonPhaseInstalling AddonPhase = "Installing"
+	// AddonPhaseReady indicates that the workloads of the add-on are available.
+	AddonPhaseReady AddonPhase = "Ready"
+	// AddonPhaseFailed indicates that the resources of the add-on could not be loaded or applied.
+	// Installation is retried.
+	AddonPhaseFailed AddonPhase = "Failed"
+)
+
+// GPUStack configures the NVIDIA software making the GPUs of a cluster schedulable. On Openshift,
+// the Node Feature Discovery and NVIDIA GPU operators are installed from OperatorHub; on Kind, the
+// host gets the NVIDIA driver and container toolkit, and the NVIDIA device plugin is deployed.
+// The cluster is only reported Ready once a node reports allocatable nvidia.com/gpu.
+// +kubebuilder:validation:XValidation:rule="!(has(self.timeSlicing) && has(self.mig))",message="timeSlicing and mig are mutually exclusive"
+type GPUStack struct {
+	// Version is the channel of the GPU operator on Openshift (e.g. "v25.3"), or the release of
+	// the device plugin on Kind (e.g. "v0.17.2"). When omitted, the release the operator defaults
+	// to is installed.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// TimeSlicing shares each GPU between several pods. Every GPU is then advertised as
+	// replicas nvidia.com/gpu, without memory or fault isolation between the pods.
+	// +optional
+	TimeSlicing *GPUTimeSlicing `json:"timeSlicing,omitempty"`
+
+	// MIG partitions the GPUs supporting Multi-Instance GPU (e.g. A100, H100) into isolated
+	// instances. Only Openshift clusters support it.
+	// +optional
+	MIG *GPUMIG `json:"mig,omitempty"`
+}
+
+// GPUTimeSlicing configures the time-slicing of the GPUs of a cluster.
+type GPUTimeSlicing struct {
+	// Replicas is the number of pods each GPU is shared by.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=2
+	// +kubebuilder:validation:Maximum=32
+	Replicas int32 `json:"replicas"`
+}
+
+// GPUMIG configures the Multi-Instance GPU partitioning of the GPUs of a cluster.
+type GPUMIG struct {
+	// Profile is the MIG configuration applied to every GPU, from the configurations of the
+	// NVIDIA MIG manager (e.g. "all-1g.10gb", "all-balanced").
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Profile string `json:"profile"`
+}
+
+// GPUStackStatus reports the installation of the GPU stack of a cluster.
+type GPUStackStatus struct {
+	// Phase is the installation phase of the GPU stack.
+	Phase AddonPhase `json:"phase"`
+
+	// Version is the release of the GPU operator or device plugin being installed.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Stage is the component being installed, e.g. gpu-operator or nvidia-device-plugin.
+	// +optional
+	Stage string `json:"stage,omitempty"`
+
+	// Message provides details on the phase, e.g. the workloads that are not available yet.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// AllocatableGPUs is the number of nvidia.com/gpu the nodes of the cluster can allocate.
+	// +optional
+	AllocatableGPUs int64 `json:"allocatableGPUs,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the GPU stack was installed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastAppliedTime is when the resources of the GPU stack were last applied.
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+}
+
+// Condition types reported by Kind and Openshift clusters. Every condition carries the generation
+// it was computed for, and Ready, Reconciling and Stalled follow the kstatus conventions so that
+// GitOps tools can assess the health of a cluster without custom health checks.
+const (
+	// ConditionReady is True once InfrastructureProvisioned, AccessSecretReady and Healthy are all True,
+	// as well as AddonsReady and GPUReady when they are reported.
+	ConditionReady = "Ready"
+	// ConditionInfrastructureProvisioned reports whether the cloud infrastructure of the cluster exists.
+	ConditionInfrastructureProvisioned = "InfrastructureProvisioned"
+	// ConditionAccessSecretReady reports whether the Secret holding the cluster credentials is up to date.
+	ConditionAccessSecretReady = "AccessSecretReady"
+	// ConditionHealthy reports whether the provisioned cluster is usable.
+	ConditionHealthy = "Healthy"
+	// ConditionExpiring is True when the cluster is about to be destroyed by its termination policy.
+	ConditionExpiring = "Expiring"
+	// ConditionReconciling is True while the controller works towards the desired state.
+	ConditionReconciling = "Reconciling"
+	// ConditionStalled is True when the controller cannot make progress without user intervention.
+	ConditionStalled = "Stalled"
+	// ConditionSpecDrift is True when the running cluster does not match its spec.
+	ConditionSpecDrift = "SpecDrift"
+	// ConditionHibernated is True while the cluster is down because of spec.hibernate or spec.schedule.
+	ConditionHibernated = "Hibernated"
+	// ConditionAddonsReady reports whether the add-ons of a Kind cluster are installed and ready. It
+	// is only reported by clusters with add-ons.
+	ConditionAddonsReady = "AddonsReady"
+	// ConditionGPUReady reports whether the GPU stack of a cluster is installed and its nodes can
+	// allocate nvidia.com/gpu. It is only reported by clusters with spec.gpuStack.
+	ConditionGPUReady = "GPUReady"
+	// ConditionBootstrapped reports whether the ClusterBootstraps targeting the cluster succeeded. It
+	// is only reported by clusters targeted by a ClusterBootstrap.
+	ConditionBootstrapped = "Bootstrapped"
+)
+
+// ClusterPhase represents the lifecycle phase of a cluster.
+// +kubebuilder:validation:Enum=Pending;Queued;Provisioning;Running;Hibernated;Failed;Deleting
+type ClusterPhase string
+
+const (
+	// ClusterPhasePending indicates that the cluster has been requested but provisioning has not started.
+	ClusterPhasePending ClusterPhase = "Pending"
+	// ClusterPhaseQueued indicates that the cluster waits for a MaptQuota of its namespace or the
+	// operator concurrency limits to free up capacity.
+	ClusterPhaseQueued ClusterPhase = "Queued"
+	// ClusterPhaseProvisioning indicates that the cloud infrastructure and the cluster are being set up.
+	ClusterPhaseProvisioning ClusterPhase = "Provisioning"
+	// ClusterPhaseRunning indicates that the cluster is provisioned and ready for use.
+	ClusterPhaseRunning ClusterPhase = "Running"
+	// ClusterPhaseHibernated indicates that the cluster is down because spec.hibernate is set or it is
+	// outside its schedule. Its instance is stopped, keeping its disks, or, for spot clusters outside
+	// their schedule, destroyed until the schedule brings the cluster up again.
+	ClusterPhaseHibernated ClusterPhase = "Hibernated"
+	// ClusterPhaseFailed indicates that provisioning or deprovisioning failed.
+	ClusterPhaseFailed ClusterPhase = "Failed"
+	// ClusterPhaseDeleting indicates that the cluster and its cloud infrastructure are being destroyed.
+	ClusterPhaseDeleting ClusterPhase = "Deleting"
+)
+
+// ClusterStatus is the observed state shared by every cluster type. It is inlined in the status
+// of each cluster type, so its fields appear directly under status.
+type ClusterStatus struct {
+	// Phase indicates the current lifecycle phase of the cluster.
+	// +optional
+	Phase ClusterPhase `json:"phase,omitempty"`
+
+	// Message provides a human-readable status message.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Conditions represent the latest available observations of the cluster state.
+	// +optional
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// ObservedGeneration is the most recent generation of the spec handled by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// AWSInstanceID is the ID of the EC2 instance the cluster runs on.
+	// +optional
+	AWSInstanceID *string `json:"awsInstanceID,omitempty"`
+
+	// InstanceType is the EC2 instance type the cluster runs on.
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// Region is the AWS region the cluster runs in.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// AvailabilityZone is the AWS availability zone the cluster runs in.
+	// +optional
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+
+	// ProvisioningAttempts records the fallback strategies tried by the last provisioning, in order.
+	// +optional
+	ProvisioningAttempts []ProvisioningAttempt `json:"provisioningAttempts,omitempty"`
+
+	// Host is the address the cluster is reachable at. It may change when the cluster resumes from
+	// hibernation, in which case the access Secret is updated accordingly.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// KubeconfigSecretName is the name of the Secret holding the credentials of the cluster.
+	// +optional
+	KubeconfigSecretName *string `json:"kubeconfigSecretName,omitempty"`
+
+	// ClusterReady indicates if the cluster is fully provisioned and accessible.
+	// +optional
+	ClusterReady bool `json:"clusterReady,omitempty"`
+
+	// AveragePrice reports the average acquisition price of the spot instance(s).
+	// This field is a string to allow for currency.
+	// +optional
+	AveragePrice string `json:"averagePrice,omitempty"`
+
+	// ExpirationTimestamp indicates when the cluster is scheduled to be terminated, based on TerminationPolicy,
+	// its extensions, the activity Lease and the maxLifetime of the MaptQuotas of the namespace.
+	// +optional
+	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
+
+	// ProvisionId is the id of the backend used by the provisioning tool.
+	// +optional
+	ProvisionId *string `json:"provisionId,omitempty"`
+
+	// ProvisionStartTime records when the provisioning process began. It is kept when the cluster is
+	// provisioned again, to apply spec changes or when its schedule brings it up, so that its TTL
+	// and maxLifetime are measured from the first provisioning.
+	// +optional
+	ProvisionStartTime *metav1.Time `json:"provisionStartTime,omitempty"`
+
+	// InfrastructureStartTime records when provisioning of the infrastructure the cluster runs on
+	// began. It is later than provisionStartTime once the cluster was provisioned again, and bounds
+	// the provisioning timeout of that run.
+	// +optional
+	InfrastructureStartTime *metav1.Time `json:"infrastructureStartTime,omitempty"`
+
+	// QueuePosition is the 1-based position of the cluster in the provisioning queue while it
+	// waits for the operator concurrency limits to admit it. It is unset once a slot is granted.
+	// +optional
+	QueuePosition *int32 `json:"queuePosition,omitempty"`
+
+	// Cost reports the hourly rate, running hours and accrued cost of the cluster.
+	// It is refreshed by the reconciles of a running cluster, at most once a minute.
+	// +optional
+	Cost *ClusterCost `json:"cost,omitempty"`
+
+	// PreviousUsage is the usage of the infrastructure the cluster ran on before its current one,
+	// destroyed to apply spec changes or outside its schedule. It is included in cost.
+	// +optional
+	PreviousUsage *ClusterUsage `json:"previousUsage,omitempty"`
+
+	// HibernatedAt records when the cluster was last hibernated. It is unset while the cluster runs.
+	// +optional
+	HibernatedAt *metav1.Time `json:"hibernatedAt,omitempty"`
+
+	// HibernatedDuration is the total time the cluster spent hibernated on its current
+	// infrastructure before its last resume. The instance is not billed for it, and it is excluded
+	// from the running hours of the cost.
+	// +optional
+	HibernatedDuration *metav1.Duration `json:"hibernatedDuration,omitempty"`
+
+	// TTLExtensions records the extensions requested with the mapt.redhat.com/extend-ttl
+	// annotation, oldest first. They postpone the expiration on top of terminationPolicy.extendBy.
+	// +optional
+	TTLExtensions []TTLExtension `json:"ttlExtensions,omitempty"`
+
+	// ActivityLeaseName is the name of the Lease consumers renew to keep the cluster alive when
+	// terminationPolicy.inactivityTimeout is set.
+	// +optional
+	ActivityLeaseName string `json:"activityLeaseName,omitempty"`
+
+	// NextTransition is when the schedule of the cluster next brings it up or down. It is unset
+	// when the cluster has no schedule.
+	// +optional
+	NextTransition *metav1.Time `json:"nextTransition,omitempty"`
+
+	// StateBackend records the backend holding the provisioning state, resolved when provisioning started.
+	// Deprovisioning always uses this backend, even if the operator default changes afterwards.
+	// +optional
+	StateBackend *StateBackend `json:"stateBackend,omitempty"`
+
+	// GPUStack reports the installation of spec.gpuStack. It is unset when the cluster has no GPU stack.
+	// +optional
+	GPUStack *GPUStackStatus `json:"gpuStack,omitempty"`
+}
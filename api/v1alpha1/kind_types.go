@@ -24,14 +24,30 @@ import (
 )
 
 // KindPhase represents the lifecycle phase of a Kind resource.
-type KindPhase string
+type KindPhase = ClusterPhase
 
 const (
-	KindPhasePending      KindPhase = "Pending"
-	KindPhaseProvisioning KindPhase = "Provisioning"
-	KindPhaseRunning      KindPhase = "Running"
-	KindPhaseFailed       KindPhase = "Failed"
-	KindPhaseDeleting     KindPhase = "Deleting"
+	KindPhasePending      = ClusterPhasePending
+	KindPhaseQueued       = ClusterPhaseQueued
+	KindPhaseProvisioning = ClusterPhaseProvisioning
+	KindPhaseRunning      = ClusterPhaseRunning
+	KindPhaseHibernated   = ClusterPhaseHibernated
+	KindPhaseFailed       = ClusterPhaseFailed
+	KindPhaseDeleting     = ClusterPhaseDeleting
+)
+
+// KindUpdateStrategy defines how changes to the spec of a running Kind cluster are applied.
+// +kubebuilder:validation:Enum=Ignore;Recreate;BlueGreen
+type KindUpdateStrategy string
+
+const (
+	// KindUpdateStrategyIgnore keeps the running cluster unchanged and reports the drift in the SpecDrift condition.
+	KindUpdateStrategyIgnore KindUpdateStrategy = "Ignore"
+	// KindUpdateStrategyRecreate destroys the running cluster and provisions it again with the new spec.
+	KindUpdateStrategyRecreate KindUpdateStrategy = "Recreate"
+	// KindUpdateStrategyBlueGreen provisions a new cluster with the new spec, moves the kubeconfig
+	// Secret to it and then destroys the previous cluster.
+	KindUpdateStrategyBlueGreen KindUpdateStrategy = "BlueGreen"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -65,6 +81,118 @@ type KindSpec struct {
 	// TerminationPolicy defines when and how the cluster should be terminated.
 	// +optional
 	TerminationPolicy *TerminationPolicy `json:"terminationPolicy,omitempty"`
+
+	// Priority orders the cluster in the provisioning queue when the operator concurrency limits
+	// are reached. Clusters with a higher priority are admitted first; clusters with the same
+	// priority are admitted in creation order.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// StateBackend selects where the provisioning state of the cluster is stored.
+	// When omitted, the operator default backend is used. It cannot be changed once set.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="stateBackend is immutable"
+	StateBackend *StateBackend `json:"stateBackend,omitempty"`
+
+	// ProvisioningTimeout bounds how long provisioning may take (e.g. "45m"). When it elapses,
+	// provisioning is aborted, the partially created resources are destroyed and the cluster
+	// is marked as Failed with the ProvisioningTimedOut reason. When omitted, provisioning is not bounded.
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// UpdateStrategy defines how changes to machineConfig or kindClusterConfig are applied once the
+	// cluster is running. Changes to the tags, price limits and fallback strategies of machineConfig
+	// only apply to the next provisioning.
+	// +optional
+	// +kubebuilder:default=Ignore
+	UpdateStrategy KindUpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// Hibernate stops the instance of a running cluster, keeping its disks, and moves it to the
+	// Hibernated phase. Setting it back to false starts the instance again; the kubeconfig of the
+	// access Secret is pointed at the new address of the cluster, still verifying its certificate
+	// against the address it was issued for. Hibernated time is not billed. Spot instances cannot
+	// be stopped, so it requires machineConfig.useSpotInstances to be false.
+	// +optional
+	Hibernate bool `json:"hibernate,omitempty"`
+
+	// Schedule defines when the cluster should be up. Outside its window, the instance of an
+	// on-demand cluster is stopped as with spec.hibernate, while a spot cluster, whose instance
+	// cannot be stopped, is destroyed and provisioned again when the window opens.
+	// +optional
+	Schedule *Schedule `json:"schedule,omitempty"`
+
+	// Addons are installed on the cluster once it is provisioned, in order: each add-on is applied
+	// once the previous one is ready. The Ready condition of the cluster waits for all of them.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Addons []KindAddon `json:"addons,omitempty"`
+
+	// GPUStack installs the NVIDIA driver and container toolkit on the host and the NVIDIA device
+	// plugin on the cluster, so that pods can request nvidia.com/gpu. It requires machineConfig.gpu.
+	// Adding or removing it is a change to the provisioned cluster, applied according to
+	// spec.updateStrategy; other changes are applied to the running cluster.
+	// +optional
+	GPUStack *GPUStack `json:"gpuStack,omitempty"`
+
+	// Network restricts how the instance of the cluster can be reached. It cannot be changed once
+	// set.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="network is immutable"
+	Network *NetworkConfig `json:"network,omitempty"`
+}
+
+// KindAddon is a set of resources installed on a Kind cluster once it is provisioned.
+// +kubebuilder:validation:XValidation:rule="!(has(self.manifestURL) && has(self.configMapRef))",message="manifestURL and configMapRef are mutually exclusive"
+type KindAddon struct {
+	// Name identifies the add-on. The well-known add-ons ingress-nginx, cert-manager and
+	// metrics-server are installed from their upstream release manifests; any other add-on
+	// requires manifestURL or configMapRef.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Version selects the release of a well-known add-on (e.g. "v1.18.2"). When omitted, the
+	// release the operator defaults to is installed. It cannot be set along manifestURL or configMapRef.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ManifestURL is the https URL of a manifest holding the resources of the add-on. For a
+	// well-known add-on, it replaces the upstream manifest, e.g. to install it from a mirror.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https://`
+	ManifestURL string `json:"manifestURL,omitempty"`
+
+	// ConfigMapRef references a ConfigMap in the namespace of the cluster whose values are
+	// manifests holding the resources of the add-on. Values are applied in the order of their keys.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+}
+
+// AddonStatus reports the installation of an add-on.
+type AddonStatus struct {
+	// Name of the add-on.
+	Name string `json:"name"`
+
+	// Version is the release of a well-known add-on being installed.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Source is where the resources of the add-on come from: a URL or configmap/<name>.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// Phase is the installation phase of the add-on.
+	Phase AddonPhase `json:"phase"`
+
+	// Message provides details on the phase, e.g. the workloads that are not available yet.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastAppliedTime is when the resources of the add-on were last applied.
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
 }
 
 // KindClusterConfig contains parameters for the Kind cluster itself.
@@ -74,6 +202,67 @@ type KindClusterConfig struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	KubernetesVersion string `json:"kubernetesVersion"`
+
+	// Nodes sets how many control-plane and worker nodes the cluster has. When omitted, the cluster
+	// has a single control-plane node, unless KindConfig defines its nodes.
+	// +optional
+	Nodes *KindNodes `json:"nodes,omitempty"`
+
+	// FeatureGates enables or disables Kubernetes feature gates on every component of the cluster
+	// (e.g. {"InPlacePodVerticalScaling": true}).
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+
+	// RuntimeConfig enables or disables API groups and versions on the API server
+	// (e.g. {"api/alpha": "true"}).
+	// +optional
+	RuntimeConfig map[string]string `json:"runtimeConfig,omitempty"`
+
+	// Networking configures the networking of the cluster.
+	// +optional
+	Networking *KindNetworking `json:"networking,omitempty"`
+
+	// KindConfig is a raw kind Cluster configuration (apiVersion kind.x-k8s.io/v1alpha4), for settings
+	// without a dedicated field such as extra port mappings or containerd registry mirrors. Nodes,
+	// FeatureGates, RuntimeConfig and Networking take precedence over it. The address and port of
+	// the API server are always set by the operator, so that the cluster is reachable from outside
+	// its host.
+	// +optional
+	KindConfig string `json:"kindConfig,omitempty"`
+}
+
+// KindNodes contains the number of nodes of each role of a Kind cluster. All nodes run as
+// containers on the same host.
+type KindNodes struct {
+	// ControlPlanes is the number of control-plane nodes. With more than one, Kind puts a load
+	// balancer in front of their API servers.
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=5
+	ControlPlanes int32 `json:"controlPlanes,omitempty"`
+
+	// Workers is the number of worker nodes. With none, workloads run on the control-plane nodes.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	Workers int32 `json:"workers,omitempty"`
+}
+
+// KindNetworking contains networking parameters of a Kind cluster.
+type KindNetworking struct {
+	// PodSubnet is the CIDR the addresses of pods are allocated from (e.g. "10.244.0.0/16").
+	// +optional
+	PodSubnet string `json:"podSubnet,omitempty"`
+
+	// ServiceSubnet is the CIDR the addresses of services are allocated from (e.g. "10.96.0.0/16").
+	// +optional
+	ServiceSubnet string `json:"serviceSubnet,omitempty"`
+
+	// DisableDefaultCNI skips the installation of the default CNI, so that a different one can be
+	// installed. Nodes are not Ready until a CNI is installed.
+	// +optional
+	DisableDefaultCNI bool `json:"disableDefaultCNI,omitempty"`
 }
 
 // CloudConfig contains parameters to specify the cloud provider and access credentials.
@@ -97,9 +286,24 @@ type CloudConfig struct {
 	//   - "bucket": The S3 bucket name (for the provisioning tool's backend state, if applicable).
 	// +kubebuilder:validation:Required
 	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+
+	// Regions constrains the regions the cluster may be provisioned in; it does not select one.
+	// Clusters are always provisioned in the region of the credentials of the operator, so a cluster
+	// whose regions do not list it is rejected. All regions are allowed when empty.
+	// +optional
+	Regions []string `json:"regions,omitempty"`
+
+	// AvailabilityZones constrains the instance types of spot clusters to those with spot capacity in
+	// one of the zones, e.g. "us-east-1a"; it does not select a zone, and the instance may be placed
+	// in another zone of the region. Zones outside the region of the credentials of the operator are
+	// rejected. On-demand instances ignore them. All zones are allowed when empty.
+	// +optional
+	AvailabilityZones []string `json:"availabilityZones,omitempty"`
 }
 
-// TerminationPolicy defines automatic deletion parameters.
+// TerminationPolicy defines automatic deletion parameters. The cluster is deleted at the earliest
+// of its TTL, its inactivity timeout and the maxLifetime of the MaptQuotas of its namespace.
+// +kubebuilder:validation:XValidation:rule="!has(self.extendBy) || has(self.deleteAfterSeconds)",message="extendBy requires deleteAfterSeconds"
 type TerminationPolicy struct {
 	// DeleteAfterSeconds specifies a Time-To-Live (TTL) for the provisioned KindSpot.
 	// After this duration (in seconds, starting from when the cluster becomes Ready or from creation),
@@ -109,54 +313,48 @@ type TerminationPolicy struct {
 	// +optional
 	// +kubebuilder:validation:Minimum=60
 	DeleteAfterSeconds *int64 `json:"deleteAfterSeconds,omitempty"`
-}
-
-// KindStatus defines the observed state of Kind.
-type KindStatus struct {
-	// Phase indicates the current lifecycle phase of the Kind cluster.
-	// E.g., Pending, Provisioning, Ready, Deleting, Error.
-	// +optional
-	Phase KindPhase `json:"phase,omitempty"`
 
-	// Message provides a human-readable status message.
+	// ExtendBy postpones the expiration set by DeleteAfterSeconds (e.g. "4h"). Raise it to give a
+	// cluster more time; lowering it brings the expiration forward again. The expiration can also
+	// be postponed with the mapt.redhat.com/extend-ttl annotation.
 	// +optional
-	Message string `json:"message,omitempty"`
-
-	// Conditions represent the latest available observations of the Kind cluster state.
-	// +optional
-	// +patchMergeKey=type
-	// +patchStrategy=merge
-	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	ExtendBy *metav1.Duration `json:"extendBy,omitempty"`
 
-	// AWSInstanceID is the ID of the EC2 instance provisioned with a Kind Cluster.
+	// InactivityTimeout expires the cluster once its activity Lease has not been renewed for this
+	// long (e.g. "2h"). The operator creates the Lease, named in status.activityLeaseName, once the
+	// cluster runs; consumers keep the cluster alive by updating the spec.renewTime of the Lease.
 	// +optional
-	AWSInstanceID *string `json:"awsInstanceID,omitempty"`
+	InactivityTimeout *metav1.Duration `json:"inactivityTimeout,omitempty"`
+}
 
-	// KubeconfigSecretName is the name of the Kubernetes Secret where the cluster's
-	// kubeconfig has been stored. This will match `spec.outputKubeconfigSecretName` if provided,
-	// or be a generated name.
-	// +optional
-	KubeconfigSecretName *string `json:"kubeconfigSecretName,omitempty"`
+// KindStatus defines the observed state of Kind.
+type KindStatus struct {
+	ClusterStatus `json:",inline"`
 
 	// KindVersion is the actual Kubernetes version of the provisioned Kind cluster.
 	// +optional
 	KindVersion *string `json:"kindVersion,omitempty"`
 
-	// ClusterReady indicates if the Kind cluster is fully provisioned and accessible.
+	// ProvisionedSpecHash is a hash of the machineConfig and kindClusterConfig the running cluster
+	// was provisioned with. It is compared with the spec to detect changes.
 	// +optional
-	ClusterReady bool `json:"clusterReady,omitempty"`
+	ProvisionedSpecHash string `json:"provisionedSpecHash,omitempty"`
 
-	// AveragePrice reports the average acquisition price of the spot instance(s).
-	// This field is a string to allow for currency.
+	// UpdateProvisionId is the id of the backend of the replacement cluster being provisioned by a
+	// BlueGreen update.
 	// +optional
-	AveragePrice string `json:"averagePrice,omitempty"`
+	UpdateProvisionId *string `json:"updateProvisionId,omitempty"`
 
-	// ExpirationTimestamp indicates when the cluster is scheduled to be terminated, based on TerminationPolicy.
+	// RetiredProvisionId is the id of the backend of a cluster replaced by a BlueGreen update that
+	// has not been destroyed yet.
 	// +optional
-	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
+	RetiredProvisionId *string `json:"retiredProvisionId,omitempty"`
 
-	// ProvisionId is the id of the backend used by the Kind provisioning tool.
-	ProvisionId *string `json:"provisionId,omitempty"`
+	// Addons reports the installation of each add-on of spec.addons.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Addons []AddonStatus `json:"addons,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -190,3 +388,8 @@ func (a *Kind) GetKindSecretName() string {
 	}
 	return fmt.Sprintf("kindspot-%s-kubeconfig", a.Name)
 }
+
+// GetClusterStatus returns the status shared with the other cluster types.
+func (a *Kind) GetClusterStatus() *ClusterStatus {
+	return &a.Status.ClusterStatus
+}
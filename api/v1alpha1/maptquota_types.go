@@ -0,0 +1,115 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MaptQuotaSpec defines the limits enforced on the clusters of a namespace.
+// A nil limit means the dimension is not constrained.
+type MaptQuotaSpec struct {
+	// MaxClusters caps the number of Kind and Openshift resources in the namespace.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxClusters *int32 `json:"maxClusters,omitempty"`
+
+	// MaxCPUs caps the sum of machineConfig.cpus across all clusters in the namespace.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxCPUs *int32 `json:"maxCPUs,omitempty"`
+
+	// MaxMemoryGiB caps the sum of machineConfig.memoryGiB across all clusters in the namespace.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxMemoryGiB *int32 `json:"maxMemoryGiB,omitempty"`
+
+	// MaxGPUClusters caps the number of clusters requesting GPU instances in the namespace.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxGPUClusters *int32 `json:"maxGPUClusters,omitempty"`
+
+	// MaxLifetime caps the lifetime of every cluster in the namespace, measured from the start of
+	// provisioning. Clusters are deleted once it elapses, whatever their termination policy and
+	// its extensions.
+	// +optional
+	MaxLifetime *metav1.Duration `json:"maxLifetime,omitempty"`
+}
+
+// QuotaUsage reports the resources consumed by the clusters of a namespace.
+type QuotaUsage struct {
+	// Clusters is the number of Kind and Openshift resources counted against the quota.
+	// +optional
+	Clusters int32 `json:"clusters,omitempty"`
+
+	// CPUs is the sum of machineConfig.cpus of the counted clusters.
+	// +optional
+	CPUs int32 `json:"cpus,omitempty"`
+
+	// MemoryGiB is the sum of machineConfig.memoryGiB of the counted clusters.
+	// +optional
+	MemoryGiB int32 `json:"memoryGiB,omitempty"`
+
+	// GPUClusters is the number of counted clusters requesting GPU instances.
+	// +optional
+	GPUClusters int32 `json:"gpuClusters,omitempty"`
+}
+
+// MaptQuotaStatus defines the observed state of MaptQuota.
+type MaptQuotaStatus struct {
+	// Used reports the resources currently consumed by active clusters in the namespace.
+	// +optional
+	Used QuotaUsage `json:"used,omitempty"`
+
+	// QueuedClusters is the number of clusters waiting in the Queued phase for capacity to free up.
+	// +optional
+	QueuedClusters int32 `json:"queuedClusters,omitempty"`
+
+	// LastUpdateTime records the last time the usage was computed.
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Clusters",type=integer,JSONPath=`.status.used.clusters`,description="Active clusters"
+// +kubebuilder:printcolumn:name="Max Clusters",type=integer,JSONPath=`.spec.maxClusters`,description="Maximum clusters"
+// +kubebuilder:printcolumn:name="Queued",type=integer,JSONPath=`.status.queuedClusters`,description="Queued clusters"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// MaptQuota is the Schema for the maptquotas API.
+// It caps the number of clusters and the compute they request within a namespace.
+type MaptQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MaptQuotaSpec   `json:"spec,omitempty"`
+	Status MaptQuotaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MaptQuotaList contains a list of MaptQuota.
+type MaptQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MaptQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MaptQuota{}, &MaptQuotaList{})
+}
@@ -23,34 +23,41 @@ import (
 )
 
 // OpenshiftSncPhase represents the lifecycle phase of a OpenshiftSnc resource.
-// +kubebuilder:validation:Enum=Pending;Provisioning;Running;Failed;Deleting
-type OpenshiftSncPhase string
+type OpenshiftSncPhase = ClusterPhase
 
 const (
 	// OpenshiftSnc lifecycle phases
-	OpenshiftSncPhasePending OpenshiftSncPhase = "Pending"
+	OpenshiftSncPhasePending = ClusterPhasePending
+	// OpenshiftSncPhaseQueued indicates that the OpenshiftSnc cluster is waiting for capacity to free up.
+	// This phase is used when creating the cluster would exceed a MaptQuota of its namespace or
+	// when the operator concurrency limits are reached; provisioning starts automatically once
+	// enough capacity is available.
+	OpenshiftSncPhaseQueued = ClusterPhaseQueued
 	// OpenshiftSncPhaseProvisioning indicates that the OpenshiftSnc cluster is being provisioned.
 	// This phase is used when the cluster is in the process of being set up, including
 	// provisioning the underlying infrastructure, installing the cluster components, etc.
 	// It is a transient state that occurs after the initial request to create the cluster
 	// and before it is fully operational.
 	// This phase is particularly useful for tracking the progress of cluster creation
-	OpenshiftSncPhaseProvisioning OpenshiftSncPhase = "Provisioning"
+	OpenshiftSncPhaseProvisioning = ClusterPhaseProvisioning
 	// OpenshiftSncPhaseRunning indicates that the OpenshiftSnc cluster is fully operational and ready for use.
 	// This phase is used when the cluster has been successfully provisioned, all components are running,
 	// and it is ready to accept workloads. It signifies that the cluster is in a healthy state and can be interacted with.
 	// This phase is typically reached after the Provisioning phase has completed successfully.
 	// It is important for users to know when the cluster is ready for deployment of applications and services.
-	OpenshiftSncPhaseRunning OpenshiftSncPhase = "Running"
+	OpenshiftSncPhaseRunning = ClusterPhaseRunning
+	// OpenshiftSncPhaseHibernated indicates that the instance of the OpenshiftSnc cluster is stopped
+	// because spec.hibernate is set. Its disks are preserved so that it can be resumed.
+	OpenshiftSncPhaseHibernated = ClusterPhaseHibernated
 	// OpenshiftSncPhaseFailed indicates that the OpenshiftSnc cluster failed to provision or encountered an error.
 	// This phase is used when there was an issue during the provisioning process, such as infrastructure
 	// failures, configuration errors, or other problems that prevent the cluster from being created successfully.
-	OpenshiftSncPhaseFailed OpenshiftSncPhase = "Failed"
+	OpenshiftSncPhaseFailed = ClusterPhaseFailed
 	// OpenshiftSncPhaseDeleting indicates that the OpenshiftSnc cluster is in the process of being deleted.
 	// This phase is used when a request has been made to delete the cluster, and the
 	// controller is actively working to clean up the resources associated with the cluster.
 	// It signifies that the cluster is no longer available for use and that the deletion process is ongoing.
-	OpenshiftSncPhaseDeleting OpenshiftSncPhase = "Deleting"
+	OpenshiftSncPhaseDeleting = ClusterPhaseDeleting
 )
 
 // OpenshiftSpec defines the desired state of Openshift.
@@ -67,6 +74,49 @@ type OpenshiftSpec struct {
 
 	// TerminationPolicy defines the policy for terminating the Openshift cluster.
 	TerminationPolicy TerminationPolicy `json:"terminationPolicy"`
+
+	// Priority orders the cluster in the provisioning queue when the operator concurrency limits
+	// are reached. Clusters with a higher priority are admitted first; clusters with the same
+	// priority are admitted in creation order.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// StateBackend selects where the provisioning state of the cluster is stored.
+	// When omitted, the operator default backend is used. It cannot be changed once set.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="stateBackend is immutable"
+	StateBackend *StateBackend `json:"stateBackend,omitempty"`
+
+	// ProvisioningTimeout bounds how long provisioning may take (e.g. "45m"). When it elapses,
+	// provisioning is aborted, the partially created resources are destroyed and the cluster
+	// is marked as Failed with the ProvisioningTimedOut reason. When omitted, provisioning is not bounded.
+	// +optional
+	ProvisioningTimeout *metav1.Duration `json:"provisioningTimeout,omitempty"`
+
+	// Hibernate stops the instance of a running cluster, keeping its disks, and moves it to the
+	// Hibernated phase. Setting it back to false starts the instance again; the kubeconfig of the
+	// access Secret is pointed at the new address of the cluster, still verifying its certificate
+	// against the address it was issued for. Hibernated time is not billed. Spot instances cannot
+	// be stopped, so it requires machineConfig.useSpotInstances to be false.
+	// +optional
+	Hibernate bool `json:"hibernate,omitempty"`
+
+	// Schedule defines when the cluster should be up. Outside its window, the instance of an
+	// on-demand cluster is stopped as with spec.hibernate, while a spot cluster, whose instance
+	// cannot be stopped, is destroyed and provisioned again when the window opens.
+	// +optional
+	Schedule *Schedule `json:"schedule,omitempty"`
+
+	// GPUStack installs the Node Feature Discovery and NVIDIA GPU operators on the cluster, so that
+	// pods can request nvidia.com/gpu. It requires machineConfig.gpu.
+	// +optional
+	GPUStack *GPUStack `json:"gpuStack,omitempty"`
+
+	// Network restricts how the instance of the cluster can be reached. It cannot be changed once
+	// set.
+	// +optional
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="network is immutable"
+	Network *NetworkConfig `json:"network,omitempty"`
 }
 
 type OpenshiftClusterConfig struct {
@@ -83,66 +133,7 @@ type OpenshiftClusterConfig struct {
 // It is used to communicate the lifecycle status of the cluster to users and other components in the system.
 // The status includes fields for phase, message, conditions, observed generation, and other relevant information
 type OpenshiftStatus struct {
-	// Phase indicates the current lifecycle phase of the Kind cluster.
-	// This field is used to track the progress of the cluster through its lifecycle stages.
-	// Possible values include:
-	// - Pending: The cluster is being created.
-	// - Provisioning: The cluster is being provisioned, including setting up the infrastructure and
-	//   installing the necessary components.
-	// - Running: The cluster is fully operational and ready for use
-	// E.g., Pending, Provisioning, Ready, Deleting, Error.
-	// +optional
-	Phase OpenshiftSncPhase `json:"phase,omitempty"`
-
-	// Message provides a human-readable status message.
-	// This field is used to convey additional information about the current state of the cluster,
-	// such as errors, warnings, or important updates.
-	// It can be used to provide context about the cluster's status, such as whether it
-	// is currently being provisioned, if there are any issues, or if it is ready for use.
-	// +optional
-	Message string `json:"message,omitempty"`
-
-	// Conditions are used to provide detailed information about the state of the cluster,
-	// including whether it is ready, if there are any issues, and other relevant status information
-	// +optional
-	// +patchMergeKey=type
-	// +patchStrategy=merge
-	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
-
-	// ObservedGeneration is the .metadata.generation that was last processed by the controller.
-	// This field is used to track the version of the resource that the controller has observed.
-	// It helps ensure that the controller is working with the most recent version of the resource
-	// and can be used to detect changes in the resource that may require action.
-	// +optional
-	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
-
-	// AWSInstanceID is the ID of the EC2 instance provisioned with a Kind Cluster.
-	// This field is used to track the specific instance that is running the Kind cluster.
-	// It is particularly useful for managing the lifecycle of the cluster and for debugging purposes.
-	// +optional
-	AWSInstanceID *string `json:"awsInstanceID,omitempty"` // NOTE: Consider replacing "Kind Cluster" with "Openshift cluster" for clarity.
-
-	// KubeconfigSecretName is the name of the Kubernetes Secret where the cluster's
-	// kubeconfig has been stored. This field is used to reference the secret that contains
-	// the kubeconfig file for accessing the Openshift cluster.
-	// +optional
-	KubeconfigSecretName *string `json:"kubeconfigSecretName,omitempty"`
-
-	// ClusterReady indicates if the Kind cluster is fully provisioned and accessible.
-	// This field is used to determine if the cluster is ready for use, meaning that all
-	// +optional
-	ClusterReady bool `json:"clusterReady,omitempty"`
-
-	// This field is used to provide information about the cost of the spot instances used
-	// for the Openshift cluster. It can help users understand the financial implications of
-	// using spot instances for their cluster.
-	// +optional
-	AveragePrice string `json:"averagePrice,omitempty"`
-
-	// ProvisionStartTime records when the provisioning process began.
-	// This field is used to track the start time of the provisioning process for the Openshift cluster.
-	// +optional
-	ProvisionStartTime *metav1.Time `json:"provisionStartTime,omitempty"`
+	ClusterStatus `json:",inline"`
 
 	// LastUpdateTime records the last time the status was updated.
 	// This field is used to track when the status of the Openshift cluster was last modified.
@@ -150,14 +141,6 @@ type OpenshiftStatus struct {
 	// This is particularly useful for monitoring and debugging purposes.
 	// +optional
 	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
-
-	// ExpirationTimestamp indicates when the cluster is scheduled to be terminated, based on TerminationPolicy.
-	// This field is used to specify when the Openshift cluster is expected to be terminated.
-	// +optional
-	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
-
-	// This field is used to track the specific provisioning session for the Openshift cluster.
-	ProvisionId *string `json:"provisionId,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -192,3 +175,8 @@ func init() {
 func (a *Openshift) GetOpenshiftSncSecretName() string {
 	return fmt.Sprintf("openshift-%s-kubeconfig", a.Name)
 }
+
+// GetClusterStatus returns the status shared with the other cluster types.
+func (a *Openshift) GetClusterStatus() *ClusterStatus {
+	return &a.Status.ClusterStatus
+}
@@ -21,14 +21,44 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddonStatus) DeepCopyInto(out *AddonStatus) {
+	*out = *in
+	if in.LastAppliedTime != nil {
+		in, out := &in.LastAppliedTime, &out.LastAppliedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddonStatus.
+func (in *AddonStatus) DeepCopy() *AddonStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AddonStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudConfig) DeepCopyInto(out *CloudConfig) {
 	*out = *in
 	out.CredentialsSecretRef = in.CredentialsSecretRef
+	if in.Regions != nil {
+		in, out := &in.Regions, &out.Regions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AvailabilityZones != nil {
+		in, out := &in.AvailabilityZones, &out.AvailabilityZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudConfig.
@@ -36,79 +66,741 @@ func (in *CloudConfig) DeepCopy() *CloudConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(CloudConfig)
+	out := new(CloudConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBootstrap) DeepCopyInto(out *ClusterBootstrap) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBootstrap.
+func (in *ClusterBootstrap) DeepCopy() *ClusterBootstrap {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBootstrap)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterBootstrap) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBootstrapList) DeepCopyInto(out *ClusterBootstrapList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterBootstrap, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBootstrapList.
+func (in *ClusterBootstrapList) DeepCopy() *ClusterBootstrapList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBootstrapList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterBootstrapList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBootstrapSpec) DeepCopyInto(out *ClusterBootstrapSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	in.Template.DeepCopyInto(&out.Template)
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBootstrapSpec.
+func (in *ClusterBootstrapSpec) DeepCopy() *ClusterBootstrapSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBootstrapSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBootstrapStatus) DeepCopyInto(out *ClusterBootstrapStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBootstrapStatus.
+func (in *ClusterBootstrapStatus) DeepCopy() *ClusterBootstrapStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBootstrapStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterCost) DeepCopyInto(out *ClusterCost) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterCost.
+func (in *ClusterCost) DeepCopy() *ClusterCost {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterCost)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterReference) DeepCopyInto(out *ClusterReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReference.
+func (in *ClusterReference) DeepCopy() *ClusterReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AWSInstanceID != nil {
+		in, out := &in.AWSInstanceID, &out.AWSInstanceID
+		*out = new(string)
+		**out = **in
+	}
+	if in.ProvisioningAttempts != nil {
+		in, out := &in.ProvisioningAttempts, &out.ProvisioningAttempts
+		*out = make([]ProvisioningAttempt, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.KubeconfigSecretName != nil {
+		in, out := &in.KubeconfigSecretName, &out.KubeconfigSecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.ExpirationTimestamp != nil {
+		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.ProvisionId != nil {
+		in, out := &in.ProvisionId, &out.ProvisionId
+		*out = new(string)
+		**out = **in
+	}
+	if in.ProvisionStartTime != nil {
+		in, out := &in.ProvisionStartTime, &out.ProvisionStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.InfrastructureStartTime != nil {
+		in, out := &in.InfrastructureStartTime, &out.InfrastructureStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.QueuePosition != nil {
+		in, out := &in.QueuePosition, &out.QueuePosition
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Cost != nil {
+		in, out := &in.Cost, &out.Cost
+		*out = new(ClusterCost)
+		**out = **in
+	}
+	if in.PreviousUsage != nil {
+		in, out := &in.PreviousUsage, &out.PreviousUsage
+		*out = new(ClusterUsage)
+		**out = **in
+	}
+	if in.HibernatedAt != nil {
+		in, out := &in.HibernatedAt, &out.HibernatedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.HibernatedDuration != nil {
+		in, out := &in.HibernatedDuration, &out.HibernatedDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.TTLExtensions != nil {
+		in, out := &in.TTLExtensions, &out.TTLExtensions
+		*out = make([]TTLExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NextTransition != nil {
+		in, out := &in.NextTransition, &out.NextTransition
+		*out = (*in).DeepCopy()
+	}
+	if in.StateBackend != nil {
+		in, out := &in.StateBackend, &out.StateBackend
+		*out = new(StateBackend)
+		**out = **in
+	}
+	if in.GPUStack != nil {
+		in, out := &in.GPUStack, &out.GPUStack
+		*out = new(GPUStackStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
+func (in *ClusterStatus) DeepCopy() *ClusterStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterUsage) DeepCopyInto(out *ClusterUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterUsage.
+func (in *ClusterUsage) DeepCopy() *ClusterUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DiskConfig) DeepCopyInto(out *DiskConfig) {
+	*out = *in
+	if in.IOPS != nil {
+		in, out := &in.IOPS, &out.IOPS
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Throughput != nil {
+		in, out := &in.Throughput, &out.Throughput
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DiskConfig.
+func (in *DiskConfig) DeepCopy() *DiskConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DiskConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FallbackStrategy) DeepCopyInto(out *FallbackStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FallbackStrategy.
+func (in *FallbackStrategy) DeepCopy() *FallbackStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(FallbackStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUMIG) DeepCopyInto(out *GPUMIG) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUMIG.
+func (in *GPUMIG) DeepCopy() *GPUMIG {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUMIG)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPURequirements) DeepCopyInto(out *GPURequirements) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPURequirements.
+func (in *GPURequirements) DeepCopy() *GPURequirements {
+	if in == nil {
+		return nil
+	}
+	out := new(GPURequirements)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUStack) DeepCopyInto(out *GPUStack) {
+	*out = *in
+	if in.TimeSlicing != nil {
+		in, out := &in.TimeSlicing, &out.TimeSlicing
+		*out = new(GPUTimeSlicing)
+		**out = **in
+	}
+	if in.MIG != nil {
+		in, out := &in.MIG, &out.MIG
+		*out = new(GPUMIG)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUStack.
+func (in *GPUStack) DeepCopy() *GPUStack {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUStack)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUStackStatus) DeepCopyInto(out *GPUStackStatus) {
+	*out = *in
+	if in.LastAppliedTime != nil {
+		in, out := &in.LastAppliedTime, &out.LastAppliedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUStackStatus.
+func (in *GPUStackStatus) DeepCopy() *GPUStackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUStackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GPUTimeSlicing) DeepCopyInto(out *GPUTimeSlicing) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GPUTimeSlicing.
+func (in *GPUTimeSlicing) DeepCopy() *GPUTimeSlicing {
+	if in == nil {
+		return nil
+	}
+	out := new(GPUTimeSlicing)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Kind) DeepCopyInto(out *Kind) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kind.
+func (in *Kind) DeepCopy() *Kind {
+	if in == nil {
+		return nil
+	}
+	out := new(Kind)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Kind) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KindAddon) DeepCopyInto(out *KindAddon) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindAddon.
+func (in *KindAddon) DeepCopy() *KindAddon {
+	if in == nil {
+		return nil
+	}
+	out := new(KindAddon)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KindClusterConfig) DeepCopyInto(out *KindClusterConfig) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = new(KindNodes)
+		**out = **in
+	}
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RuntimeConfig != nil {
+		in, out := &in.RuntimeConfig, &out.RuntimeConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Networking != nil {
+		in, out := &in.Networking, &out.Networking
+		*out = new(KindNetworking)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindClusterConfig.
+func (in *KindClusterConfig) DeepCopy() *KindClusterConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(KindClusterConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KindList) DeepCopyInto(out *KindList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Kind, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindList.
+func (in *KindList) DeepCopy() *KindList {
+	if in == nil {
+		return nil
+	}
+	out := new(KindList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KindList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KindNetworking) DeepCopyInto(out *KindNetworking) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindNetworking.
+func (in *KindNetworking) DeepCopy() *KindNetworking {
+	if in == nil {
+		return nil
+	}
+	out := new(KindNetworking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KindNodes) DeepCopyInto(out *KindNodes) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindNodes.
+func (in *KindNodes) DeepCopy() *KindNodes {
+	if in == nil {
+		return nil
+	}
+	out := new(KindNodes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KindSpec) DeepCopyInto(out *KindSpec) {
+	*out = *in
+	in.CloudConfig.DeepCopyInto(&out.CloudConfig)
+	in.MachineConfig.DeepCopyInto(&out.MachineConfig)
+	in.KindClusterConfig.DeepCopyInto(&out.KindClusterConfig)
+	if in.TerminationPolicy != nil {
+		in, out := &in.TerminationPolicy, &out.TerminationPolicy
+		*out = new(TerminationPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StateBackend != nil {
+		in, out := &in.StateBackend, &out.StateBackend
+		*out = new(StateBackend)
+		**out = **in
+	}
+	if in.ProvisioningTimeout != nil {
+		in, out := &in.ProvisioningTimeout, &out.ProvisioningTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(Schedule)
+		**out = **in
+	}
+	if in.Addons != nil {
+		in, out := &in.Addons, &out.Addons
+		*out = make([]KindAddon, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GPUStack != nil {
+		in, out := &in.GPUStack, &out.GPUStack
+		*out = new(GPUStack)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindSpec.
+func (in *KindSpec) DeepCopy() *KindSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KindSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Kind) DeepCopyInto(out *Kind) {
+func (in *KindStatus) DeepCopyInto(out *KindStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	in.ClusterStatus.DeepCopyInto(&out.ClusterStatus)
+	if in.KindVersion != nil {
+		in, out := &in.KindVersion, &out.KindVersion
+		*out = new(string)
+		**out = **in
+	}
+	if in.UpdateProvisionId != nil {
+		in, out := &in.UpdateProvisionId, &out.UpdateProvisionId
+		*out = new(string)
+		**out = **in
+	}
+	if in.RetiredProvisionId != nil {
+		in, out := &in.RetiredProvisionId, &out.RetiredProvisionId
+		*out = new(string)
+		**out = **in
+	}
+	if in.Addons != nil {
+		in, out := &in.Addons, &out.Addons
+		*out = make([]AddonStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Kind.
-func (in *Kind) DeepCopy() *Kind {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindStatus.
+func (in *KindStatus) DeepCopy() *KindStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Kind)
+	out := new(KindStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *Kind) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
+	*out = *in
+	if in.GPURequirements != nil {
+		in, out := &in.GPURequirements, &out.GPURequirements
+		*out = new(GPURequirements)
+		**out = **in
 	}
-	return nil
+	if in.InstanceTypes != nil {
+		in, out := &in.InstanceTypes, &out.InstanceTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludedInstanceTypes != nil {
+		in, out := &in.ExcludedInstanceTypes, &out.ExcludedInstanceTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Disk != nil {
+		in, out := &in.Disk, &out.Disk
+		*out = new(DiskConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SpotPriceIncreasePercentage != nil {
+		in, out := &in.SpotPriceIncreasePercentage, &out.SpotPriceIncreasePercentage
+		*out = new(int)
+		**out = **in
+	}
+	if in.Fallback != nil {
+		in, out := &in.Fallback, &out.Fallback
+		*out = make([]FallbackStrategy, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfig.
+func (in *MachineConfig) DeepCopy() *MachineConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfig)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KindClusterConfig) DeepCopyInto(out *KindClusterConfig) {
+func (in *MaptQuota) DeepCopyInto(out *MaptQuota) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindClusterConfig.
-func (in *KindClusterConfig) DeepCopy() *KindClusterConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaptQuota.
+func (in *MaptQuota) DeepCopy() *MaptQuota {
 	if in == nil {
 		return nil
 	}
-	out := new(KindClusterConfig)
+	out := new(MaptQuota)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MaptQuota) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KindList) DeepCopyInto(out *KindList) {
+func (in *MaptQuotaList) DeepCopyInto(out *MaptQuotaList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Kind, len(*in))
+		*out = make([]MaptQuota, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindList.
-func (in *KindList) DeepCopy() *KindList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaptQuotaList.
+func (in *MaptQuotaList) DeepCopy() *MaptQuotaList {
 	if in == nil {
 		return nil
 	}
-	out := new(KindList)
+	out := new(MaptQuotaList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KindList) DeepCopyObject() runtime.Object {
+func (in *MaptQuotaList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -116,97 +808,81 @@ func (in *KindList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KindSpec) DeepCopyInto(out *KindSpec) {
+func (in *MaptQuotaSpec) DeepCopyInto(out *MaptQuotaSpec) {
 	*out = *in
-	out.CloudConfig = in.CloudConfig
-	in.MachineConfig.DeepCopyInto(&out.MachineConfig)
-	out.KindClusterConfig = in.KindClusterConfig
-	if in.TerminationPolicy != nil {
-		in, out := &in.TerminationPolicy, &out.TerminationPolicy
-		*out = new(TerminationPolicy)
-		(*in).DeepCopyInto(*out)
+	if in.MaxClusters != nil {
+		in, out := &in.MaxClusters, &out.MaxClusters
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxCPUs != nil {
+		in, out := &in.MaxCPUs, &out.MaxCPUs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxMemoryGiB != nil {
+		in, out := &in.MaxMemoryGiB, &out.MaxMemoryGiB
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxGPUClusters != nil {
+		in, out := &in.MaxGPUClusters, &out.MaxGPUClusters
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxLifetime != nil {
+		in, out := &in.MaxLifetime, &out.MaxLifetime
+		*out = new(v1.Duration)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindSpec.
-func (in *KindSpec) DeepCopy() *KindSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaptQuotaSpec.
+func (in *MaptQuotaSpec) DeepCopy() *MaptQuotaSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KindSpec)
+	out := new(MaptQuotaSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KindStatus) DeepCopyInto(out *KindStatus) {
+func (in *MaptQuotaStatus) DeepCopyInto(out *MaptQuotaStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.AWSInstanceID != nil {
-		in, out := &in.AWSInstanceID, &out.AWSInstanceID
-		*out = new(string)
-		**out = **in
-	}
-	if in.KubeconfigSecretName != nil {
-		in, out := &in.KubeconfigSecretName, &out.KubeconfigSecretName
-		*out = new(string)
-		**out = **in
-	}
-	if in.KindVersion != nil {
-		in, out := &in.KindVersion, &out.KindVersion
-		*out = new(string)
-		**out = **in
-	}
-	if in.ExpirationTimestamp != nil {
-		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
+	out.Used = in.Used
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
 		*out = (*in).DeepCopy()
 	}
-	if in.ProvisionId != nil {
-		in, out := &in.ProvisionId, &out.ProvisionId
-		*out = new(string)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KindStatus.
-func (in *KindStatus) DeepCopy() *KindStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaptQuotaStatus.
+func (in *MaptQuotaStatus) DeepCopy() *MaptQuotaStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(KindStatus)
+	out := new(MaptQuotaStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MachineConfig) DeepCopyInto(out *MachineConfig) {
+func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
 	*out = *in
-	if in.SpotPriceIncreasePercentage != nil {
-		in, out := &in.SpotPriceIncreasePercentage, &out.SpotPriceIncreasePercentage
-		*out = new(int)
-		**out = **in
-	}
-	if in.Tags != nil {
-		in, out := &in.Tags, &out.Tags
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.AllowedCIDRs != nil {
+		in, out := &in.AllowedCIDRs, &out.AllowedCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfig.
-func (in *MachineConfig) DeepCopy() *MachineConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfig.
+func (in *NetworkConfig) DeepCopy() *NetworkConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(MachineConfig)
+	out := new(NetworkConfig)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -291,6 +967,31 @@ func (in *OpenshiftSpec) DeepCopyInto(out *OpenshiftSpec) {
 	out.OpenshiftClusterConfig = in.OpenshiftClusterConfig
 	in.MachineConfig.DeepCopyInto(&out.MachineConfig)
 	in.TerminationPolicy.DeepCopyInto(&out.TerminationPolicy)
+	if in.StateBackend != nil {
+		in, out := &in.StateBackend, &out.StateBackend
+		*out = new(StateBackend)
+		**out = **in
+	}
+	if in.ProvisioningTimeout != nil {
+		in, out := &in.ProvisioningTimeout, &out.ProvisioningTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(Schedule)
+		**out = **in
+	}
+	if in.GPUStack != nil {
+		in, out := &in.GPUStack, &out.GPUStack
+		*out = new(GPUStack)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Network != nil {
+		in, out := &in.Network, &out.Network
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenshiftSpec.
@@ -306,40 +1007,11 @@ func (in *OpenshiftSpec) DeepCopy() *OpenshiftSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OpenshiftStatus) DeepCopyInto(out *OpenshiftStatus) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.AWSInstanceID != nil {
-		in, out := &in.AWSInstanceID, &out.AWSInstanceID
-		*out = new(string)
-		**out = **in
-	}
-	if in.KubeconfigSecretName != nil {
-		in, out := &in.KubeconfigSecretName, &out.KubeconfigSecretName
-		*out = new(string)
-		**out = **in
-	}
-	if in.ProvisionStartTime != nil {
-		in, out := &in.ProvisionStartTime, &out.ProvisionStartTime
-		*out = (*in).DeepCopy()
-	}
+	in.ClusterStatus.DeepCopyInto(&out.ClusterStatus)
 	if in.LastUpdateTime != nil {
 		in, out := &in.LastUpdateTime, &out.LastUpdateTime
 		*out = (*in).DeepCopy()
 	}
-	if in.ExpirationTimestamp != nil {
-		in, out := &in.ExpirationTimestamp, &out.ExpirationTimestamp
-		*out = (*in).DeepCopy()
-	}
-	if in.ProvisionId != nil {
-		in, out := &in.ProvisionId, &out.ProvisionId
-		*out = new(string)
-		**out = **in
-	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpenshiftStatus.
@@ -352,6 +1024,85 @@ func (in *OpenshiftStatus) DeepCopy() *OpenshiftStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisioningAttempt) DeepCopyInto(out *ProvisioningAttempt) {
+	*out = *in
+	out.Strategy = in.Strategy
+	in.StartTime.DeepCopyInto(&out.StartTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisioningAttempt.
+func (in *ProvisioningAttempt) DeepCopy() *ProvisioningAttempt {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisioningAttempt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaUsage) DeepCopyInto(out *QuotaUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaUsage.
+func (in *QuotaUsage) DeepCopy() *QuotaUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Schedule) DeepCopyInto(out *Schedule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Schedule.
+func (in *Schedule) DeepCopy() *Schedule {
+	if in == nil {
+		return nil
+	}
+	out := new(Schedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StateBackend) DeepCopyInto(out *StateBackend) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StateBackend.
+func (in *StateBackend) DeepCopy() *StateBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(StateBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TTLExtension) DeepCopyInto(out *TTLExtension) {
+	*out = *in
+	out.Duration = in.Duration
+	in.AppliedAt.DeepCopyInto(&out.AppliedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TTLExtension.
+func (in *TTLExtension) DeepCopy() *TTLExtension {
+	if in == nil {
+		return nil
+	}
+	out := new(TTLExtension)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TerminationPolicy) DeepCopyInto(out *TerminationPolicy) {
 	*out = *in
@@ -360,6 +1111,16 @@ func (in *TerminationPolicy) DeepCopyInto(out *TerminationPolicy) {
 		*out = new(int64)
 		**out = **in
 	}
+	if in.ExtendBy != nil {
+		in, out := &in.ExtendBy, &out.ExtendBy
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.InactivityTimeout != nil {
+		in, out := &in.InactivityTimeout, &out.InactivityTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TerminationPolicy.
@@ -17,15 +17,19 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -33,6 +37,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
@@ -40,6 +45,9 @@ import (
 	"github.com/konflux-ci/operator-toolkit/controller"
 	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
 	maptCtrl "github.com/mapt-oss/mapt-operator/internal/controller"
+	webhookmaptv1alpha1 "github.com/mapt-oss/mapt-operator/internal/webhook/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -64,6 +72,12 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var maxConcurrentOperations, maxConcurrentOperationsPerAccount, maxConcurrentReconciles int
+	var workspaceDir, workspaceSizeLimit string
+	var stateBackend, stateBackendBucket, stateBackendDir string
+	var provisioner string
+	var simulatedOpts clusters.SimulatedOptions
+	var simulatedFailureRates, simulatedKubeconfig string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -82,6 +96,42 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.IntVar(&maxConcurrentOperations, "max-concurrent-operations", 10,
+		"Maximum number of provisioning and deprovisioning operations running at once. Use 0 for no limit.")
+	flag.IntVar(&maxConcurrentOperationsPerAccount, "max-concurrent-operations-per-account", 5,
+		"Maximum number of provisioning and deprovisioning operations running at once per cloud credentials Secret. "+
+			"Use 0 for no limit. The "+concurrency.MaxConcurrentOperationsAnnotation+" annotation on a Secret overrides it.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 10,
+		"Number of Kind and Openshift clusters each controller reconciles at once. Provisioning runs inside "+
+			"reconciles, so at most this many operations of a cluster type run at once whatever the concurrency limits.")
+	flag.StringVar(&workspaceDir, "workspace-dir", filepath.Join(os.TempDir(), "mapt-workspaces"),
+		"The directory where provisioning operations get their scratch workspaces, e.g. an emptyDir or PVC mount.")
+	flag.StringVar(&workspaceSizeLimit, "workspace-size-limit", "",
+		"Maximum disk usage of all provisioning workspaces as a quantity, e.g. 10Gi. Leave empty for no limit.")
+	flag.StringVar(&stateBackend, "state-backend", string(maptv1alpha1.StateBackendS3),
+		"The default backend storing the provisioning state of clusters: S3, File or AzureBlob.")
+	flag.StringVar(&stateBackendBucket, "state-backend-bucket", "",
+		"The default S3 bucket or Azure Blob container of the state backend. "+
+			"S3 falls back to the bucket of the cloud credentials.")
+	flag.StringVar(&stateBackendDir, "state-backend-dir", "/var/lib/mapt/state",
+		"The directory holding the state of the File backend, e.g. a PVC mount.")
+	flag.StringVar(&provisioner, "provisioner", "mapt",
+		"The provisioner creating clusters: mapt, or simulated to run without cloud access for e2e tests and demos.")
+	flag.DurationVar(&simulatedOpts.Latency, "simulated-latency", 30*time.Second,
+		"How long the simulated provisioner takes to provision a cluster.")
+	flag.DurationVar(&simulatedOpts.DeprovisionLatency, "simulated-deprovision-latency", 10*time.Second,
+		"How long the simulated provisioner takes to deprovision a cluster.")
+	flag.StringVar(&simulatedFailureRates, "simulated-failure-rates", "",
+		"Failures injected by the simulated provisioner as reason=probability pairs, "+
+			"e.g. SpotCapacityUnavailable=0.1,QuotaExceeded=0.05.")
+	flag.Float64Var(&simulatedOpts.SpotPriceMin, "simulated-spot-price-min", 0.05,
+		"The lowest hourly spot price, in USD, reported by the simulated provisioner.")
+	flag.Float64Var(&simulatedOpts.SpotPriceMax, "simulated-spot-price-max", 0.5,
+		"The highest hourly spot price, in USD, reported by the simulated provisioner.")
+	flag.StringVar(&simulatedKubeconfig, "simulated-kubeconfig", string(clusters.DefaultSimulatedKubeconfigMode),
+		"How the simulated provisioner builds kubeconfigs: envtest starts a local API server per cluster "+
+			"(requires building with -tags simulated_envtest and KUBEBUILDER_ASSETS), static returns kubeconfigs "+
+			"pointing to an unreachable server.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -204,7 +254,19 @@ func main() {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
-	setUpControllers(mgr)
+	concurrency.ConfigureShared(maxConcurrentOperations, maxConcurrentOperationsPerAccount)
+	setUpWorkspaces(workspaceDir, workspaceSizeLimit)
+	if err := clusters.ConfigureStateBackends(clusters.StateBackendOptions{
+		Type:    maptv1alpha1.StateBackendType(stateBackend),
+		Bucket:  stateBackendBucket,
+		FileDir: stateBackendDir,
+	}); err != nil {
+		setupLog.Error(err, "invalid state backend configuration")
+		os.Exit(1)
+	}
+	setUpProvisioner(mgr, provisioner, simulatedOpts, simulatedFailureRates, simulatedKubeconfig)
+	setUpControllers(mgr, maxConcurrentReconciles)
+	setUpWebhooks(mgr)
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -239,11 +301,85 @@ func main() {
 	}
 }
 
-// setUpControllers sets up controllers.
-func setUpControllers(mgr ctrl.Manager) {
+// setUpWorkspaces configures where provisioning operations get their scratch directories.
+func setUpWorkspaces(dir, sizeLimit string) {
+	var maxBytes int64
+	if sizeLimit != "" {
+		limit, err := resource.ParseQuantity(sizeLimit)
+		if err != nil {
+			setupLog.Error(err, "invalid workspace size limit", "limit", sizeLimit)
+			os.Exit(1)
+		}
+		maxBytes = limit.Value()
+	}
+	if err := clusters.ConfigureWorkspaces(dir, maxBytes); err != nil {
+		setupLog.Error(err, "unable to set up provisioning workspaces", "dir", dir)
+		os.Exit(1)
+	}
+}
+
+// setUpProvisioner replaces the mapt provisioner with the simulated one when requested.
+func setUpProvisioner(mgr ctrl.Manager, provisioner string, opts clusters.SimulatedOptions, failureRates, kubeconfigMode string) {
+	switch provisioner {
+	case "mapt":
+		return
+	case "simulated":
+	default:
+		setupLog.Error(fmt.Errorf("unsupported provisioner %q", provisioner), "invalid provisioner")
+		os.Exit(1)
+	}
+
+	rates, err := clusters.ParseFailureRates(failureRates)
+	if err != nil {
+		setupLog.Error(err, "invalid simulated failure rates")
+		os.Exit(1)
+	}
+	opts.FailureRates = rates
+	opts.KubeconfigMode = clusters.SimulatedKubeconfigMode(kubeconfigMode)
+	if opts.KubeconfigMode != clusters.SimulatedKubeconfigEnvtest && opts.KubeconfigMode != clusters.SimulatedKubeconfigStatic {
+		setupLog.Error(fmt.Errorf("unsupported kubeconfig mode %q", kubeconfigMode), "invalid simulated kubeconfig mode")
+		os.Exit(1)
+	}
+
+	simulated := clusters.NewSimulatedProvisioner(opts)
+	clusters.SetDefaultProvisioner(simulated)
+	// Stop the simulated API servers together with the manager.
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return simulated.Stop()
+	})); err != nil {
+		setupLog.Error(err, "unable to register the simulated provisioner")
+		os.Exit(1)
+	}
+	setupLog.Info("using the simulated provisioner; no cloud resources will be created")
+}
+
+// setUpControllers sets up controllers, reconciling up to workers clusters of each type at once.
+func setUpControllers(mgr ctrl.Manager, workers int) {
+	if workers < 1 {
+		setupLog.Error(fmt.Errorf("invalid number of workers %d", workers), "max-concurrent-reconciles must be at least 1")
+		os.Exit(1)
+	}
+	maptCtrl.SetMaxConcurrentReconciles(workers)
 	err := controller.SetupControllers(mgr, nil, maptCtrl.EnabledControllers...)
 	if err != nil {
 		setupLog.Error(err, "unable to setup controllers")
 		os.Exit(1)
 	}
 }
+
+// setUpWebhooks sets up the admission webhooks unless ENABLE_WEBHOOKS is set to "false",
+// which is useful when running the manager locally without serving certificates.
+func setUpWebhooks(mgr ctrl.Manager) {
+	if os.Getenv("ENABLE_WEBHOOKS") == "false" {
+		return
+	}
+	if err := webhookmaptv1alpha1.SetupKindWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Kind")
+		os.Exit(1)
+	}
+	if err := webhookmaptv1alpha1.SetupOpenshiftWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Openshift")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,391 @@
+package clusterbootstrap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/controller/lifecycle"
+	"github.com/mapt-oss/mapt-operator/internal/metadata"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// accessVolume is the volume holding the access Secret of the cluster in bootstrap pods.
+	accessVolume = "mapt-cluster-access"
+	// accessMountPath is where the access Secret of the cluster is mounted in bootstrap containers.
+	accessMountPath = "/etc/mapt/cluster"
+)
+
+// ClusterBootstrapReconciler runs the Job of each ClusterBootstrap once its cluster is running, and
+// reports the outcome in the Bootstrapped condition of the cluster.
+type ClusterBootstrapReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *ClusterBootstrapReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("controller", "ClusterBootstrapReconciler", "resource", req.NamespacedName)
+
+	var bootstrap v1alpha1.ClusterBootstrap
+	if err := r.Get(ctx, req.NamespacedName, &bootstrap); err != nil {
+		if apierrors.IsNotFound(err) {
+			// The cluster the bootstrap targeted is unknown once it is gone, so every cluster of the
+			// namespace reporting the condition is checked.
+			return ctrl.Result{}, controllerutils.LogError(logger, r.syncNamespace(ctx, req.Namespace), "Failed to update the Bootstrapped condition of clusters")
+		}
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to fetch ClusterBootstrap resource")
+	}
+
+	cluster, err := r.cluster(ctx, bootstrap.Namespace, bootstrap.Spec.ClusterRef)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to fetch the cluster to bootstrap")
+	}
+
+	status := v1alpha1.ClusterBootstrapStatus{
+		Phase:   v1alpha1.BootstrapPhasePending,
+		Message: fmt.Sprintf("%s %s does not exist.", bootstrap.Spec.ClusterRef.Kind, bootstrap.Spec.ClusterRef.Name),
+	}
+	if cluster != nil {
+		if status, err = r.bootstrap(ctx, logger, &bootstrap, cluster); err != nil {
+			return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to run the bootstrap Job")
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(status, bootstrap.Status) {
+		original := bootstrap.DeepCopy()
+		bootstrap.Status = status
+		if err := r.Status().Patch(ctx, &bootstrap, client.MergeFrom(original)); err != nil {
+			return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to update ClusterBootstrap status")
+		}
+	}
+
+	if cluster != nil {
+		if err := r.syncCluster(ctx, cluster, &bootstrap); err != nil {
+			return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to update the Bootstrapped condition of the cluster")
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// bootstrap runs the Job of a bootstrap against the current provisioning of its cluster and
+// returns the resulting status. A bootstrap is only run once per provisioning: its outcome is
+// kept while the cluster is down, e.g. hibernated, and it runs again on a newly provisioned cluster.
+func (r *ClusterBootstrapReconciler) bootstrap(ctx context.Context, logger logr.Logger, bootstrap *v1alpha1.ClusterBootstrap,
+	cluster lifecycle.Cluster) (v1alpha1.ClusterBootstrapStatus, error) {
+	clusterStatus := cluster.GetClusterStatus()
+	status := *bootstrap.Status.DeepCopy()
+
+	running := cluster.GetDeletionTimestamp() == nil && clusterStatus.Phase == v1alpha1.ClusterPhaseRunning &&
+		clusterStatus.ProvisionId != nil && clusterStatus.KubeconfigSecretName != nil
+	if !running {
+		if clusterStatus.ProvisionId != nil && status.ProvisionId == *clusterStatus.ProvisionId {
+			return status, nil
+		}
+		return v1alpha1.ClusterBootstrapStatus{
+			Phase:   v1alpha1.BootstrapPhasePending,
+			Message: fmt.Sprintf("Waiting for %s %s to be running.", bootstrap.Spec.ClusterRef.Kind, bootstrap.Spec.ClusterRef.Name),
+		}, nil
+	}
+
+	provisionID := *clusterStatus.ProvisionId
+	if status.ProvisionId != provisionID {
+		if status.JobName != "" {
+			if err := r.deleteJob(ctx, bootstrap.Namespace, status.JobName); err != nil {
+				return status, err
+			}
+		}
+		status = v1alpha1.ClusterBootstrapStatus{
+			Phase:       v1alpha1.BootstrapPhaseRunning,
+			ProvisionId: provisionID,
+			JobName:     jobName(bootstrap.Name, provisionID),
+		}
+	}
+	if status.Phase == v1alpha1.BootstrapPhaseSucceeded || status.Phase == v1alpha1.BootstrapPhaseFailed {
+		return status, nil
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: status.JobName, Namespace: bootstrap.Namespace}, job)
+	if apierrors.IsNotFound(err) {
+		job = newJob(bootstrap, status.JobName, *clusterStatus.KubeconfigSecretName)
+		if err := controllerutil.SetControllerReference(bootstrap, job, r.Scheme); err != nil {
+			return status, err
+		}
+		if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+			return status, err
+		}
+		logger.Info("Bootstrap Job created.", "job", job.Name, "provisionId", provisionID)
+		now := metav1.Now()
+		status.StartTime = &now
+		status.Phase = v1alpha1.BootstrapPhaseRunning
+		status.Message = fmt.Sprintf("Job %s is running.", job.Name)
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	if status.StartTime == nil {
+		status.StartTime = &job.CreationTimestamp
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			status.Phase = v1alpha1.BootstrapPhaseSucceeded
+			status.Message = fmt.Sprintf("Job %s completed.", job.Name)
+			status.CompletionTime = completionTime(job, c)
+			return status, nil
+		case batchv1.JobFailed:
+			status.Phase = v1alpha1.BootstrapPhaseFailed
+			status.Message = fmt.Sprintf("Job %s failed: %s", job.Name, c.Message)
+			status.CompletionTime = completionTime(job, c)
+			return status, nil
+		}
+	}
+	status.Phase = v1alpha1.BootstrapPhaseRunning
+	status.Message = fmt.Sprintf("Job %s is running.", job.Name)
+	return status, nil
+}
+
+// deleteJob deletes the Job of a previous provisioning of the cluster, along with its pods.
+func (r *ClusterBootstrapReconciler) deleteJob(ctx context.Context, namespace, name string) error {
+	job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	return client.IgnoreNotFound(r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// syncCluster sets the Bootstrapped condition of a cluster from the ClusterBootstraps targeting it,
+// and removes it when there are none. current, when set, takes precedence over its cached copy,
+// which may not reflect its latest status yet.
+func (r *ClusterBootstrapReconciler) syncCluster(ctx context.Context, cluster lifecycle.Cluster, current *v1alpha1.ClusterBootstrap) error {
+	var bootstraps v1alpha1.ClusterBootstrapList
+	if err := r.List(ctx, &bootstraps, client.InNamespace(cluster.GetNamespace())); err != nil {
+		return err
+	}
+	ref := referenceTo(cluster)
+	var targeting []v1alpha1.ClusterBootstrap
+	for _, b := range bootstraps.Items {
+		if current != nil && b.Name == current.Name {
+			b = *current
+		}
+		if b.Spec.ClusterRef == ref && b.DeletionTimestamp == nil {
+			targeting = append(targeting, b)
+		}
+	}
+	sort.Slice(targeting, func(i, j int) bool { return targeting[i].Name < targeting[j].Name })
+
+	original := cluster.DeepCopyObject().(client.Object)
+	status := cluster.GetClusterStatus()
+	if len(targeting) == 0 {
+		meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionBootstrapped)
+	} else {
+		condStatus, reason, msg := bootstrappedCondition(targeting)
+		controllerutils.SetCondition(&status.Conditions, cluster.GetGeneration(), v1alpha1.ConditionBootstrapped, condStatus, reason, msg)
+	}
+	if equality.Semantic.DeepEqual(original.(lifecycle.Cluster).GetClusterStatus().Conditions, status.Conditions) {
+		return nil
+	}
+	return r.Status().Patch(ctx, cluster, client.MergeFrom(original))
+}
+
+// syncNamespace refreshes the Bootstrapped condition of the clusters of a namespace reporting it.
+func (r *ClusterBootstrapReconciler) syncNamespace(ctx context.Context, namespace string) error {
+	var kinds v1alpha1.KindList
+	if err := r.List(ctx, &kinds, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	var openshifts v1alpha1.OpenshiftList
+	if err := r.List(ctx, &openshifts, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+	var clusters []lifecycle.Cluster
+	for i := range kinds.Items {
+		clusters = append(clusters, &kinds.Items[i])
+	}
+	for i := range openshifts.Items {
+		clusters = append(clusters, &openshifts.Items[i])
+	}
+	for _, cluster := range clusters {
+		if meta.FindStatusCondition(cluster.GetClusterStatus().Conditions, v1alpha1.ConditionBootstrapped) == nil {
+			continue
+		}
+		if err := r.syncCluster(ctx, cluster, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cluster fetches the cluster a ClusterBootstrap points to.
+func (r *ClusterBootstrapReconciler) cluster(ctx context.Context, namespace string, ref v1alpha1.ClusterReference) (lifecycle.Cluster, error) {
+	var cluster lifecycle.Cluster
+	switch ref.Kind {
+	case "Kind":
+		cluster = &v1alpha1.Kind{}
+	case "Openshift":
+		cluster = &v1alpha1.Openshift{}
+	default:
+		return nil, fmt.Errorf("unsupported cluster kind %q", ref.Kind)
+	}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, cluster); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// bootstrapsOfCluster enqueues the ClusterBootstraps targeting a changed cluster.
+func (r *ClusterBootstrapReconciler) bootstrapsOfCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	var bootstraps v1alpha1.ClusterBootstrapList
+	if err := r.List(ctx, &bootstraps, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	ref := referenceTo(obj)
+	var requests []reconcile.Request
+	for _, b := range bootstraps.Items {
+		if b.Spec.ClusterRef == ref {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: b.Name, Namespace: b.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+func (r *ClusterBootstrapReconciler) Register(mgr ctrl.Manager, log *logr.Logger, _ crcluster.Cluster) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ClusterBootstrap{}).
+		Owns(&batchv1.Job{}).
+		Watches(&v1alpha1.Kind{}, handler.EnqueueRequestsFromMapFunc(r.bootstrapsOfCluster)).
+		Watches(&v1alpha1.Openshift{}, handler.EnqueueRequestsFromMapFunc(r.bootstrapsOfCluster)).
+		Named("clusterbootstrap").
+		Complete(r)
+}
+
+// newJob returns the Job running the pod template of a bootstrap with the access Secret of its
+// cluster mounted.
+func newJob(bootstrap *v1alpha1.ClusterBootstrap, name, secretName string) *batchv1.Job {
+	template := bootstrap.Spec.Template.DeepCopy()
+	if template.Spec.RestartPolicy == "" {
+		template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	}
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name:         accessVolume,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+	})
+	mountAccess(template.Spec.InitContainers)
+	mountAccess(template.Spec.Containers)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: bootstrap.Namespace,
+			Labels:    map[string]string{metadata.ClusterBootstrapLabel: bootstrap.Name},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          bootstrap.Spec.BackoffLimit,
+			ActiveDeadlineSeconds: bootstrap.Spec.ActiveDeadlineSeconds,
+			Template:              *template,
+		},
+	}
+}
+
+// mountAccess mounts the access Secret in the containers and points KUBECONFIG to it, unless a
+// container sets KUBECONFIG itself.
+func mountAccess(containers []corev1.Container) {
+	for i := range containers {
+		c := &containers[i]
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: accessVolume, MountPath: accessMountPath, ReadOnly: true})
+		if !hasEnv(c.Env, "KUBECONFIG") {
+			c.Env = append(c.Env, corev1.EnvVar{Name: "KUBECONFIG", Value: accessMountPath + "/kubeconfig"})
+		}
+	}
+}
+
+func hasEnv(env []corev1.EnvVar, name string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jobName returns the name of the Job bootstrapping a provisioning of the cluster. It stays within
+// the 63 characters Job names are limited to, as they are used as pod labels.
+func jobName(bootstrap, provisionID string) string {
+	suffix := strings.ReplaceAll(provisionID, "-", "")
+	if len(suffix) > 8 {
+		suffix = suffix[:8]
+	}
+	if len(bootstrap) > 54 {
+		bootstrap = strings.TrimRight(bootstrap[:54], "-.")
+	}
+	return bootstrap + "-" + suffix
+}
+
+// completionTime returns when a Job finished, falling back to the transition of its final condition.
+func completionTime(job *batchv1.Job, c batchv1.JobCondition) *metav1.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime
+	}
+	t := c.LastTransitionTime
+	return &t
+}
+
+// referenceTo returns the reference of a ClusterBootstrap pointing to the cluster.
+func referenceTo(cluster client.Object) v1alpha1.ClusterReference {
+	ref := v1alpha1.ClusterReference{Name: cluster.GetName()}
+	switch cluster.(type) {
+	case *v1alpha1.Kind:
+		ref.Kind = "Kind"
+	case *v1alpha1.Openshift:
+		ref.Kind = "Openshift"
+	}
+	return ref
+}
+
+// bootstrappedCondition returns the status, reason and message of the Bootstrapped condition of a
+// cluster targeted by the given bootstraps. Failures are reported first.
+func bootstrappedCondition(bootstraps []v1alpha1.ClusterBootstrap) (metav1.ConditionStatus, string, string) {
+	var failed, pending []string
+	for _, b := range bootstraps {
+		switch b.Status.Phase {
+		case v1alpha1.BootstrapPhaseSucceeded:
+		case v1alpha1.BootstrapPhaseFailed:
+			failed = append(failed, fmt.Sprintf("%s: %s", b.Name, b.Status.Message))
+		default:
+			pending = append(pending, b.Name)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		return metav1.ConditionFalse, "BootstrapFailed", "ClusterBootstraps failed: " + strings.Join(failed, "; ")
+	case len(pending) > 0:
+		return metav1.ConditionFalse, "Bootstrapping", "Waiting for ClusterBootstraps " + strings.Join(pending, ", ") + "."
+	}
+	return metav1.ConditionTrue, "Bootstrapped", fmt.Sprintf("All %d ClusterBootstraps succeeded.", len(bootstraps))
+}
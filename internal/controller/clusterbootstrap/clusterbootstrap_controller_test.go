@@ -0,0 +1,170 @@
+package clusterbootstrap
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+)
+
+var _ = Describe("ClusterBootstrapReconciler", func() {
+	const (
+		Namespace   = "default"
+		ClusterName = "my-kind-cluster"
+		ProvisionID = "4f1c2a9e-0000-0000-0000-000000000000"
+	)
+
+	var (
+		reconciler *ClusterBootstrapReconciler
+		fakeClient client.Client
+		kindObj    *maptv1alpha1.Kind
+		bootstrap  *maptv1alpha1.ClusterBootstrap
+		req        ctrl.Request
+	)
+
+	BeforeEach(func() {
+		Expect(maptv1alpha1.AddToScheme(scheme.Scheme)).To(Succeed())
+		kindObj = &maptv1alpha1.Kind{
+			ObjectMeta: metav1.ObjectMeta{Name: ClusterName, Namespace: Namespace},
+			Status: maptv1alpha1.KindStatus{ClusterStatus: maptv1alpha1.ClusterStatus{
+				Phase:                maptv1alpha1.ClusterPhaseRunning,
+				ProvisionId:          ptr.To(ProvisionID),
+				KubeconfigSecretName: ptr.To("my-kind-cluster-access"),
+			}},
+		}
+		bootstrap = &maptv1alpha1.ClusterBootstrap{
+			ObjectMeta: metav1.ObjectMeta{Name: "seed", Namespace: Namespace},
+			Spec: maptv1alpha1.ClusterBootstrapSpec{
+				ClusterRef:   maptv1alpha1.ClusterReference{Kind: "Kind", Name: ClusterName},
+				BackoffLimit: ptr.To[int32](2),
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "seed", Image: "seed:latest"}},
+				}},
+			},
+		}
+		req = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(bootstrap)}
+	})
+
+	JustBeforeEach(func() {
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(kindObj, bootstrap).
+			WithStatusSubresource(kindObj, bootstrap).
+			Build()
+		reconciler = &ClusterBootstrapReconciler{Client: fakeClient, Scheme: scheme.Scheme}
+	})
+
+	reconcileBootstrap := func() (*maptv1alpha1.ClusterBootstrap, *maptv1alpha1.Kind) {
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		updated := &maptv1alpha1.ClusterBootstrap{}
+		Expect(fakeClient.Get(ctx, req.NamespacedName, updated)).To(Succeed())
+		cluster := &maptv1alpha1.Kind{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), cluster)).To(Succeed())
+		return updated, cluster
+	}
+
+	finishJob := func(name string, condition batchv1.JobConditionType, message string) {
+		job := &batchv1.Job{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Name: name, Namespace: Namespace}, job)).To(Succeed())
+		job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+			Type:               condition,
+			Status:             corev1.ConditionTrue,
+			Message:            message,
+			LastTransitionTime: metav1.Now(),
+		})
+		Expect(fakeClient.Status().Update(ctx, job)).To(Succeed())
+	}
+
+	Context("when the cluster is not running yet", func() {
+		BeforeEach(func() {
+			kindObj.Status.Phase = maptv1alpha1.ClusterPhaseProvisioning
+		})
+
+		It("waits without creating a Job", func() {
+			updated, cluster := reconcileBootstrap()
+			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.BootstrapPhasePending))
+			Expect(updated.Status.JobName).To(BeEmpty())
+			Expect(meta.FindStatusCondition(cluster.Status.Conditions, maptv1alpha1.ConditionBootstrapped)).
+				To(HaveField("Reason", "Bootstrapping"))
+
+			var jobs batchv1.JobList
+			Expect(fakeClient.List(ctx, &jobs)).To(Succeed())
+			Expect(jobs.Items).To(BeEmpty())
+		})
+	})
+
+	It("runs a Job with the access Secret of the cluster mounted", func() {
+		updated, cluster := reconcileBootstrap()
+		Expect(updated.Status.Phase).To(Equal(maptv1alpha1.BootstrapPhaseRunning))
+		Expect(updated.Status.JobName).To(Equal("seed-4f1c2a9e"))
+		Expect(updated.Status.ProvisionId).To(Equal(ProvisionID))
+		Expect(updated.Status.StartTime).NotTo(BeNil())
+		Expect(meta.IsStatusConditionFalse(cluster.Status.Conditions, maptv1alpha1.ConditionBootstrapped)).To(BeTrue())
+
+		job := &batchv1.Job{}
+		Expect(fakeClient.Get(ctx, client.ObjectKey{Name: "seed-4f1c2a9e", Namespace: Namespace}, job)).To(Succeed())
+		Expect(job.OwnerReferences).To(ConsistOf(HaveField("Name", "seed")))
+		Expect(job.Spec.BackoffLimit).To(HaveValue(Equal(int32(2))))
+		Expect(job.Spec.Template.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.Secret.SecretName", "my-kind-cluster-access")))
+		container := job.Spec.Template.Spec.Containers[0]
+		Expect(container.VolumeMounts).To(ContainElement(HaveField("MountPath", "/etc/mapt/cluster")))
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "KUBECONFIG", Value: "/etc/mapt/cluster/kubeconfig"}))
+
+		finishJob(job.Name, batchv1.JobComplete, "")
+		updated, cluster = reconcileBootstrap()
+		Expect(updated.Status.Phase).To(Equal(maptv1alpha1.BootstrapPhaseSucceeded))
+		Expect(updated.Status.CompletionTime).NotTo(BeNil())
+		Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, maptv1alpha1.ConditionBootstrapped)).To(BeTrue())
+	})
+
+	It("reports failed Jobs on the cluster", func() {
+		updated, _ := reconcileBootstrap()
+		finishJob(updated.Status.JobName, batchv1.JobFailed, "Job has reached the specified backoff limit")
+
+		updated, cluster := reconcileBootstrap()
+		Expect(updated.Status.Phase).To(Equal(maptv1alpha1.BootstrapPhaseFailed))
+		condition := meta.FindStatusCondition(cluster.Status.Conditions, maptv1alpha1.ConditionBootstrapped)
+		Expect(condition).To(HaveField("Reason", "BootstrapFailed"))
+		Expect(condition.Message).To(ContainSubstring("backoff limit"))
+	})
+
+	It("runs again once the cluster is provisioned anew", func() {
+		updated, _ := reconcileBootstrap()
+		finishJob(updated.Status.JobName, batchv1.JobComplete, "")
+		reconcileBootstrap()
+
+		cluster := &maptv1alpha1.Kind{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), cluster)).To(Succeed())
+		cluster.Status.ProvisionId = ptr.To("9b7d0c3f-0000-0000-0000-000000000000")
+		Expect(fakeClient.Status().Update(ctx, cluster)).To(Succeed())
+
+		updated, _ = reconcileBootstrap()
+		Expect(updated.Status.Phase).To(Equal(maptv1alpha1.BootstrapPhaseRunning))
+		Expect(updated.Status.JobName).To(Equal("seed-9b7d0c3f"))
+		err := fakeClient.Get(ctx, client.ObjectKey{Name: "seed-4f1c2a9e", Namespace: Namespace}, &batchv1.Job{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("removes the condition once the cluster has no bootstraps", func() {
+		reconcileBootstrap()
+		Expect(fakeClient.Delete(ctx, bootstrap)).To(Succeed())
+
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		cluster := &maptv1alpha1.Kind{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), cluster)).To(Succeed())
+		Expect(meta.FindStatusCondition(cluster.Status.Conditions, maptv1alpha1.ConditionBootstrapped)).To(BeNil())
+	})
+})
@@ -2,7 +2,9 @@ package controller
 
 import (
 	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/internal/controller/clusterbootstrap"
 	"github.com/mapt-oss/mapt-operator/internal/controller/kind"
+	"github.com/mapt-oss/mapt-operator/internal/controller/maptquota"
 	openshiftsnc "github.com/mapt-oss/mapt-operator/internal/controller/openshift-snc"
 )
 
@@ -10,4 +12,19 @@ import (
 var EnabledControllers = []controller.Controller{
 	&kind.KindReconciler{},
 	&openshiftsnc.OpenshiftReconciler{},
+	&maptquota.MaptQuotaReconciler{},
+	&clusterbootstrap.ClusterBootstrapReconciler{},
+}
+
+// SetMaxConcurrentReconciles sets the number of clusters the cluster controllers reconcile at
+// once. It must be called before the controllers are registered.
+func SetMaxConcurrentReconciles(workers int) {
+	for _, c := range EnabledControllers {
+		switch r := c.(type) {
+		case *kind.KindReconciler:
+			r.MaxConcurrentReconciles = workers
+		case *openshiftsnc.OpenshiftReconciler:
+			r.MaxConcurrentReconciles = workers
+		}
+	}
 }
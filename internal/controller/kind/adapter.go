@@ -2,310 +2,410 @@ package kind
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
 	"github.com/konflux-ci/operator-toolkit/controller"
 	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/controller/lifecycle"
 	"github.com/mapt-oss/mapt-operator/internal/metadata"
 	"github.com/mapt-oss/mapt-operator/pkg/clusters"
 	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
-	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-// adapter wraps the reconciliation logic for the Kind custom resource.
-// It handles provisioning, deprovisioning, status updates, and secret management.
+// adapter wraps the reconciliation logic for the Kind custom resource. The lifecycle engine it
+// embeds handles provisioning, deprovisioning, status updates and secret management; the adapter
+// adds the application of spec changes and the installation of add-ons to running clusters.
 type adapter struct {
-	// client is the Kubernetes client used to interact with the API server.
-	client client.Client
-
-	// ctx is the context for the reconciliation process.
-	ctx context.Context
+	*lifecycle.Engine[*v1alpha1.Kind]
 
 	// kind is the Kind custom resource being reconciled.
 	kind *v1alpha1.Kind
-
-	// validations holds any validation functions to be executed during reconciliation.
-	validations []controller.ValidationFunction
-
-	// provisioner is the cluster provisioner used to manage Kind clusters.
-	// It must not be nil.
-	provisioner clusters.GenericMaptProvisioner
-
-	// cloudCrentials holds metadata about the cloud provider used for provisioning.
-	cloudCrentials *clusters.ClusterProvisionerMetadata
-
-	// log is the logger used for logging messages during reconciliation.
-	log logr.Logger
 }
 
 // newAdapter initializes the Kind adapter with necessary dependencies and context.
 // Returns an error if the provisioner is nil.
 func newAdapter(ctx context.Context, c client.Client, kind *v1alpha1.Kind, prv clusters.GenericMaptProvisioner, l logr.Logger) (*adapter, error) {
-	if prv == nil {
-		return nil, fmt.Errorf("no provisioner provided")
+	a := &adapter{kind: kind}
+	engine, err := lifecycle.New(ctx, c, kind, prv, lifecycle.Definition[*v1alpha1.Kind]{
+		ClusterType:       clusters.KindClusterType,
+		Finalizer:         metadata.KindFinalizer,
+		Settings:          kindSettings,
+		Access:            kindAccess,
+		BeforeDeprovision: a.destroyUpdateClusters,
+		Provisioned: func(kind *v1alpha1.Kind) {
+			kind.Status.ProvisionedSpecHash = specHash(&kind.Spec)
+			kind.Status.ObservedGeneration = kind.Generation
+			resetAddons(kind)
+		},
+		// A cluster is still being updated while a BlueGreen replacement is provisioned or its
+		// add-ons are installed.
+		UpdateInProgress: func(kind *v1alpha1.Kind) bool {
+			return kind.Status.UpdateProvisionId != nil || firstUnready(desiredAddons(kind)) >= 0
+		},
+	}, l)
+	if err != nil {
+		return nil, err
 	}
-	return &adapter{
-		client:      c,
-		ctx:         ctx,
-		kind:        kind,
-		log:         l.WithValues("name", kind.Name, "namespace", kind.Namespace),
-		provisioner: prv,
-		validations: []controller.ValidationFunction{},
-	}, nil
+	a.Engine = engine
+	return a, nil
 }
 
-// EnsureFinalizerIsAdded ensures the finalizer is present on the Kind resource.
-// If it's missing, it adds and patches the resource.
-func (a *adapter) EnsureFinalizerIsAdded() (controller.OperationResult, error) {
-	if controllerutil.ContainsFinalizer(a.kind, metadata.KindFinalizer) {
-		return controller.ContinueProcessing()
+// kindSettings returns the parts of the Kind spec the lifecycle engine acts on.
+func kindSettings(kind *v1alpha1.Kind) lifecycle.Settings {
+	return lifecycle.Settings{
+		MachineConfig:       kind.Spec.MachineConfig,
+		TerminationPolicy:   kind.Spec.TerminationPolicy,
+		ProvisioningTimeout: kind.Spec.ProvisioningTimeout,
+		StateBackend:        kind.Spec.StateBackend,
+		Priority:            kind.Spec.Priority,
+		Hibernate:           kind.Spec.Hibernate,
+		Schedule:            kind.Spec.Schedule,
+		GPUStack:            kind.Spec.GPUStack,
 	}
+}
 
-	kindCopy := a.kind.DeepCopy()
-	patch := client.MergeFrom(kindCopy)
-	controllerutil.AddFinalizer(a.kind, metadata.KindFinalizer)
-
-	if err := a.client.Patch(a.ctx, a.kind, patch); err != nil {
-		a.log.Error(err, "Failed to add finalizer.")
-		return controller.RequeueWithError(err)
+// kindAccess ensures the provisioner's response contains valid data and returns the content of the
+// kubeconfig Secret and the hourly price of the cluster, root volume included.
+func kindAccess(kind *v1alpha1.Kind, result clusters.ClusterProvisionerMetadata) (*lifecycle.Access, error) {
+	meta, ok := result.(*clusters.KindMetadata)
+	if !ok || meta == nil {
+		return nil, fmt.Errorf("provisioner returned nil metadata")
 	}
-	return controller.ContinueProcessing()
+	if meta.Kubeconfig == "" {
+		return nil, fmt.Errorf("provisioner returned empty kubeconfig")
+	}
+	return &lifecycle.Access{
+		SecretData: map[string][]byte{"kubeconfig": []byte(meta.Kubeconfig)},
+		HourlyRate: controllerutils.HourlyRate{
+			Instance: meta.SpotPrice,
+			Storage:  clusters.DiskHourlyPrice(kind.Spec.MachineConfig.Disk),
+		},
+		Host:      meta.Host,
+		Placement: meta.Placement,
+		Attempts:  meta.Attempts,
+	}, nil
 }
 
-// EnsureFinalizersAreCalled triggers cleanup logic if the Kind resource is being deleted
-// and the finalizer is present. Removes the finalizer after successful cleanup.
-func (a *adapter) EnsureFinalizersAreCalled() (controller.OperationResult, error) {
-	if a.kind.GetDeletionTimestamp() == nil || !controllerutil.ContainsFinalizer(a.kind, metadata.KindFinalizer) {
-		a.log.Info("Skipping finalizer logic; no deletion or no finalizer present")
+// EnsureSpecChangesAreApplied applies changes to machineConfig or kindClusterConfig of a running
+// cluster according to spec.updateStrategy. Each generation is handled once: a reported drift or a
+// failed update is only retried after the spec changes again.
+func (a *adapter) EnsureSpecChangesAreApplied() (controller.OperationResult, error) {
+	if a.kind.GetDeletionTimestamp() != nil || a.kind.Status.Phase != v1alpha1.KindPhaseRunning {
 		return controller.ContinueProcessing()
 	}
 
-	if err := a.finalizeKind(); err != nil {
-		a.log.Error(err, "Finalization failed during deprovisioning.")
-		return controller.RequeueWithError(err)
-	}
-
-	kindCopy := a.kind.DeepCopy()
-	patch := client.MergeFrom(kindCopy)
-	controllerutil.RemoveFinalizer(a.kind, metadata.KindFinalizer)
-
-	if err := a.client.Patch(a.ctx, a.kind, patch); err != nil {
-		a.log.Error(err, "Failed to remove finalizer from resource.")
+	if err := a.destroyUpdateClusters(); err != nil {
+		a.Log.Error(err, "Failed to destroy clusters left over by a BlueGreen update.")
 		return controller.RequeueWithError(err)
 	}
-	return controller.Requeue()
-}
 
-// EnsureKindClusterIsProvisioned checks if provisioning should proceed,
-// skips if already provisioned or marked for deletion.
-func (a *adapter) EnsureKindClusterIsProvisioned() (controller.OperationResult, error) {
-	if a.kind.GetDeletionTimestamp() != nil {
-		a.log.Info("Resource is marked for deletion, skipping provisioning.")
+	hash := specHash(&a.kind.Spec)
+	switch {
+	case a.kind.Status.ProvisionedSpecHash == "":
+		// Clusters provisioned before spec hashes were recorded are assumed to match their spec.
+		return a.recordObservedSpec(func(*v1alpha1.ClusterStatus) {
+			a.kind.Status.ProvisionedSpecHash = hash
+		})
+	case a.kind.Status.ProvisionedSpecHash == hash:
+		if a.kind.Status.ObservedGeneration == a.kind.Generation && meta.FindStatusCondition(a.kind.Status.Conditions, v1alpha1.ConditionSpecDrift) == nil {
+			return controller.ContinueProcessing()
+		}
+		return a.recordObservedSpec(func(s *v1alpha1.ClusterStatus) {
+			meta.RemoveStatusCondition(&s.Conditions, v1alpha1.ConditionSpecDrift)
+		})
+	case a.kind.Status.ObservedGeneration == a.kind.Generation:
 		return controller.ContinueProcessing()
 	}
 
-	switch a.kind.Status.Phase {
-	case v1alpha1.KindPhaseProvisioning:
-		a.log.Info("Cluster is currently being provisioned.", "phase", a.kind.Status.Phase)
-		return controller.StopProcessing()
-	case v1alpha1.KindPhaseRunning:
-		a.log.Info("Cluster is already provisioned and running.", "phase", a.kind.Status.Phase)
-		return controller.StopProcessing()
-	case v1alpha1.KindPhaseFailed:
-		a.log.Info("Cluster provisioning previously failed. Stopping further retries.", "phase", a.kind.Status.Phase)
-		return controller.StopProcessing()
+	switch a.kind.Spec.UpdateStrategy {
+	case v1alpha1.KindUpdateStrategyRecreate:
+		return a.recreateCluster()
+	case v1alpha1.KindUpdateStrategyBlueGreen:
+		return a.replaceCluster(hash)
+	default:
+		a.Log.Info("Spec changed after provisioning; keeping the running cluster.", "updateStrategy", a.kind.Spec.UpdateStrategy)
+		return a.recordObservedSpec(func(s *v1alpha1.ClusterStatus) {
+			setSpecDrift(s, a.kind.Generation, "UpdateStrategyIgnore",
+				"machineConfig or kindClusterConfig changed after provisioning; the running cluster keeps its original configuration. "+
+					"Use the Recreate or BlueGreen updateStrategy to apply such changes.")
+		})
 	}
-
-	return a.provisionClusterResources()
 }
 
-// provisionClusterResources provisions the Kind cluster using the provisioner,
-// then creates the kubeconfig secret and updates status.
-func (a *adapter) provisionClusterResources() (controller.OperationResult, error) {
-	if a.provisioner == nil {
-		err := fmt.Errorf("provisioner is nil")
-		a.log.Error(err, "Cannot proceed with provisioning")
-		return a.markProvisioningFailed(err)
-	}
-
-	if err := a.markClusterProvisioningStarted(); err != nil {
+// recordObservedSpec marks the current generation as handled, applying update to the status.
+func (a *adapter) recordObservedSpec(update func(*v1alpha1.ClusterStatus)) (controller.OperationResult, error) {
+	if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		s.ObservedGeneration = a.kind.Generation
+		update(s)
+	}); err != nil {
+		a.Log.Error(err, "Failed to record the observed spec.")
 		return controller.RequeueWithError(err)
 	}
+	return controller.ContinueProcessing()
+}
 
-	if a.kind.Status.ProvisionId == nil || *a.kind.Status.ProvisionId == "" {
-		if err := a.client.Get(a.ctx, client.ObjectKeyFromObject(a.kind), a.kind); err != nil {
-			a.log.Error(err, "Failed to re-fetch Kind resource after marking provisioning started")
-			return controller.RequeueWithError(err)
-		}
+// recreateCluster destroys the running cluster and sends it back to Pending, so that it is
+// provisioned again with the current spec. The kubeconfig Secret is kept and updated in place.
+func (a *adapter) recreateCluster() (controller.OperationResult, error) {
+	granted, err := a.acquireUpdateSlot()
+	if err != nil || !granted {
+		return a.updateSlotResult(err)
+	}
+	defer a.Limiter.Release(string(a.kind.UID))
+
+	a.Log.Info("Recreating cluster to apply spec changes.")
+	if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *lifecycle.NewStatusBuilder(a.kind).
+			Message("Recreating the cluster to apply spec changes: destroying the current cluster.").
+			QueuePosition(nil).
+			Status
+	}); err != nil {
+		return controller.RequeueWithError(err)
 	}
 
-	provisionMetadata, provisionErr := a.provisioner.Provision(&clusters.MaptCluster{
+	if err := a.Provisioner.Deprovision(a.Ctx, &clusters.MaptCluster{
 		Type:   clusters.KindClusterType,
 		Object: a.kind,
-	})
-
-	if err := validateKindMetadata(provisionMetadata); err != nil {
-		return a.markProvisioningFailed(err)
-	}
-	if provisionErr != nil {
-		return a.markProvisioningFailed(provisionErr)
+	}); err != nil {
+		a.Log.Error(err, "Failed to destroy the cluster being recreated.")
+		_ = a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			s.Message = fmt.Sprintf("Failed to destroy the cluster being recreated: %s", err.Error())
+		})
+		return controller.RequeueWithError(err)
 	}
 
-	secretData := map[string][]byte{
-		"kubeconfig": []byte(provisionMetadata.KindMetadata.Kubeconfig),
+	// The cost and expiration of the cluster carry over to the recreated cluster: its lifetime is
+	// still measured from its first provisioning.
+	hourlyRate, _ := controllerutils.ParseHourlyRate(a.kind.Status.Cost)
+	if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *lifecycle.NewStatusBuilder(a.kind).
+			Phase(v1alpha1.KindPhasePending).
+			Message("Previous cluster destroyed; provisioning it again with the updated spec.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "Recreating", "The cluster is being recreated to apply spec changes.").
+			Condition(v1alpha1.ConditionAccessSecretReady, metav1.ConditionFalse, "Recreating", "The kubeconfig Secret is updated once the cluster is recreated.").
+			RetireInfrastructure(hourlyRate, a.kind.Spec.TerminationPolicy).
+			Status
+		s.ClusterReady = false
+		s.ProvisionId = nil
+		s.AveragePrice = ""
+		s.AWSInstanceID = nil
+		s.Region = ""
+		s.AvailabilityZone = ""
+		s.InstanceType = ""
+		a.kind.Status.ProvisionedSpecHash = ""
+		s.ObservedGeneration = a.kind.Generation
+		meta.RemoveStatusCondition(&s.Conditions, v1alpha1.ConditionSpecDrift)
+	}); err != nil {
+		a.Log.Error(err, "Failed to reset status after destroying the cluster being recreated.")
+		return controller.RequeueWithError(err)
 	}
+	return controller.Requeue()
+}
 
-	secretName := fmt.Sprintf("kubeconfig-%s", a.kind.Name)
-	existingSecret := &corev1.Secret{}
-	err := a.client.Get(a.ctx, client.ObjectKey{
-		Name:      secretName,
-		Namespace: a.kind.Namespace,
-	}, existingSecret)
-
-	if err == nil {
-		a.log.Info("Kubeconfig secret already exists; skipping secret creation.", "secret", secretName)
-		return a.finalizeSuccessfulProvisioning(secretName, provisionMetadata.KindMetadata.SpotPrice)
+// replaceCluster performs a BlueGreen update: it provisions a replacement cluster with the current
+// spec, moves the kubeconfig Secret to it and destroys the previous cluster. The previous cluster
+// keeps running if the replacement cannot be provisioned.
+func (a *adapter) replaceCluster(hash string) (controller.OperationResult, error) {
+	granted, err := a.acquireUpdateSlot()
+	if err != nil || !granted {
+		return a.updateSlotResult(err)
+	}
+	defer a.Limiter.Release(string(a.kind.UID))
+
+	updateID := uuid.New().String()
+	a.Log.Info("Provisioning a replacement cluster to apply spec changes.", "updateProvisionId", updateID)
+	if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *lifecycle.NewStatusBuilder(a.kind).
+			Message("Provisioning a replacement cluster to apply spec changes.").
+			QueuePosition(nil).
+			Status
+		a.kind.Status.UpdateProvisionId = &updateID
+	}); err != nil {
+		return controller.RequeueWithError(err)
 	}
 
-	generatedSecretName, err := controllerutils.CreateGeneratedSecret(
-		a.ctx, a.client, a.client.Scheme(), secretData, a.kind,
-	)
+	replacement := a.clusterWithProvisionID(updateID)
+	started := metav1.Now()
+	var deadline *time.Time
+	if timeout := a.kind.Spec.ProvisioningTimeout; timeout != nil && timeout.Duration > 0 {
+		d := started.Add(timeout.Duration)
+		deadline = &d
+	}
+	provisionCtx, cancel := controllerutils.ProvisioningContext(a.Ctx, a.Client, a.kind, deadline)
+	defer cancel()
+	result, err := a.Provisioner.Provision(provisionCtx, &clusters.MaptCluster{
+		Type:   clusters.KindClusterType,
+		Object: replacement,
+	})
 	if err != nil {
-		a.log.Error(err, "Failed to create kubeconfig secret after successful provisioning.")
-		return a.markSecretCreationFailed(err)
+		if errors.Is(context.Cause(provisionCtx), controllerutils.ErrObjectDeleted) || a.Ctx.Err() != nil {
+			// The replacement is destroyed by the finalizer or the next reconciliation.
+			return controller.RequeueWithError(err)
+		}
+		return a.markUpdateFailed(replacement, err)
 	}
-
-	return a.finalizeSuccessfulProvisioning(generatedSecretName, provisionMetadata.KindMetadata.SpotPrice)
-}
-
-// validateKindMetadata ensures the provisioner's response contains valid data.
-func validateKindMetadata(meta *clusters.ClusterProvisionerMetadata) error {
-	if meta == nil || meta.KindMetadata == nil {
-		return fmt.Errorf("provisioner returned nil metadata")
+	access, err := kindAccess(replacement, result)
+	if err == nil {
+		err = a.UpdateAccessSecret(access.SecretData)
 	}
-	if meta.KindMetadata.Kubeconfig == "" {
-		return fmt.Errorf("provisioner returned empty kubeconfig")
+	if err != nil {
+		return a.markUpdateFailed(replacement, err)
 	}
-	return nil
-}
 
-// markClusterProvisioningStarted sets the status to "Provisioning" and assigns a new provision ID.
-func (a *adapter) markClusterProvisioningStarted() error {
-	if a.kind.Status.ProvisionId != nil && *a.kind.Status.ProvisionId != "" {
-		a.log.Info("Provisioning already started; skipping ProvisionId generation")
-		return nil
+	// The replaced cluster is billed until the replacement takes over, and the replacement from
+	// when its provisioning started.
+	previousID := *a.kind.Status.ProvisionId
+	previousRate, _ := controllerutils.ParseHourlyRate(a.kind.Status.Cost)
+	if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *lifecycle.NewStatusBuilder(a.kind).
+			Message("Cluster replaced to apply spec changes.").
+			RetireInfrastructure(previousRate, a.kind.Spec.TerminationPolicy).
+			ProvisionStartTime(started).
+			BackendID(updateID).
+			Host(access.Host).
+			Placement(access.Placement).
+			ProvisioningAttempts(access.Attempts).
+			AvgPrice(access.HourlyRate.Total()).
+			Cost(access.HourlyRate, a.kind.Spec.TerminationPolicy).
+			Status
+		s.ObservedGeneration = a.kind.Generation
+		s.AWSInstanceID = nil
+		a.kind.Status.ProvisionedSpecHash = hash
+		a.kind.Status.UpdateProvisionId = nil
+		a.kind.Status.RetiredProvisionId = &previousID
+		meta.RemoveStatusCondition(&s.Conditions, v1alpha1.ConditionSpecDrift)
+		a.ResetGPUStack(s)
+		resetAddons(a.kind)
+	}); err != nil {
+		a.Log.Error(err, "Failed to record the replacement cluster.")
+		return controller.RequeueWithError(err)
 	}
 
-	provisionId := uuid.New().String()
-	err := a.updateStatus(func(s *v1alpha1.KindStatus) {
-		*s = *newStatusBuilder(a.kind).
-			phase(v1alpha1.KindPhaseProvisioning).
-			message("Provisioning of Kind cluster has started.").
-			condition("Ready", metav1.ConditionFalse, "ProvisioningStarted", "Cluster provisioning has been initiated and is in progress.").
-			backendID(provisionId).
-			status
-	})
-	if err != nil {
-		return err
+	if err := a.destroyUpdateClusters(); err != nil {
+		a.Log.Error(err, "Failed to destroy the replaced cluster.")
+		return controller.RequeueWithError(err)
 	}
-
-	a.kind.Status.ProvisionId = &provisionId
-	return nil
+	return controller.ContinueProcessing()
 }
 
-// finalizeKind handles cleanup logic during deletion of the Kind resource.
-func (a *adapter) finalizeKind() error {
-	if a.kind.Status.ProvisionId == nil || *a.kind.Status.ProvisionId == "" {
-		a.log.Info("No provision ID found; skipping deprovisioning.")
-		return a.updateStatus(func(s *v1alpha1.KindStatus) {
-			*s = *newStatusBuilder(a.kind).
-				phase(v1alpha1.KindPhaseDeleting).
-				message("Skipping deprovisioning: no external resources found for this Kind cluster.").
-				condition("Ready", metav1.ConditionFalse, "DeprovisionSkipped", "Cluster marked for deletion, but no provision ID exists; assuming no external resources.").
-				status
-		})
+// markUpdateFailed destroys a replacement cluster that could not take over and reports the failed
+// update, leaving the running cluster untouched.
+func (a *adapter) markUpdateFailed(replacement *v1alpha1.Kind, updateErr error) (controller.OperationResult, error) {
+	a.Log.Error(updateErr, "BlueGreen update failed; keeping the running cluster.")
+	destroyErr := a.Provisioner.Deprovision(a.Ctx, &clusters.MaptCluster{
+		Type:   clusters.KindClusterType,
+		Object: replacement,
+	})
+	if destroyErr != nil {
+		a.Log.Error(destroyErr, "Failed to destroy the replacement cluster; retrying on the next reconciliation.")
 	}
 
-	if err := a.updateStatus(func(s *v1alpha1.KindStatus) {
-		*s = *newStatusBuilder(a.kind).
-			phase(v1alpha1.KindPhaseDeleting).
-			message("Deprovisioning in progress: external resources are being deleted.").
-			condition("Ready", metav1.ConditionFalse, string(v1alpha1.KindPhaseDeleting), "Cluster deletion requested; associated infrastructure cleanup in progress.").
-			status
+	if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		s.Message = fmt.Sprintf("BlueGreen update failed: %s; the running cluster keeps its original configuration.", updateErr.Error())
+		s.ObservedGeneration = a.kind.Generation
+		if destroyErr == nil {
+			a.kind.Status.UpdateProvisionId = nil
+		}
+		setSpecDrift(s, a.kind.Generation, "UpdateFailed", fmt.Sprintf("Applying spec changes failed: %s", updateErr.Error()))
 	}); err != nil {
-		return err
+		return controller.RequeueWithError(err)
 	}
+	return controller.ContinueProcessing()
+}
 
-	if err := a.provisioner.Deprovision(&clusters.MaptCluster{
-		Type:   clusters.KindClusterType,
-		Object: a.kind,
-	}); err != nil {
-		a.log.Error(err, "Deprovisioning failed.", "provisionId", *a.kind.Status.ProvisionId)
-		_ = a.updateStatus(func(s *v1alpha1.KindStatus) {
-			*s = *newStatusBuilder(a.kind).
-				phase(v1alpha1.KindPhaseFailed).
-				message(fmt.Sprintf("Failed to deprovision cluster: %s", err.Error())).
-				condition("Ready", metav1.ConditionFalse, "DeprovisioningFailed", fmt.Sprintf("Error while deprovisioning Kind cluster: %s", err.Error())).
-				status
-		})
-		return err
+// destroyUpdateClusters destroys the clusters left over by BlueGreen updates: a replaced cluster,
+// and a replacement whose update was interrupted before it took over.
+func (a *adapter) destroyUpdateClusters() error {
+	if id := a.kind.Status.RetiredProvisionId; id != nil {
+		if err := a.Provisioner.Deprovision(a.Ctx, &clusters.MaptCluster{
+			Type:   clusters.KindClusterType,
+			Object: a.clusterWithProvisionID(*id),
+		}); err != nil {
+			return fmt.Errorf("failed to destroy replaced cluster %s: %w", *id, err)
+		}
+		if err := a.UpdateStatus(func(*v1alpha1.ClusterStatus) { a.kind.Status.RetiredProvisionId = nil }); err != nil {
+			return err
+		}
 	}
-
-	return a.updateStatus(func(s *v1alpha1.KindStatus) {
-		*s = *newStatusBuilder(a.kind).
-			phase(v1alpha1.KindPhaseDeleting).
-			message("Kind resources successfully deprovisioned.").
-			condition("Ready", metav1.ConditionFalse, "Deprovisioned", "Cluster marked as deleted.").
-			status
-	})
+	if id := a.kind.Status.UpdateProvisionId; id != nil {
+		if err := a.Provisioner.Deprovision(a.Ctx, &clusters.MaptCluster{
+			Type:   clusters.KindClusterType,
+			Object: a.clusterWithProvisionID(*id),
+		}); err != nil {
+			return fmt.Errorf("failed to destroy replacement cluster %s: %w", *id, err)
+		}
+		if err := a.UpdateStatus(func(*v1alpha1.ClusterStatus) { a.kind.Status.UpdateProvisionId = nil }); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// markProvisioningFailed updates the Kind status when provisioning fails.
-func (a *adapter) markProvisioningFailed(err error) (controller.OperationResult, error) {
-	a.log.Error(err, "Cluster provisioning failed.")
-	_ = a.updateStatus(func(s *v1alpha1.KindStatus) {
-		*s = *newStatusBuilder(a.kind).
-			phase(v1alpha1.KindPhaseFailed).
-			message(fmt.Sprintf("Failed to provision Kind cluster: %s", err.Error())).
-			condition("Ready", metav1.ConditionFalse, "ProvisioningFailed", fmt.Sprintf("Provisioning error: %s", err.Error())).
-			status
-	})
-	return controller.RequeueWithError(err)
+// clusterWithProvisionID returns a copy of the Kind resource addressing the backend with the given id.
+func (a *adapter) clusterWithProvisionID(id string) *v1alpha1.Kind {
+	kind := a.kind.DeepCopy()
+	kind.Status.ProvisionId = &id
+	return kind
 }
 
-// markSecretCreationFailed sets the Kind status when kubeconfig secret creation fails.
-func (a *adapter) markSecretCreationFailed(err error) (controller.OperationResult, error) {
-	_ = a.updateStatus(func(s *v1alpha1.KindStatus) {
-		*s = *newStatusBuilder(a.kind).
-			phase(v1alpha1.KindPhaseFailed).
-			message(fmt.Sprintf("Error creating kubeconfig secret: %s", err.Error())).
-			condition("Ready", metav1.ConditionFalse, "SecretCreationFailed", fmt.Sprintf("Could not create kubeconfig secret: %s", err.Error())).
-			status
+// acquireUpdateSlot reserves a provisioning slot to apply spec changes. The cluster keeps running
+// while it waits, so only the message and queue position are updated.
+func (a *adapter) acquireUpdateSlot() (bool, error) {
+	return a.AcquireSlot(func(position int32) error {
+		return a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *lifecycle.NewStatusBuilder(a.kind).
+				Message(fmt.Sprintf("Waiting for a provisioning slot to apply spec changes (position %d in queue).", position)).
+				QueuePosition(&position).
+				Status
+		})
 	})
-	return controller.RequeueWithError(err)
 }
 
-// finalizeSuccessfulProvisioning updates the status after a successful cluster provision.
-func (a *adapter) finalizeSuccessfulProvisioning(secretName string, avgPrice float64) (controller.OperationResult, error) {
-	a.log.Info("Cluster successfully provisioned and kubeconfig secret created.")
-	err := a.updateStatus(func(s *v1alpha1.KindStatus) {
-		builder := newStatusBuilder(a.kind).
-			phase(v1alpha1.KindPhaseRunning).
-			message("Kind cluster successfully provisioned and ready.").
-			condition("Ready", metav1.ConditionTrue, "Provisioned", "The Kind cluster has been successfully created and is ready for use.").
-			avgPrice(avgPrice)
-		*s = *builder.status
-		s.ClusterReady = true
-		s.KubeconfigSecretName = &secretName
-	})
+func (a *adapter) updateSlotResult(err error) (controller.OperationResult, error) {
 	if err != nil {
-		a.log.Error(err, "Failed to update status to Running after successful provisioning.")
+		a.Log.Error(err, "Failed to mark cluster as waiting for a provisioning slot.")
 		return controller.RequeueWithError(err)
 	}
-	return controller.ContinueProcessing()
+	return controller.RequeueAfter(lifecycle.SlotRequeueInterval, nil)
+}
+
+// setSpecDrift reports that the running cluster does not match its spec.
+func setSpecDrift(s *v1alpha1.ClusterStatus, generation int64, reason, message string) {
+	controllerutils.SetCondition(&s.Conditions, generation, v1alpha1.ConditionSpecDrift, metav1.ConditionTrue, reason, message)
+}
+
+// specHash returns a hash of the parts of the spec that define the provisioned cluster. Only the
+// machineConfig fields shaping the infrastructure are part of it: tags, price limits and fallback
+// strategies only matter while provisioning, so changing them does not update a running cluster.
+// Whether the cluster has a GPU stack is part of it, since the host is only set up for GPUs when
+// it has one.
+func specHash(spec *v1alpha1.KindSpec) string {
+	m := spec.MachineConfig
+	data, _ := json.Marshal(struct {
+		Architecture                string                     `json:"architecture,omitempty"`
+		CPUs                        int32                      `json:"cpus,omitempty"`
+		GPU                         bool                       `json:"gpu,omitempty"`
+		GPURequirements             *v1alpha1.GPURequirements  `json:"gpuRequirements,omitempty"`
+		MemoryGiB                   int32                      `json:"memoryGiB,omitempty"`
+		InstanceTypes               []string                   `json:"instanceTypes,omitempty"`
+		ExcludedInstanceTypes       []string                   `json:"excludedInstanceTypes,omitempty"`
+		Disk                        *v1alpha1.DiskConfig       `json:"disk,omitempty"`
+		NestedVirtualizationEnabled bool                       `json:"nestedVirtualizationEnabled,omitempty"`
+		UseSpotInstances            bool                       `json:"useSpotInstances,omitempty"`
+		KindClusterConfig           v1alpha1.KindClusterConfig `json:"kindClusterConfig"`
+		GPUStack                    bool                       `json:"gpuStack,omitempty"`
+	}{m.Architecture, m.CPUs, m.GPU, m.GPURequirements, m.MemoryGiB, m.InstanceTypes, m.ExcludedInstanceTypes,
+		m.Disk, m.NestedVirtualizationEnabled, m.UseSpotInstances, spec.KindClusterConfig, spec.GPUStack != nil})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
 }
@@ -3,19 +3,32 @@ package kind
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	"github.com/go-logr/logr"
+	"github.com/konflux-ci/operator-toolkit/controller"
 	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/controller/lifecycle"
 	"github.com/mapt-oss/mapt-operator/internal/metadata"
 	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
 )
 
 var _ = Describe("Kind Adapter (Unit Tests)", func() {
@@ -108,7 +121,7 @@ var _ = Describe("Kind Adapter (Unit Tests)", func() {
 			kindObj.ObjectMeta.Finalizers = []string{metadata.KindFinalizer}
 			kindObj.Status.ProvisionId = &provisionID
 
-			mockProv.MockDeprovision = func(cluster *clusters.MaptCluster) error {
+			mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
 				return nil
 			}
 
@@ -134,36 +147,650 @@ var _ = Describe("Kind Adapter (Unit Tests)", func() {
 				WithObjects(kindObj).
 				Build()
 
-			mockProv.MockProvision = func(cluster *clusters.MaptCluster) (*clusters.ClusterProvisionerMetadata, error) {
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
 				Fail("Provision should not be called")
 				return nil, nil
 			}
 
 			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
 			Expect(err).NotTo(HaveOccurred())
-			_, err = adapter.EnsureKindClusterIsProvisioned()
+			_, err = adapter.EnsureClusterIsProvisioned()
 			Expect(err).NotTo(HaveOccurred())
 		})
 
 		It("handles provisioning failure", func() {
-			mockProv.MockProvision = func(cluster *clusters.MaptCluster) (*clusters.ClusterProvisionerMetadata, error) {
-				return &clusters.ClusterProvisionerMetadata{
-					Type: clusters.KindClusterType,
-					KindMetadata: &clusters.KindMetadata{
-						Kubeconfig: "",
-					},
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				return &clusters.KindMetadata{
+					Kubeconfig: "",
 				}, errors.New("provision failed")
 			}
 
 			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
 			Expect(err).NotTo(HaveOccurred())
-			_, err = adapter.EnsureKindClusterIsProvisioned()
+			_, err = adapter.EnsureClusterIsProvisioned()
 			Expect(err).To(HaveOccurred())
 
 			var updated maptv1alpha1.Kind
 			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
 			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseFailed))
-			Expect(updated.Status.Message).To(ContainSubstring("provisioner returned empty kubeconfig"))
+			Expect(updated.Status.Message).To(ContainSubstring("provision failed"))
+			Expect(meta.IsStatusConditionTrue(updated.Status.Conditions, maptv1alpha1.ConditionStalled)).To(BeTrue())
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionReady)).
+				To(HaveField("Reason", "ProvisioningFailed"))
+		})
+
+		It("adds the price of the root volume to the average price", func() {
+			kindObj.Spec.MachineConfig.Disk = &maptv1alpha1.DiskConfig{SizeGiB: 730, Type: maptv1alpha1.VolumeTypeGP3}
+			Expect(fakeClient.Update(ctx, kindObj)).To(Succeed())
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				return &clusters.KindMetadata{Kubeconfig: "apiVersion: v1", SpotPrice: 0.1}, nil
+			}
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureClusterIsProvisioned()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.AveragePrice).To(Equal("0.1800 USD/hour"))
+		})
+
+		It("reports a single Ready condition backed by its sub-conditions", func() {
+			kindObj.Status.Conditions = []metav1.Condition{
+				{Type: maptv1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "ProvisioningStarted"},
+				{Type: maptv1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "QuotaExceeded"},
+			}
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				return &clusters.KindMetadata{Kubeconfig: "apiVersion: v1", SpotPrice: 0.1}, nil
+			}
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureClusterIsProvisioned()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			var ready []metav1.Condition
+			for _, c := range updated.Status.Conditions {
+				if c.Type == maptv1alpha1.ConditionReady {
+					ready = append(ready, c)
+				}
+			}
+			Expect(ready).To(HaveLen(1))
+			Expect(ready[0].Status).To(Equal(metav1.ConditionTrue))
+			Expect(ready[0].ObservedGeneration).To(Equal(updated.Generation))
+			for _, t := range []string{
+				maptv1alpha1.ConditionInfrastructureProvisioned,
+				maptv1alpha1.ConditionAccessSecretReady,
+				maptv1alpha1.ConditionHealthy,
+			} {
+				Expect(meta.IsStatusConditionTrue(updated.Status.Conditions, t)).To(BeTrue(), t)
+			}
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionExpiring)).
+				To(HaveField("Reason", "NoExpiration"))
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionReconciling)).To(BeNil())
+			Expect(updated.Status.ObservedGeneration).To(Equal(updated.Generation))
+		})
+
+		Context("when the concurrency limits are reached", func() {
+			var limiter *concurrency.Limiter
+
+			BeforeEach(func() {
+				kindObj.UID = "queued-kind"
+				limiter = concurrency.NewLimiter(1, 0)
+				granted, _ := limiter.Acquire(concurrency.Request{ID: "running-kind"})
+				Expect(granted).To(BeTrue())
+			})
+
+			It("queues the cluster with its position", func() {
+				mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+					Fail("Provision should not be called")
+					return nil, nil
+				}
+
+				adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+				Expect(err).NotTo(HaveOccurred())
+				adapter.Limiter = limiter
+				result, err := adapter.EnsureClusterIsProvisioned()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueDelay).To(Equal(lifecycle.SlotRequeueInterval))
+
+				var updated maptv1alpha1.Kind
+				Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+				Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseQueued))
+				Expect(updated.Status.QueuePosition).To(HaveValue(BeEquivalentTo(1)))
+				Expect(updated.Status.ProvisionId).To(BeNil())
+			})
+
+			It("provisions and releases the slot once admitted", func() {
+				mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+					return nil, errors.New("provision failed")
+				}
+				limiter.Release("running-kind")
+
+				adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+				Expect(err).NotTo(HaveOccurred())
+				adapter.Limiter = limiter
+				_, err = adapter.EnsureClusterIsProvisioned()
+				Expect(err).To(HaveOccurred())
+
+				var updated maptv1alpha1.Kind
+				Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+				Expect(updated.Status.QueuePosition).To(BeNil())
+
+				granted, _ := limiter.Acquire(concurrency.Request{ID: "other-kind"})
+				Expect(granted).To(BeTrue())
+			})
+		})
+
+		Context("when the provisioning timeout elapses", func() {
+			BeforeEach(func() {
+				kindObj.Spec.ProvisioningTimeout = &metav1.Duration{Duration: time.Millisecond}
+			})
+
+			It("destroys the partial cluster and marks it as timed out", func() {
+				mockProv.MockProvision = func(ctx context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				deprovisioned := false
+				mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
+					deprovisioned = true
+					return nil
+				}
+
+				adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+				Expect(err).NotTo(HaveOccurred())
+				result, err := adapter.EnsureClusterIsProvisioned()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeTrue())
+				Expect(deprovisioned).To(BeTrue())
+
+				var updated maptv1alpha1.Kind
+				Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+				Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseFailed))
+				Expect(updated.Status.Conditions).To(ContainElement(HaveField("Reason", "ProvisioningTimedOut")))
+			})
+
+			It("times out a provisioning interrupted by a restart", func() {
+				provisionID := "interrupted-provision-id"
+				started := metav1.NewTime(time.Now().Add(-time.Hour))
+				kindObj.Status.Phase = maptv1alpha1.KindPhaseProvisioning
+				kindObj.Status.ProvisionId = &provisionID
+				kindObj.Status.ProvisionStartTime = &started
+				mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
+					return errors.New("stack is locked")
+				}
+
+				adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+				Expect(err).NotTo(HaveOccurred())
+				_, err = adapter.EnsureClusterIsProvisioned()
+				Expect(err).NotTo(HaveOccurred())
+
+				var updated maptv1alpha1.Kind
+				Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+				Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseFailed))
+				Expect(updated.Status.Message).To(ContainSubstring("stack is locked"))
+			})
+		})
+	})
+
+	Describe("EnsureClusterCostIsUpdated", func() {
+		It("refreshes the accrued cost of a running cluster", func() {
+			start := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			kindObj.Status.Phase = maptv1alpha1.KindPhaseRunning
+			kindObj.Status.ProvisionStartTime = &start
+			kindObj.Status.Cost = &maptv1alpha1.ClusterCost{HourlyRateUSD: "0.5000"}
+
+			fakeClient = fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(kindObj).
+				WithStatusSubresource(kindObj).
+				Build()
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureClusterCostIsUpdated()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Cost).NotTo(BeNil())
+			Expect(updated.Status.Cost.HourlyRateUSD).To(Equal("0.5000"))
+			accrued, err := strconv.ParseFloat(updated.Status.Cost.AccruedUSD, 64)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(accrued).To(BeNumerically("~", 1.0, 0.01))
+		})
+
+		It("skips clusters that are not running", func() {
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureClusterCostIsUpdated()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Cost).To(BeNil())
+		})
+	})
+
+	Describe("EnsureQuotaIsAvailable", func() {
+		It("queues a cluster while the namespace quota is exhausted", func() {
+			maxClusters := int32(1)
+			quota := &maptv1alpha1.MaptQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: KindNamespace},
+				Spec:       maptv1alpha1.MaptQuotaSpec{MaxClusters: &maxClusters},
+			}
+			running := &maptv1alpha1.Kind{
+				ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: KindNamespace},
+				Status:     maptv1alpha1.KindStatus{ClusterStatus: maptv1alpha1.ClusterStatus{Phase: maptv1alpha1.KindPhaseRunning}},
+			}
+
+			fakeClient = fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(kindObj, quota, running).
+				WithStatusSubresource(kindObj).
+				Build()
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			result, err := adapter.EnsureQuotaIsAvailable()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueRequest).To(BeTrue())
+			Expect(result.RequeueDelay).To(Equal(lifecycle.QuotaRequeueInterval))
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseQueued))
+			Expect(updated.Status.Message).To(ContainSubstring("clusters: requested 2, limit 1"))
+		})
+
+		It("continues when no quota applies", func() {
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			result, err := adapter.EnsureQuotaIsAvailable()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueRequest).To(BeFalse())
+		})
+	})
+
+	Describe("EnsureSpecChangesAreApplied", func() {
+		var (
+			secret  *corev1.Secret
+			started metav1.Time
+		)
+
+		BeforeEach(func() {
+			provisionID := "blue-provision-id"
+			secretName := "kindspot-test-kubeconfig"
+			started = metav1.NewTime(time.Now().Truncate(time.Minute).Add(-2 * time.Hour))
+			kindObj.Status.ProvisionStartTime = &started
+			kindObj.Status.InfrastructureStartTime = &started
+			kindObj.Status.Cost = &maptv1alpha1.ClusterCost{HourlyRateUSD: "0.5000"}
+			kindObj.Generation = 2
+			kindObj.Spec.KindClusterConfig.KubernetesVersion = "v1.31.0"
+			kindObj.Status.Phase = maptv1alpha1.KindPhaseRunning
+			kindObj.Status.ClusterReady = true
+			kindObj.Status.ProvisionId = &provisionID
+			kindObj.Status.KubeconfigSecretName = &secretName
+			kindObj.Status.ProvisionedSpecHash = "provisioned-with-v1.30.0"
+			kindObj.Status.ObservedGeneration = 1
+
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: KindNamespace},
+				Data:       map[string][]byte{"kubeconfig": []byte("blue-kubeconfig")},
+			}
+		})
+
+		JustBeforeEach(func() {
+			fakeClient = fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(kindObj, secret).
+				WithStatusSubresource(kindObj).
+				Build()
+		})
+
+		It("reports the drift with the Ignore strategy", func() {
+			kindObj.Spec.UpdateStrategy = maptv1alpha1.KindUpdateStrategyIgnore
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureSpecChangesAreApplied()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseRunning))
+			Expect(updated.Status.ObservedGeneration).To(BeEquivalentTo(2))
+			drift := meta.FindStatusCondition(updated.Status.Conditions, "SpecDrift")
+			Expect(drift).NotTo(BeNil())
+			Expect(drift.Reason).To(Equal("UpdateStrategyIgnore"))
+		})
+
+		It("records the spec of clusters provisioned before hashes were tracked", func() {
+			kindObj.Status.ProvisionedSpecHash = ""
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureSpecChangesAreApplied()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.ProvisionedSpecHash).To(Equal(specHash(&kindObj.Spec)))
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, "SpecDrift")).To(BeNil())
+		})
+
+		It("keeps the running cluster when only provisioning settings change", func() {
+			kindObj.Spec.UpdateStrategy = maptv1alpha1.KindUpdateStrategyRecreate
+			kindObj.Status.ProvisionedSpecHash = specHash(&kindObj.Spec)
+			kindObj.Spec.MachineConfig.Tags = map[string]string{"team": "ci"}
+			kindObj.Spec.MachineConfig.MaxHourlyPrice = "0.5"
+			mockProv.MockDeprovision = func(context.Context, *clusters.MaptCluster) error {
+				return errors.New("the cluster must not be destroyed")
+			}
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureSpecChangesAreApplied()
+			Expect(err).NotTo(HaveOccurred())
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseRunning))
+			Expect(updated.Status.ProvisionId).To(HaveValue(Equal("blue-provision-id")))
+			Expect(updated.Status.ObservedGeneration).To(BeEquivalentTo(2))
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, "SpecDrift")).To(BeNil())
+		})
+
+		It("destroys the cluster and provisions it again with the Recreate strategy", func() {
+			kindObj.Spec.UpdateStrategy = maptv1alpha1.KindUpdateStrategyRecreate
+			var destroyed []string
+			mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
+				destroyed = append(destroyed, *cluster.Object.(*maptv1alpha1.Kind).Status.ProvisionId)
+				return nil
+			}
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			result, err := adapter.EnsureSpecChangesAreApplied()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueRequest).To(BeTrue())
+			Expect(destroyed).To(ConsistOf("blue-provision-id"))
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhasePending))
+			Expect(updated.Status.ProvisionId).To(BeNil())
+			Expect(updated.Status.KubeconfigSecretName).To(HaveValue(Equal(secret.Name)))
+			// The lifetime of the cluster is still measured from its first provisioning.
+			Expect(updated.Status.ProvisionStartTime.Time).To(BeTemporally("~", started.Time, time.Second))
+			Expect(updated.Status.InfrastructureStartTime).To(BeNil())
+			Expect(updated.Status.PreviousUsage).To(Equal(&maptv1alpha1.ClusterUsage{RunningHours: "2.0000", AccruedUSD: "1.0000"}))
+		})
+
+		It("swaps the kubeconfig and destroys the previous cluster with the BlueGreen strategy", func() {
+			kindObj.Spec.UpdateStrategy = maptv1alpha1.KindUpdateStrategyBlueGreen
+			var provisioned, destroyed []string
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				provisioned = append(provisioned, *cluster.Object.(*maptv1alpha1.Kind).Status.ProvisionId)
+				return &clusters.KindMetadata{Kubeconfig: "green-kubeconfig", SpotPrice: 0.2,
+					Placement: clusters.Placement{Region: "us-east-1", AvailabilityZone: "us-east-1b", InstanceType: "g6.xlarge"}}, nil
+			}
+			mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
+				destroyed = append(destroyed, *cluster.Object.(*maptv1alpha1.Kind).Status.ProvisionId)
+				return nil
+			}
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureSpecChangesAreApplied()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(provisioned).To(HaveLen(1))
+			Expect(provisioned[0]).NotTo(Equal("blue-provision-id"))
+			Expect(destroyed).To(ConsistOf("blue-provision-id"))
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.Phase).To(Equal(maptv1alpha1.KindPhaseRunning))
+			Expect(updated.Status.ProvisionId).To(HaveValue(Equal(provisioned[0])))
+			Expect(updated.Status.RetiredProvisionId).To(BeNil())
+			Expect(updated.Status.ProvisionedSpecHash).To(Equal(specHash(&kindObj.Spec)))
+			Expect(updated.Status.InstanceType).To(Equal("g6.xlarge"))
+			Expect(updated.Status.AvailabilityZone).To(Equal("us-east-1b"))
+			Expect(updated.Status.ProvisionStartTime.Time).To(BeTemporally("~", started.Time, time.Second))
+			Expect(updated.Status.InfrastructureStartTime.Time).To(BeTemporally(">", started.Time))
+			Expect(updated.Status.PreviousUsage).To(Equal(&maptv1alpha1.ClusterUsage{RunningHours: "2.0000", AccruedUSD: "1.0000"}))
+			Expect(updated.Status.Cost.AccruedUSD).To(Equal("1.0000"))
+
+			var updatedSecret corev1.Secret
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(secret), &updatedSecret)).To(Succeed())
+			Expect(string(updatedSecret.Data["kubeconfig"])).To(Equal("green-kubeconfig"))
+		})
+
+		It("keeps the running cluster when the BlueGreen replacement fails", func() {
+			kindObj.Spec.UpdateStrategy = maptv1alpha1.KindUpdateStrategyBlueGreen
+			var destroyed []string
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				return nil, errors.New("no spot capacity")
+			}
+			mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
+				destroyed = append(destroyed, *cluster.Object.(*maptv1alpha1.Kind).Status.ProvisionId)
+				return nil
+			}
+
+			adapter, err := newAdapter(ctx, fakeClient, kindObj, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			_, err = adapter.EnsureSpecChangesAreApplied()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(destroyed).To(HaveLen(1))
+			Expect(destroyed[0]).NotTo(Equal("blue-provision-id"))
+
+			var updated maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), &updated)).To(Succeed())
+			Expect(updated.Status.ProvisionId).To(HaveValue(Equal("blue-provision-id")))
+			Expect(updated.Status.UpdateProvisionId).To(BeNil())
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, "SpecDrift")).To(HaveField("Reason", "UpdateFailed"))
+
+			var updatedSecret corev1.Secret
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(secret), &updatedSecret)).To(Succeed())
+			Expect(string(updatedSecret.Data["kubeconfig"])).To(Equal("blue-kubeconfig"))
+		})
+	})
+
+	Describe("EnsureAddonsAreInstalled", func() {
+		const demoManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: demo
+  namespace: demo
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: demo
+  template:
+    metadata:
+      labels:
+        app: demo
+    spec:
+      containers:
+      - name: demo
+        image: demo
+`
+		var remote client.Client
+
+		BeforeEach(func() {
+			kindObj.Spec.Addons = []maptv1alpha1.KindAddon{{
+				Name:         "demo",
+				ConfigMapRef: &corev1.LocalObjectReference{Name: "demo-manifests"},
+			}}
+			kindObj.Status.Phase = maptv1alpha1.KindPhaseRunning
+			kindObj.Status.KubeconfigSecretName = ptr.To("custom-secret")
+
+			// The fake client does not implement server-side apply, so applied objects are created.
+			remote = fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+						if patch.Type() != types.ApplyPatchType {
+							return c.Patch(ctx, obj, patch, opts...)
+						}
+						if err := c.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+							return err
+						}
+						return nil
+					},
+				}).
+				Build()
+		})
+
+		JustBeforeEach(func() {
+			Expect(fakeClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "custom-secret", Namespace: KindNamespace},
+				Data:       map[string][]byte{"kubeconfig": []byte("kubeconfig")},
+			})).To(Succeed())
+		})
+
+		reconcile := func() (controller.OperationResult, *maptv1alpha1.Kind) {
+			current := &maptv1alpha1.Kind{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), current)).To(Succeed())
+			adapter, err := newAdapter(ctx, fakeClient, current, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			adapter.RemoteClient = func([]byte) (client.Client, error) { return remote, nil }
+			result, err := adapter.EnsureAddonsAreInstalled()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), current)).To(Succeed())
+			return result, current
+		}
+
+		It("installs the add-ons and waits for their workloads", func() {
+			Expect(fakeClient.Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "demo-manifests", Namespace: KindNamespace},
+				Data:       map[string]string{"deployment.yaml": demoManifest},
+			})).To(Succeed())
+
+			result, updated := reconcile()
+			Expect(result.RequeueDelay).To(Equal(addonRequeueInterval))
+			Expect(updated.Status.Addons).To(HaveLen(1))
+			Expect(updated.Status.Addons[0].Phase).To(Equal(maptv1alpha1.AddonPhaseInstalling))
+			Expect(updated.Status.Addons[0].Source).To(Equal("configmap/demo-manifests"))
+			Expect(updated.Status.Addons[0].LastAppliedTime).NotTo(BeNil())
+			Expect(meta.IsStatusConditionFalse(updated.Status.Conditions, maptv1alpha1.ConditionAddonsReady)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(updated.Status.Conditions, maptv1alpha1.ConditionReady)).To(BeFalse())
+
+			deployment := &appsv1.Deployment{}
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "demo", Namespace: "demo"}, deployment)).To(Succeed())
+			deployment.Status.AvailableReplicas = 1
+			Expect(remote.Status().Update(ctx, deployment)).To(Succeed())
+
+			result, updated = reconcile()
+			Expect(result.CancelRequest).To(BeFalse())
+			Expect(result.RequeueDelay).To(BeZero())
+			Expect(updated.Status.Addons[0].Phase).To(Equal(maptv1alpha1.AddonPhaseReady))
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionAddonsReady)).
+				To(HaveField("Status", metav1.ConditionTrue))
+		})
+
+		It("reports add-ons that cannot be loaded", func() {
+			result, updated := reconcile()
+			Expect(result.RequeueDelay).To(Equal(addonRequeueInterval))
+			Expect(updated.Status.Addons[0].Phase).To(Equal(maptv1alpha1.AddonPhaseFailed))
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionAddonsReady)).
+				To(HaveField("Reason", "AddonFailed"))
+		})
+
+		Context("when the add-ons are removed from the spec", func() {
+			BeforeEach(func() {
+				kindObj.Spec.Addons = nil
+				kindObj.Status.Addons = []maptv1alpha1.AddonStatus{{Name: "demo", Phase: maptv1alpha1.AddonPhaseReady}}
+				kindObj.Status.Conditions = []metav1.Condition{{
+					Type: maptv1alpha1.ConditionAddonsReady, Status: metav1.ConditionTrue, Reason: "AddonsReady",
+					LastTransitionTime: metav1.Now(),
+				}}
+			})
+
+			It("clears the add-on status", func() {
+				result, updated := reconcile()
+				Expect(result.RequeueDelay).To(BeZero())
+				Expect(updated.Status.Addons).To(BeEmpty())
+				Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionAddonsReady)).To(BeNil())
+			})
+		})
+	})
+
+	Describe("EnsureGPUStackIsInstalled", func() {
+		var remote client.Client
+
+		BeforeEach(func() {
+			kindObj.Spec.MachineConfig.GPU = true
+			kindObj.Spec.GPUStack = &maptv1alpha1.GPUStack{TimeSlicing: &maptv1alpha1.GPUTimeSlicing{Replicas: 4}}
+			kindObj.Status.Phase = maptv1alpha1.KindPhaseRunning
+			kindObj.Status.KubeconfigSecretName = ptr.To("custom-secret")
+
+			// The fake client does not implement server-side apply, so applied objects are created.
+			remote = fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-worker"}}).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+						if patch.Type() != types.ApplyPatchType {
+							return c.Patch(ctx, obj, patch, opts...)
+						}
+						if err := c.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+							return err
+						}
+						return nil
+					},
+				}).
+				Build()
+		})
+
+		JustBeforeEach(func() {
+			Expect(fakeClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "custom-secret", Namespace: KindNamespace},
+				Data:       map[string][]byte{"kubeconfig": []byte("kubeconfig")},
+			})).To(Succeed())
+		})
+
+		reconcile := func() (controller.OperationResult, *maptv1alpha1.Kind) {
+			current := &maptv1alpha1.Kind{}
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), current)).To(Succeed())
+			adapter, err := newAdapter(ctx, fakeClient, current, mockProv, logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			adapter.RemoteClient = func([]byte) (client.Client, error) { return remote, nil }
+			result, err := adapter.EnsureGPUStackIsInstalled()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(kindObj), current)).To(Succeed())
+			return result, current
+		}
+
+		It("deploys the device plugin and waits for allocatable GPUs", func() {
+			result, updated := reconcile()
+			Expect(result.RequeueDelay).To(Equal(lifecycle.GPUStackRequeueInterval))
+			Expect(updated.Status.GPUStack).To(HaveField("Phase", maptv1alpha1.AddonPhaseInstalling))
+			Expect(updated.Status.GPUStack.Message).To(ContainSubstring("No node reports allocatable nvidia.com/gpu"))
+			Expect(meta.IsStatusConditionFalse(updated.Status.Conditions, maptv1alpha1.ConditionGPUReady)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(updated.Status.Conditions, maptv1alpha1.ConditionReady)).To(BeFalse())
+
+			config := &corev1.ConfigMap{}
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "nvidia-device-plugin-config", Namespace: "kube-system"}, config)).To(Succeed())
+			Expect(config.Data["config.yaml"]).To(ContainSubstring("replicas: 4"))
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "nvidia-device-plugin-daemonset", Namespace: "kube-system"}, &appsv1.DaemonSet{})).To(Succeed())
+
+			node := &corev1.Node{}
+			Expect(remote.Get(ctx, client.ObjectKey{Name: "gpu-worker"}, node)).To(Succeed())
+			node.Status.Allocatable = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")}
+			Expect(remote.Status().Update(ctx, node)).To(Succeed())
+
+			result, updated = reconcile()
+			Expect(result.RequeueDelay).To(BeZero())
+			Expect(updated.Status.GPUStack).To(HaveField("Phase", maptv1alpha1.AddonPhaseReady))
+			Expect(updated.Status.GPUStack.AllocatableGPUs).To(Equal(int64(4)))
+			Expect(meta.FindStatusCondition(updated.Status.Conditions, maptv1alpha1.ConditionGPUReady)).
+				To(HaveField("Status", metav1.ConditionTrue))
 		})
 	})
 })
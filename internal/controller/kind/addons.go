@@ -0,0 +1,181 @@
+package kind
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/addons"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// addonRequeueInterval is how often the add-ons of a cluster are checked until they are all ready.
+const addonRequeueInterval = 30 * time.Second
+
+// EnsureAddonsAreInstalled installs spec.addons on a running cluster, in order: an add-on is
+// applied once the previous one is ready. Progress is reported in status.addons and the
+// AddonsReady condition. Once an add-on is ready, it is only applied again when its source or
+// version changes; add-ons removed from the spec are left on the cluster.
+func (a *adapter) EnsureAddonsAreInstalled() (controller.OperationResult, error) {
+	if a.kind.GetDeletionTimestamp() != nil || a.kind.Status.Phase != v1alpha1.KindPhaseRunning ||
+		a.kind.Status.UpdateProvisionId != nil {
+		return controller.ContinueProcessing()
+	}
+
+	if len(a.kind.Spec.Addons) == 0 {
+		if len(a.kind.Status.Addons) == 0 && meta.FindStatusCondition(a.kind.Status.Conditions, v1alpha1.ConditionAddonsReady) == nil {
+			return controller.ContinueProcessing()
+		}
+		return a.recordAddons(nil)
+	}
+
+	statuses := desiredAddons(a.kind)
+	if pending := firstUnready(statuses); pending >= 0 {
+		if err := a.installAddons(statuses[pending:], a.kind.Spec.Addons[pending:]); err != nil {
+			a.Log.Error(err, "Failed to connect to the cluster to install its add-ons.")
+			statuses[pending].Phase = v1alpha1.AddonPhaseFailed
+			statuses[pending].Message = err.Error()
+		}
+	}
+	return a.recordAddons(statuses)
+}
+
+// installAddons applies the given add-ons in order, stopping at the first one that is not ready.
+// Failures of an add-on are reported in its status.
+func (a *adapter) installAddons(statuses []v1alpha1.AddonStatus, specs []v1alpha1.KindAddon) error {
+	remote, err := a.RemoteCluster()
+	if err != nil {
+		return err
+	}
+
+	for i, spec := range specs {
+		status := &statuses[i]
+		if status.Phase == v1alpha1.AddonPhaseReady {
+			continue
+		}
+
+		objs, err := addons.Load(a.Ctx, a.Client, a.kind.Namespace, spec)
+		if err == nil {
+			err = addons.Apply(a.Ctx, remote, objs)
+		}
+		if err != nil {
+			a.Log.Error(err, "Failed to install add-on.", "addon", spec.Name)
+			status.Phase = v1alpha1.AddonPhaseFailed
+			status.Message = err.Error()
+			return nil
+		}
+		now := metav1.Now()
+		status.LastAppliedTime = &now
+
+		ready, message, err := addons.Ready(a.Ctx, remote, objs)
+		switch {
+		case err != nil:
+			status.Phase = v1alpha1.AddonPhaseInstalling
+			status.Message = err.Error()
+			return nil
+		case !ready:
+			status.Phase = v1alpha1.AddonPhaseInstalling
+			status.Message = message
+			return nil
+		}
+		a.Log.Info("Add-on is ready.", "addon", spec.Name, "source", status.Source)
+		status.Phase = v1alpha1.AddonPhaseReady
+		status.Message = ""
+	}
+	return nil
+}
+
+// recordAddons stores the add-on statuses and the AddonsReady condition derived from them, and
+// requeues the cluster until every add-on is ready. Nil statuses clear both.
+func (a *adapter) recordAddons(statuses []v1alpha1.AddonStatus) (controller.OperationResult, error) {
+	conditions := append([]metav1.Condition{}, a.kind.Status.Conditions...)
+	if statuses == nil {
+		meta.RemoveStatusCondition(&conditions, v1alpha1.ConditionAddonsReady)
+	} else {
+		status, reason, message := addonsCondition(statuses)
+		controllerutils.SetCondition(&conditions, a.kind.Generation, v1alpha1.ConditionAddonsReady, status, reason, message)
+	}
+
+	if !equality.Semantic.DeepEqual(statuses, a.kind.Status.Addons) ||
+		!equality.Semantic.DeepEqual(conditions, a.kind.Status.Conditions) {
+		if err := a.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			s.Conditions = conditions
+			a.kind.Status.Addons = statuses
+		}); err != nil {
+			a.Log.Error(err, "Failed to record the add-ons of the cluster.")
+			return controller.RequeueWithError(err)
+		}
+	}
+
+	if firstUnready(statuses) >= 0 {
+		return controller.RequeueAfter(addonRequeueInterval, nil)
+	}
+	return controller.ContinueProcessing()
+}
+
+// desiredAddons returns the statuses of the add-ons of the spec, in order. The recorded status of
+// an add-on is kept while its source and version are unchanged; other add-ons are Pending.
+func desiredAddons(kind *v1alpha1.Kind) []v1alpha1.AddonStatus {
+	recorded := map[string]v1alpha1.AddonStatus{}
+	for _, s := range kind.Status.Addons {
+		recorded[s.Name] = s
+	}
+
+	statuses := make([]v1alpha1.AddonStatus, 0, len(kind.Spec.Addons))
+	for _, addon := range kind.Spec.Addons {
+		source, version := addons.Source(addon)
+		if s, ok := recorded[addon.Name]; ok && s.Source == source && s.Version == version {
+			statuses = append(statuses, *s.DeepCopy())
+			continue
+		}
+		statuses = append(statuses, v1alpha1.AddonStatus{
+			Name:    addon.Name,
+			Version: version,
+			Source:  source,
+			Phase:   v1alpha1.AddonPhasePending,
+		})
+	}
+	return statuses
+}
+
+// firstUnready returns the index of the first add-on that is not ready, or -1.
+func firstUnready(statuses []v1alpha1.AddonStatus) int {
+	for i, s := range statuses {
+		if s.Phase != v1alpha1.AddonPhaseReady {
+			return i
+		}
+	}
+	return -1
+}
+
+// addonsCondition returns the status, reason and message of the AddonsReady condition.
+func addonsCondition(statuses []v1alpha1.AddonStatus) (metav1.ConditionStatus, string, string) {
+	i := firstUnready(statuses)
+	if i < 0 {
+		return metav1.ConditionTrue, "AddonsReady", fmt.Sprintf("All %d add-ons are ready.", len(statuses))
+	}
+	s := statuses[i]
+	switch s.Phase {
+	case v1alpha1.AddonPhaseFailed:
+		return metav1.ConditionFalse, "AddonFailed", fmt.Sprintf("Add-on %s could not be installed: %s", s.Name, s.Message)
+	case v1alpha1.AddonPhaseInstalling:
+		return metav1.ConditionFalse, "AddonsInstalling", fmt.Sprintf("Installing add-on %s: %s", s.Name, s.Message)
+	}
+	return metav1.ConditionFalse, "AddonsPending", fmt.Sprintf("Add-on %s has not been installed yet.", s.Name)
+}
+
+// resetAddons marks the add-ons of a cluster whose infrastructure was replaced as Pending, so that
+// they are installed on the new cluster.
+func resetAddons(kind *v1alpha1.Kind) {
+	kind.Status.Addons = nil
+	if len(kind.Spec.Addons) == 0 {
+		meta.RemoveStatusCondition(&kind.Status.Conditions, v1alpha1.ConditionAddonsReady)
+		return
+	}
+	status, reason, message := addonsCondition(desiredAddons(kind))
+	controllerutils.SetCondition(&kind.Status.Conditions, kind.Generation, v1alpha1.ConditionAddonsReady, status, reason, message)
+}
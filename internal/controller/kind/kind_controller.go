@@ -7,14 +7,18 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/konflux-ci/operator-toolkit/controller"
 	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/controller/lifecycle"
 	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
 	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	crcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -22,6 +26,14 @@ type KindReconciler struct {
 	client.Client
 	Scheme      *runtime.Scheme
 	Provisioner clusters.GenericMaptProvisioner
+	// Limiter bounds concurrent provisioning operations. The operator-wide limiter is used when nil.
+	Limiter *concurrency.Limiter
+	// MaxConcurrentReconciles is the number of clusters reconciled at once. A single worker is used
+	// when zero, which serializes provisioning regardless of the concurrency limits.
+	MaxConcurrentReconciles int
+	// RemoteClient connects to provisioned clusters to install their add-ons and GPU stack.
+	// Clients are built from the kubeconfig of the cluster when nil.
+	RemoteClient lifecycle.RemoteClientFunc
 }
 
 func (r *KindReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -51,17 +63,34 @@ func (r *KindReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	if err != nil {
 		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to create adapter")
 	}
+	if r.Limiter != nil {
+		adapter.Limiter = r.Limiter
+	}
+	if r.RemoteClient != nil {
+		adapter.RemoteClient = r.RemoteClient
+	}
 
 	result, err := controller.ReconcileHandler([]controller.Operation{
 		adapter.EnsureFinalizersAreCalled,
 		adapter.EnsureFinalizerIsAdded,
-		adapter.EnsureKindClusterIsProvisioned,
+		adapter.EnsureExpirationIsEnforced,
+		adapter.EnsureClusterCostIsUpdated,
+		adapter.EnsureHibernationIsApplied,
+		adapter.EnsureSpecChangesAreApplied,
+		adapter.EnsureGPUStackIsInstalled,
+		adapter.EnsureAddonsAreInstalled,
+		adapter.EnsureQuotaIsAvailable,
+		adapter.EnsureClusterIsProvisioned,
 	})
 	if err != nil {
 		return result, controllerutils.LogError(logger, err, "Reconciliation failed")
 	}
 
-	result.RequeueAfter = 15 * time.Minute
+	result = controllerutils.RequeueBy(result, kindCopy.Status.NextTransition, time.Now())
+	result = controllerutils.RequeueBy(result, kindCopy.Status.ExpirationTimestamp, time.Now())
+	if result.RequeueAfter == 0 {
+		result.RequeueAfter = 15 * time.Minute
+	}
 	return result, nil
 }
 
@@ -72,6 +101,8 @@ func (r *KindReconciler) Register(mgr ctrl.Manager, log *logr.Logger, _ crcluste
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Kind{}).
 		Owns(&corev1.Secret{}).
+		Owns(&coordinationv1.Lease{}).
 		Named("kind").
+		WithOptions(crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"os"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -31,6 +32,7 @@ import (
 	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
 	"github.com/mapt-oss/mapt-operator/internal/metadata"
 	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -105,16 +107,13 @@ var _ = Describe("KindReconciler", func() {
 			DeferCleanup(os.Remove, tempFile.Name())
 			Expect(tempFile.Close()).To(Succeed())
 
-			mockProv.MockProvision = func(cluster *clusters.MaptCluster) (*clusters.ClusterProvisionerMetadata, error) {
-				return &clusters.ClusterProvisionerMetadata{
-					Type: clusters.KindClusterType,
-					KindMetadata: &clusters.KindMetadata{
-						Username:   "test-user",
-						PrivateKey: "mock-private-key",
-						Host:       "mock-host",
-						Kubeconfig: tempFile.Name(),
-						SpotPrice:  0.01,
-					},
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				return &clusters.KindMetadata{
+					Username:   "test-user",
+					PrivateKey: "mock-private-key",
+					Host:       "mock-host",
+					Kubeconfig: tempFile.Name(),
+					SpotPrice:  0.01,
 				}, nil
 			}
 
@@ -138,20 +137,20 @@ var _ = Describe("KindReconciler", func() {
 			Expect(updatedKind.Status.Phase).To(Equal(maptv1alpha1.KindPhaseRunning))
 			Expect(updatedKind.Status.ClusterReady).To(BeTrue())
 			Expect(*updatedKind.Status.ProvisionId).To(Not(BeEmpty()))
+			Expect(updatedKind.Status.ProvisionStartTime).NotTo(BeNil())
+			Expect(updatedKind.Status.Cost).NotTo(BeNil())
+			Expect(updatedKind.Status.Cost.HourlyRateUSD).To(Equal("0.0100"))
 		})
 
 		It("should update the status to Failed if provisioning fails", func() {
 			// 1. Setup
-			mockProv.MockProvision = func(cluster *clusters.MaptCluster) (*clusters.ClusterProvisionerMetadata, error) {
-				return &clusters.ClusterProvisionerMetadata{
-					Type: clusters.KindClusterType,
-					KindMetadata: &clusters.KindMetadata{
-						Username:   "test-user",
-						PrivateKey: "mock-private-key",
-						Host:       "mock-host",
-						Kubeconfig: "kubeconfig",
-						SpotPrice:  0.01,
-					},
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				return &clusters.KindMetadata{
+					Username:   "test-user",
+					PrivateKey: "mock-private-key",
+					Host:       "mock-host",
+					Kubeconfig: "kubeconfig",
+					SpotPrice:  0.01,
 				}, errors.New("pulumi exploded")
 			}
 
@@ -187,7 +186,7 @@ var _ = Describe("KindReconciler", func() {
 			reconciler.Client = fakeClient
 
 			deprovisionCalled := false
-			mockProv.MockDeprovision = func(cluster *clusters.MaptCluster) error {
+			mockProv.MockDeprovision = func(_ context.Context, cluster *clusters.MaptCluster) error {
 				deprovisionCalled = true
 				return nil
 			}
@@ -204,4 +203,77 @@ var _ = Describe("KindReconciler", func() {
 			Expect(apierrors.IsNotFound(err)).To(BeTrue())
 		})
 	})
+
+	Context("when reconciling several clusters at once", func() {
+		It("should queue the clusters exceeding the concurrency limits", func() {
+			tempFile, err := os.CreateTemp("", "kubeconfig-*.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.Remove, tempFile.Name())
+			Expect(tempFile.Close()).To(Succeed())
+
+			// Provisioning blocks until released, so the first clusters hold their slots.
+			started := make(chan string, 3)
+			release := make(chan struct{})
+			mockProv.MockProvision = func(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+				started <- cluster.Object.GetName()
+				<-release
+				return &clusters.KindMetadata{Host: "mock-host", Kubeconfig: tempFile.Name(), SpotPrice: 0.01}, nil
+			}
+
+			names := []string{"kind-a", "kind-b", "kind-c"}
+			for _, name := range names {
+				Expect(fakeClient.Create(ctx, &maptv1alpha1.Kind{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: KindNamespace,
+						// The fake client does not set UIDs, which identify the clusters to the limiter.
+						UID:        types.UID(name),
+						Finalizers: []string{metadata.KindFinalizer},
+					},
+				})).To(Succeed())
+			}
+			reconciler.Limiter = concurrency.NewLimiter(2, 0)
+			reconcile := func(name string) (ctrl.Result, error) {
+				return reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: KindNamespace}})
+			}
+
+			By("Provisioning two clusters in parallel workers")
+			var wg sync.WaitGroup
+			for _, name := range names[:2] {
+				wg.Add(1)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					_, err := reconcile(name)
+					Expect(err).NotTo(HaveOccurred())
+				}()
+			}
+			Eventually(started, timeout, interval).Should(Receive())
+			Eventually(started, timeout, interval).Should(Receive())
+
+			By("Queueing the third cluster while both slots are held")
+			result, err := reconcile(names[2])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(started).NotTo(Receive())
+
+			var queued maptv1alpha1.Kind
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: names[2], Namespace: KindNamespace}, &queued)).To(Succeed())
+			Expect(queued.Status.Phase).To(Equal(maptv1alpha1.ClusterPhaseQueued))
+			Expect(queued.Status.QueuePosition).To(HaveValue(BeEquivalentTo(1)))
+
+			By("Provisioning the third cluster once a slot is released")
+			close(release)
+			wg.Wait()
+			_, err = reconcile(names[2])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(started).To(Receive(Equal(names[2])))
+
+			for _, name := range names {
+				var kind maptv1alpha1.Kind
+				Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: KindNamespace}, &kind)).To(Succeed())
+				Expect(kind.Status.Phase).To(Equal(maptv1alpha1.KindPhaseRunning))
+			}
+		})
+	})
 })
@@ -1,6 +1,7 @@
 package kind
 
 import (
+	"context"
 	"errors"
 
 	"github.com/mapt-oss/mapt-operator/pkg/clusters"
@@ -8,20 +9,20 @@ import (
 
 // MockProvisioner is a mock implementation of GenericMaptProvisioner for testing.
 type MockProvisioner struct {
-	MockProvision   func(cluster *clusters.MaptCluster) (*clusters.ClusterProvisionerMetadata, error)
-	MockDeprovision func(cluster *clusters.MaptCluster) error
+	MockProvision   func(ctx context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error)
+	MockDeprovision func(ctx context.Context, cluster *clusters.MaptCluster) error
 }
 
-func (m *MockProvisioner) Provision(cluster *clusters.MaptCluster) (*clusters.ClusterProvisionerMetadata, error) {
+func (m *MockProvisioner) Provision(ctx context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
 	if m.MockProvision != nil {
-		return m.MockProvision(cluster)
+		return m.MockProvision(ctx, cluster)
 	}
 	return nil, errors.New("MockProvision function was not implemented for this test")
 }
 
-func (m *MockProvisioner) Deprovision(cluster *clusters.MaptCluster) error {
+func (m *MockProvisioner) Deprovision(ctx context.Context, cluster *clusters.MaptCluster) error {
 	if m.MockDeprovision != nil {
-		return m.MockDeprovision(cluster)
+		return m.MockDeprovision(ctx, cluster)
 	}
 	return errors.New("MockDeprovision function was not implemented for this test")
 }
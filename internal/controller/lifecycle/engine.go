@@ -0,0 +1,609 @@
+// Package lifecycle implements the reconciliation shared by every cluster type: finalizers, quota
+// and concurrency admission, provisioning, access Secrets, cost, status and the GPU stack. Cluster types plug in
+// with a Definition holding a few hooks, and add their own operations around the engine ones.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/addons"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	"github.com/mapt-oss/mapt-operator/pkg/quota"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// QuotaRequeueInterval is how often a Queued cluster checks whether quota has been freed.
+	QuotaRequeueInterval = time.Minute
+
+	// SlotRequeueInterval is how often a cluster waiting for a provisioning slot polls the limiter.
+	SlotRequeueInterval = 15 * time.Second
+)
+
+// Cluster is the API object of a cluster type managed by the engine.
+type Cluster interface {
+	client.Object
+	GetClusterStatus() *v1alpha1.ClusterStatus
+}
+
+// Settings are the parts of the spec of a cluster the engine acts on.
+type Settings struct {
+	// MachineConfig is checked against the MaptQuotas of the namespace.
+	MachineConfig v1alpha1.MachineConfig
+	// TerminationPolicy bounds the lifetime of the cluster; nil means it is not bounded.
+	TerminationPolicy *v1alpha1.TerminationPolicy
+	// ProvisioningTimeout bounds how long provisioning may take; nil means it is not bounded.
+	ProvisioningTimeout *metav1.Duration
+	// StateBackend selects where the provisioning state is stored; nil means the operator default.
+	StateBackend *v1alpha1.StateBackend
+	// Priority orders the cluster in the provisioning queue.
+	Priority int32
+	// Hibernate requests the machine of the cluster to be stopped, keeping its disks.
+	Hibernate bool
+	// Schedule defines when the cluster should be up; nil means it is always up.
+	Schedule *v1alpha1.Schedule
+	// GPUStack is installed on the running cluster; nil means the cluster has no GPU stack.
+	GPUStack *v1alpha1.GPUStack
+}
+
+// RemoteClientFunc returns a client for the cluster a kubeconfig points at.
+type RemoteClientFunc func(kubeconfig []byte) (client.Client, error)
+
+// Access describes how to reach a provisioned cluster.
+type Access struct {
+	// SecretData is the content of the Secret handed to users of the cluster.
+	SecretData map[string][]byte
+	// HourlyRate is the hourly price paid for the cluster, in USD.
+	HourlyRate controllerutils.HourlyRate
+	// Host is the address the cluster is reachable at. When it changes on resume from hibernation,
+	// every occurrence of it in SecretData is replaced with the new address.
+	Host string
+	// Placement is where the instance of the cluster runs; its fields are empty when unknown.
+	Placement clusters.Placement
+	// Attempts are the fallback strategies tried to provision the cluster.
+	Attempts []v1alpha1.ProvisioningAttempt
+}
+
+// Definition plugs a cluster type into the engine. ClusterType, Finalizer, Settings and Access are
+// required; the other hooks are optional.
+type Definition[T Cluster] struct {
+	// ClusterType selects the provider of the cluster type.
+	ClusterType clusters.ClusterType
+	// Finalizer guards the cloud resources of the cluster.
+	Finalizer string
+	// Settings returns the parts of the spec of obj the engine acts on.
+	Settings func(obj T) Settings
+	// Access validates the metadata reported by the provider and returns how to reach the cluster.
+	Access func(obj T, result clusters.ClusterProvisionerMetadata) (*Access, error)
+
+	// BeforeDeprovision runs before the infrastructure of the cluster is destroyed, when it is deleted
+	// or brought down by its schedule.
+	BeforeDeprovision func() error
+	// Provisioned runs within the status update marking obj as Running, so that type specific
+	// status fields are recorded along.
+	Provisioned func(obj T)
+	// UpdateInProgress is set by cluster types that apply spec changes to running clusters. The
+	// engine then leaves status.observedGeneration of running clusters to the cluster type, and
+	// reports them as reconciling while UpdateInProgress returns true.
+	UpdateInProgress func(obj T) bool
+}
+
+// Engine reconciles one cluster object. Its fields are shared with the operations a cluster type
+// adds around the engine ones.
+type Engine[T Cluster] struct {
+	// Client is the Kubernetes client used to interact with the API server.
+	Client client.Client
+	// Ctx is the context for the reconciliation process.
+	Ctx context.Context
+	// Object is the cluster being reconciled; status updates are applied to it in place.
+	Object T
+	// Provisioner creates and destroys the cloud infrastructure of the cluster.
+	Provisioner clusters.GenericMaptProvisioner
+	// Limiter bounds the number of concurrent Provision and Deprovision calls.
+	Limiter *concurrency.Limiter
+	// Log is the logger used for logging messages during reconciliation.
+	Log logr.Logger
+	// RemoteClient connects to the provisioned cluster, e.g. to install software on it.
+	RemoteClient RemoteClientFunc
+
+	def Definition[T]
+}
+
+// New returns the engine reconciling obj. Returns an error if the provisioner is nil or the
+// definition lacks a required hook.
+func New[T Cluster](ctx context.Context, c client.Client, obj T, prv clusters.GenericMaptProvisioner, def Definition[T], l logr.Logger) (*Engine[T], error) {
+	if prv == nil {
+		return nil, fmt.Errorf("no provisioner provided")
+	}
+	if def.ClusterType == "" || def.Finalizer == "" || def.Settings == nil || def.Access == nil {
+		return nil, fmt.Errorf("incomplete definition for cluster type %q", def.ClusterType)
+	}
+	return &Engine[T]{
+		Client:       c,
+		Ctx:          ctx,
+		Object:       obj,
+		Provisioner:  prv,
+		Limiter:      concurrency.Shared(),
+		Log:          l.WithValues("name", obj.GetName(), "namespace", obj.GetNamespace()),
+		RemoteClient: addons.NewClient,
+		def:          def,
+	}, nil
+}
+
+// EnsureFinalizerIsAdded ensures the finalizer is present on the cluster.
+func (e *Engine[T]) EnsureFinalizerIsAdded() (controller.OperationResult, error) {
+	if controllerutil.ContainsFinalizer(e.Object, e.def.Finalizer) {
+		return controller.ContinueProcessing()
+	}
+
+	patch := client.MergeFrom(e.Object.DeepCopyObject().(client.Object))
+	controllerutil.AddFinalizer(e.Object, e.def.Finalizer)
+	if err := e.Client.Patch(e.Ctx, e.Object, patch); err != nil {
+		e.Log.Error(err, "Failed to add finalizer.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.ContinueProcessing()
+}
+
+// EnsureFinalizersAreCalled destroys the infrastructure of a cluster being deleted and removes the
+// finalizer once it is gone.
+func (e *Engine[T]) EnsureFinalizersAreCalled() (controller.OperationResult, error) {
+	if e.Object.GetDeletionTimestamp() == nil || !controllerutil.ContainsFinalizer(e.Object, e.def.Finalizer) {
+		return controller.ContinueProcessing()
+	}
+
+	if e.provisioned() {
+		granted, err := e.AcquireSlot(func(position int32) error {
+			return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+				*s = *NewStatusBuilder(e.Object).
+					Phase(v1alpha1.ClusterPhaseDeleting).
+					Message(fmt.Sprintf("Waiting for a deprovisioning slot (position %d in queue).", position)).
+					QueuePosition(&position).
+					Status
+			})
+		})
+		if err != nil {
+			e.Log.Error(err, "Failed to mark cluster as waiting for a deprovisioning slot.")
+			return controller.RequeueWithError(err)
+		}
+		if !granted {
+			return controller.RequeueAfter(SlotRequeueInterval, nil)
+		}
+		defer e.Limiter.Release(string(e.Object.GetUID()))
+	}
+
+	if err := e.finalize(); err != nil {
+		e.Log.Error(err, "Finalization failed during deprovisioning.")
+		return controller.RequeueWithError(err)
+	}
+
+	patch := client.MergeFrom(e.Object.DeepCopyObject().(client.Object))
+	controllerutil.RemoveFinalizer(e.Object, e.def.Finalizer)
+	if err := e.Client.Patch(e.Ctx, e.Object, patch); err != nil {
+		e.Log.Error(err, "Failed to remove finalizer from resource.")
+		return controller.RequeueWithError(err)
+	}
+	e.Limiter.Forget(string(e.Object.GetUID()))
+	return controller.Requeue()
+}
+
+// EnsureClusterCostIsUpdated refreshes the accrued cost and the Expiring condition of a running
+// cluster. The status is only patched when one of them changed.
+func (e *Engine[T]) EnsureClusterCostIsUpdated() (controller.OperationResult, error) {
+	status := e.Object.GetClusterStatus()
+	if e.Object.GetDeletionTimestamp() != nil || status.Phase != v1alpha1.ClusterPhaseRunning || status.Cost == nil {
+		return controller.ContinueProcessing()
+	}
+
+	hourlyRate, err := e.hourlyRate()
+	if err != nil {
+		e.Log.Error(err, "Failed to parse hourly rate; skipping cost refresh.", "hourlyRate", status.Cost.HourlyRateUSD)
+		return controller.ContinueProcessing()
+	}
+
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	updated := NewStatusBuilder(e.Object).
+		Cost(hourlyRate, policy).
+		Expiring(policy).
+		Status
+	if equality.Semantic.DeepEqual(updated.Cost, status.Cost) &&
+		equality.Semantic.DeepEqual(updated.Conditions, status.Conditions) {
+		return controller.ContinueProcessing()
+	}
+
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		s.Cost = updated.Cost
+		s.Conditions = updated.Conditions
+	}); err != nil {
+		e.Log.Error(err, "Failed to update cluster cost.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.ContinueProcessing()
+}
+
+// EnsureQuotaIsAvailable holds a cluster that has not started provisioning in the Queued phase
+// while starting it would exceed a MaptQuota of its namespace. It acts as a fallback for the
+// admission webhook, e.g. when the webhook is disabled or concurrent creations raced it.
+func (e *Engine[T]) EnsureQuotaIsAvailable() (controller.OperationResult, error) {
+	if e.Object.GetDeletionTimestamp() != nil || e.provisioned() {
+		return controller.ContinueProcessing()
+	}
+	switch e.Object.GetClusterStatus().Phase {
+	case "", v1alpha1.ClusterPhasePending, v1alpha1.ClusterPhaseQueued:
+	default:
+		return controller.ContinueProcessing()
+	}
+
+	err := quota.Check(e.Ctx, e.Client, e.Object, e.def.Settings(e.Object).MachineConfig, quota.ActiveClusters)
+	if err == nil {
+		return controller.ContinueProcessing()
+	}
+	if !quota.IsExceeded(err) {
+		e.Log.Error(err, "Failed to evaluate namespace quota.")
+		return controller.RequeueWithError(err)
+	}
+
+	e.Log.Info("Quota exceeded; cluster is queued until capacity frees up.", "reason", err.Error())
+	if updateErr := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseQueued).
+			Message(fmt.Sprintf("Waiting for quota to free up: %s", err.Error())).
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "QuotaExceeded", fmt.Sprintf("Provisioning is on hold: %s", err.Error())).
+			Status
+	}); updateErr != nil {
+		e.Log.Error(updateErr, "Failed to mark cluster as queued.")
+		return controller.RequeueWithError(updateErr)
+	}
+	return controller.RequeueAfter(QuotaRequeueInterval, nil)
+}
+
+// EnsureClusterIsProvisioned provisions a cluster that has not been provisioned yet, and enforces
+// the provisioning timeout of a provisioning that was interrupted.
+func (e *Engine[T]) EnsureClusterIsProvisioned() (controller.OperationResult, error) {
+	if e.Object.GetDeletionTimestamp() != nil {
+		return controller.ContinueProcessing()
+	}
+
+	status := e.Object.GetClusterStatus()
+	switch status.Phase {
+	case v1alpha1.ClusterPhaseProvisioning:
+		// Provisioning runs within a single reconciliation, so seeing this phase means the run that
+		// set it was interrupted, e.g. by an operator restart. Enforce its timeout if it has one.
+		deadline := controllerutils.ProvisioningDeadline(infrastructureStartTime(status), e.def.Settings(e.Object).ProvisioningTimeout)
+		if deadline != nil {
+			if remaining := time.Until(*deadline); remaining > 0 {
+				return controller.RequeueAfter(remaining, nil)
+			}
+			return e.timeOut()
+		}
+		e.Log.Info("Cluster is currently being provisioned.", "phase", status.Phase)
+		return controller.StopProcessing()
+	case v1alpha1.ClusterPhaseRunning, v1alpha1.ClusterPhaseHibernated:
+		e.Log.Info("Cluster is already provisioned.", "phase", status.Phase)
+		return controller.StopProcessing()
+	case v1alpha1.ClusterPhaseFailed:
+		e.Log.Info("Cluster provisioning previously failed. Stopping further retries.", "phase", status.Phase)
+		return controller.StopProcessing()
+	}
+
+	return e.provision()
+}
+
+// AcquireSlot reserves a provisioning slot for the cluster. When the concurrency limits are reached,
+// the cluster is queued and queued is called with its position so that the status can reflect it.
+// The per-account limit is enforced for the operator cloud credentials, which every cluster is
+// provisioned with.
+func (e *Engine[T]) AcquireSlot(queued func(position int32) error) (bool, error) {
+	settings := e.def.Settings(e.Object)
+	account := clusters.CloudCredentialsSecretKey()
+
+	granted, position := e.Limiter.Acquire(concurrency.Request{
+		ID:           string(e.Object.GetUID()),
+		Account:      account.String(),
+		AccountLimit: concurrency.AccountLimit(e.Ctx, e.Client, account),
+		Priority:     settings.Priority,
+		CreatedAt:    e.Object.GetCreationTimestamp().Time,
+	})
+	if granted {
+		return true, nil
+	}
+
+	e.Log.Info("Concurrency limit reached; waiting for a slot.", "position", position, "account", account.String())
+	return false, queued(int32(position))
+}
+
+// UpdateAccessSecret writes data into the existing access Secret of the cluster, keeping the keys
+// data does not set.
+func (e *Engine[T]) UpdateAccessSecret(data map[string][]byte) error {
+	name := e.Object.GetClusterStatus().KubeconfigSecretName
+	if name == nil {
+		return fmt.Errorf("cluster has no access secret")
+	}
+	secret := &corev1.Secret{}
+	if err := e.Client.Get(e.Ctx, client.ObjectKey{Name: *name, Namespace: e.Object.GetNamespace()}, secret); err != nil {
+		return fmt.Errorf("failed to get access secret: %w", err)
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for k, v := range data {
+		secret.Data[k] = v
+	}
+	if err := e.Client.Update(e.Ctx, secret); err != nil {
+		return fmt.Errorf("failed to update access secret: %w", err)
+	}
+	return nil
+}
+
+// RemoteCluster returns a client for the provisioned cluster, using the kubeconfig of its access Secret.
+func (e *Engine[T]) RemoteCluster() (client.Client, error) {
+	name := e.Object.GetClusterStatus().KubeconfigSecretName
+	if name == nil {
+		return nil, fmt.Errorf("cluster has no access secret")
+	}
+	secret := &corev1.Secret{}
+	if err := e.Client.Get(e.Ctx, client.ObjectKey{Name: *name, Namespace: e.Object.GetNamespace()}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get access secret: %w", err)
+	}
+	return e.RemoteClient(secret.Data[kubeconfigKey])
+}
+
+// provision provisions the cluster, stores its access Secret and marks it as Running.
+func (e *Engine[T]) provision() (controller.OperationResult, error) {
+	granted, err := e.AcquireSlot(func(position int32) error {
+		return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Phase(v1alpha1.ClusterPhaseQueued).
+				Message(fmt.Sprintf("Waiting for a provisioning slot (position %d in queue).", position)).
+				Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "ConcurrencyLimitReached", "Provisioning is on hold until the operator concurrency limits admit the cluster.").
+				QueuePosition(&position).
+				Status
+		})
+	})
+	if err != nil {
+		e.Log.Error(err, "Failed to mark cluster as waiting for a provisioning slot.")
+		return controller.RequeueWithError(err)
+	}
+	if !granted {
+		return controller.RequeueAfter(SlotRequeueInterval, nil)
+	}
+	defer e.Limiter.Release(string(e.Object.GetUID()))
+
+	if err := e.markProvisioningStarted(); err != nil {
+		e.Log.Error(err, "Failed to mark provisioning as started.")
+		return controller.RequeueWithError(err)
+	}
+
+	provisionCtx, cancel := controllerutils.ProvisioningContext(e.Ctx, e.Client, e.Object,
+		controllerutils.ProvisioningDeadline(infrastructureStartTime(e.Object.GetClusterStatus()), e.def.Settings(e.Object).ProvisioningTimeout))
+	defer cancel()
+	result, err := e.Provisioner.Provision(provisionCtx, &clusters.MaptCluster{
+		Type:   e.def.ClusterType,
+		Object: e.Object,
+	})
+	if err != nil {
+		switch cause := context.Cause(provisionCtx); {
+		case errors.Is(cause, controllerutils.ErrProvisioningTimedOut):
+			return e.timeOut()
+		case errors.Is(cause, controllerutils.ErrObjectDeleted):
+			e.Log.Info("Cluster deleted while provisioning; the finalizer destroys the created resources.")
+			return controller.Requeue()
+		case e.Ctx.Err() != nil:
+			e.Log.Info("Reconciliation cancelled while provisioning.", "reason", err.Error())
+			return controller.RequeueWithError(err)
+		}
+		return e.markProvisioningFailed(err)
+	}
+
+	access, err := e.def.Access(e.Object, result)
+	if err != nil {
+		return e.markProvisioningFailed(err)
+	}
+
+	secretName, err := e.storeAccessSecret(access.SecretData)
+	if err != nil {
+		e.Log.Error(err, "Failed to store the access secret after successful provisioning.")
+		return e.markSecretCreationFailed(err)
+	}
+	return e.markRunning(secretName, access)
+}
+
+// storeAccessSecret creates the access Secret of the cluster, or updates it in place when the
+// cluster already has one, e.g. because it was recreated to apply spec changes.
+func (e *Engine[T]) storeAccessSecret(data map[string][]byte) (string, error) {
+	if name := e.Object.GetClusterStatus().KubeconfigSecretName; name != nil {
+		return *name, e.UpdateAccessSecret(data)
+	}
+	return controllerutils.CreateGeneratedSecret(e.Ctx, e.Client, e.Client.Scheme(), data, e.Object)
+}
+
+// markProvisioningStarted sets the phase to Provisioning and assigns a new provision ID.
+func (e *Engine[T]) markProvisioningStarted() error {
+	if e.provisioned() {
+		e.Log.Info("Provisioning already started; skipping ProvisionId generation.")
+		return nil
+	}
+
+	provisionID := uuid.New().String()
+	return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseProvisioning).
+			Message("Provisioning of the cluster has started.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "ProvisioningStarted", "Cluster provisioning has been initiated and is in progress.").
+			BackendID(provisionID).
+			ProvisionStartTime(metav1.Now()).
+			StateBackend(clusters.ResolveStateBackend(e.def.Settings(e.Object).StateBackend)).
+			QueuePosition(nil).
+			Status
+	})
+}
+
+// markRunning records a successful provisioning.
+func (e *Engine[T]) markRunning(secretName string, access *Access) (controller.OperationResult, error) {
+	e.Log.Info("Cluster successfully provisioned.", "secret", secretName)
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseRunning).
+			Message("Cluster successfully provisioned and ready.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionTrue, "Provisioned", "The cluster infrastructure has been provisioned.").
+			Condition(v1alpha1.ConditionAccessSecretReady, metav1.ConditionTrue, "SecretReady", fmt.Sprintf("The Secret %s holds the cluster credentials.", secretName)).
+			Condition(v1alpha1.ConditionHealthy, metav1.ConditionTrue, "ClusterRunning", "The cluster has been successfully created and is ready for use.").
+			KubeconfigSecret(secretName).
+			Host(access.Host).
+			Placement(access.Placement).
+			ProvisioningAttempts(access.Attempts).
+			AvgPrice(access.HourlyRate.Total()).
+			Cost(access.HourlyRate, policy).
+			Expiring(policy).
+			Status
+		s.ClusterReady = true
+		e.ResetGPUStack(s)
+		if e.def.Provisioned != nil {
+			e.def.Provisioned(e.Object)
+		}
+	}); err != nil {
+		e.Log.Error(err, "Failed to update status to Running after successful provisioning.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.ContinueProcessing()
+}
+
+// markProvisioningFailed marks the cluster as Failed after provisioning failed.
+func (e *Engine[T]) markProvisioningFailed(err error) (controller.OperationResult, error) {
+	e.Log.Error(err, "Cluster provisioning failed.")
+	var attempts []v1alpha1.ProvisioningAttempt
+	var attemptsErr *clusters.AttemptsError
+	if errors.As(err, &attemptsErr) {
+		attempts = attemptsErr.Attempts
+	}
+	_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseFailed).
+			Message(fmt.Sprintf("Failed to provision cluster: %s", err.Error())).
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "ProvisioningFailed", fmt.Sprintf("Provisioning error: %s", err.Error())).
+			ProvisioningAttempts(attempts).
+			Status
+	})
+	return controller.RequeueWithError(err)
+}
+
+// markSecretCreationFailed marks the cluster as Failed after its access Secret could not be stored.
+func (e *Engine[T]) markSecretCreationFailed(err error) (controller.OperationResult, error) {
+	_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseFailed).
+			Message(fmt.Sprintf("Error storing the access secret: %s", err.Error())).
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionTrue, "Provisioned", "The cluster infrastructure has been provisioned.").
+			Condition(v1alpha1.ConditionAccessSecretReady, metav1.ConditionFalse, "SecretCreationFailed", fmt.Sprintf("Could not store the access secret: %s", err.Error())).
+			Status
+	})
+	return controller.RequeueWithError(err)
+}
+
+// timeOut destroys the resources created before spec.provisioningTimeout elapsed and marks the
+// cluster as Failed. Resources that could not be destroyed are retried on deletion.
+func (e *Engine[T]) timeOut() (controller.OperationResult, error) {
+	timeout := e.def.Settings(e.Object).ProvisioningTimeout.Duration
+	e.Log.Info("Provisioning timed out; destroying partially created resources.", "timeout", timeout)
+
+	message := fmt.Sprintf("Provisioning did not complete within %s; partially created resources were destroyed.", timeout)
+	if err := e.Provisioner.Deprovision(e.Ctx, &clusters.MaptCluster{
+		Type:   e.def.ClusterType,
+		Object: e.Object,
+	}); err != nil {
+		e.Log.Error(err, "Failed to destroy partially created resources after provisioning timed out.")
+		message = fmt.Sprintf("Provisioning did not complete within %s; destroying partially created resources failed: %s. "+
+			"They are destroyed again when the cluster is deleted.", timeout, err.Error())
+	}
+
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseFailed).
+			Message(message).
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "ProvisioningTimedOut", fmt.Sprintf("Provisioning exceeded the %s timeout.", timeout)).
+			Status
+	}); err != nil {
+		e.Log.Error(err, "Failed to mark cluster as timed out.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.StopProcessing()
+}
+
+// finalize destroys the infrastructure of a cluster being deleted.
+func (e *Engine[T]) finalize() error {
+	if !e.provisioned() {
+		e.Log.Info("No provision ID found; skipping deprovisioning.")
+		return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Phase(v1alpha1.ClusterPhaseDeleting).
+				Message("Skipping deprovisioning: no external resources found for this cluster.").
+				Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "DeprovisionSkipped", "Cluster marked for deletion, but no provision ID exists; assuming no external resources.").
+				Status
+		})
+	}
+
+	if e.def.BeforeDeprovision != nil {
+		if err := e.def.BeforeDeprovision(); err != nil {
+			return err
+		}
+	}
+
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseDeleting).
+			Message("Deprovisioning in progress: external resources are being deleted.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, string(v1alpha1.ClusterPhaseDeleting), "Cluster deletion requested; associated infrastructure cleanup in progress.").
+			QueuePosition(nil).
+			Status
+	}); err != nil {
+		return err
+	}
+
+	if err := e.Provisioner.Deprovision(e.Ctx, &clusters.MaptCluster{
+		Type:   e.def.ClusterType,
+		Object: e.Object,
+	}); err != nil {
+		e.Log.Error(err, "Deprovisioning failed.", "provisionId", *e.Object.GetClusterStatus().ProvisionId)
+		_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Phase(v1alpha1.ClusterPhaseFailed).
+				Message(fmt.Sprintf("Failed to deprovision cluster: %s", err.Error())).
+				Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionUnknown, "DeprovisioningFailed", fmt.Sprintf("Error while deprovisioning cluster: %s", err.Error())).
+				Status
+		})
+		return err
+	}
+
+	return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseDeleting).
+			Message("Cluster resources successfully deprovisioned.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "Deprovisioned", "Cluster marked as deleted.").
+			Status
+	})
+}
+
+// hourlyRate returns the hourly rate the cost of the cluster is computed with.
+func (e *Engine[T]) hourlyRate() (controllerutils.HourlyRate, error) {
+	return controllerutils.ParseHourlyRate(e.Object.GetClusterStatus().Cost)
+}
+
+// provisioned reports whether provisioning of the cluster has started.
+func (e *Engine[T]) provisioned() bool {
+	id := e.Object.GetClusterStatus().ProvisionId
+	return id != nil && *id != ""
+}
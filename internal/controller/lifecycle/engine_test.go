@@ -0,0 +1,704 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/metadata"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+)
+
+// testProvisioner records the calls made by the engine.
+type testProvisioner struct {
+	provisionErr   error
+	deprovisionErr error
+	provisioned    int
+	deprovisioned  int
+	resumeHost     string
+	hibernated     int
+	resumed        int
+}
+
+func (p *testProvisioner) Provision(_ context.Context, cluster *clusters.MaptCluster) (clusters.ClusterProvisionerMetadata, error) {
+	p.provisioned++
+	if p.provisionErr != nil {
+		return nil, p.provisionErr
+	}
+	return &clusters.KindMetadata{Host: "10.0.0.1", Kubeconfig: testKubeconfig(cluster.Object.GetName(), "10.0.0.1")}, nil
+}
+
+// testKubeconfig returns a kubeconfig for the API server of the named cluster at host.
+func testKubeconfig(name, host string) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: %[1]s
+  cluster:
+    server: https://%[2]s:6443
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+    user: %[1]s
+current-context: %[1]s
+users:
+- name: %[1]s
+  user:
+    token: secret
+`, name, host)
+}
+
+func (p *testProvisioner) Deprovision(_ context.Context, _ *clusters.MaptCluster) error {
+	p.deprovisioned++
+	return p.deprovisionErr
+}
+
+func (p *testProvisioner) Hibernate(_ context.Context, _ *clusters.MaptCluster) (string, error) {
+	p.hibernated++
+	return "i-0123456789", nil
+}
+
+func (p *testProvisioner) Resume(_ context.Context, _ *clusters.MaptCluster) (string, error) {
+	p.resumed++
+	return p.resumeHost, nil
+}
+
+// nonHibernatingProvisioner hides the Hibernator implementation of a testProvisioner.
+type nonHibernatingProvisioner struct {
+	clusters.GenericMaptProvisioner
+}
+
+// engineCase describes how a cluster type plugs into the engine.
+type engineCase[T Cluster] struct {
+	newCluster   func(name string) T
+	definition   func(hooks *hookCalls) Definition[T]
+	setHibernate func(obj T, hibernate bool)
+	setSchedule  func(obj T, schedule *maptv1alpha1.Schedule, spot bool)
+	setPolicy    func(obj T, policy maptv1alpha1.TerminationPolicy)
+	setGPUStack  func(obj T, stack *maptv1alpha1.GPUStack)
+}
+
+// hookCalls counts the calls of the optional hooks of a definition.
+type hookCalls struct {
+	provisioned       int
+	beforeDeprovision int
+	beforeErr         error
+}
+
+func testAccess[T Cluster](_ T, result clusters.ClusterProvisionerMetadata) (*Access, error) {
+	md, ok := result.(*clusters.KindMetadata)
+	if !ok || md.Kubeconfig == "" {
+		return nil, errors.New("provisioner returned empty kubeconfig")
+	}
+	return &Access{SecretData: map[string][]byte{"kubeconfig": []byte(md.Kubeconfig)}, HourlyRate: controllerutils.HourlyRate{Instance: 0.5}, Host: md.Host}, nil
+}
+
+func testDefinition[T Cluster](clusterType clusters.ClusterType, finalizer string, settings func(T) Settings, hooks *hookCalls) Definition[T] {
+	return Definition[T]{
+		ClusterType: clusterType,
+		Finalizer:   finalizer,
+		Settings:    settings,
+		Access:      testAccess[T],
+		BeforeDeprovision: func() error {
+			hooks.beforeDeprovision++
+			return hooks.beforeErr
+		},
+		Provisioned: func(T) { hooks.provisioned++ },
+	}
+}
+
+var kindCase = engineCase[*maptv1alpha1.Kind]{
+	newCluster: func(name string) *maptv1alpha1.Kind {
+		return &maptv1alpha1.Kind{
+			TypeMeta:   metav1.TypeMeta{APIVersion: maptv1alpha1.GroupVersion.String(), Kind: "Kind"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: maptv1alpha1.KindSpec{
+				CloudConfig: maptv1alpha1.CloudConfig{
+					Provider:             "AWS",
+					CredentialsSecretRef: corev1.LocalObjectReference{Name: "aws-credentials"},
+				},
+				KindClusterConfig: maptv1alpha1.KindClusterConfig{KubernetesVersion: "v1.30.0"},
+			},
+		}
+	},
+	definition: func(hooks *hookCalls) Definition[*maptv1alpha1.Kind] {
+		return testDefinition(clusters.KindClusterType, "mapt.redhat.com/test-kind", func(k *maptv1alpha1.Kind) Settings {
+			return Settings{MachineConfig: k.Spec.MachineConfig, TerminationPolicy: k.Spec.TerminationPolicy, Hibernate: k.Spec.Hibernate, Schedule: k.Spec.Schedule,
+				GPUStack: k.Spec.GPUStack}
+		}, hooks)
+	},
+	setHibernate: func(k *maptv1alpha1.Kind, hibernate bool) { k.Spec.Hibernate = hibernate },
+	setSchedule: func(k *maptv1alpha1.Kind, schedule *maptv1alpha1.Schedule, spot bool) {
+		k.Spec.Schedule = schedule
+		k.Spec.MachineConfig.UseSpotInstances = spot
+	},
+	setPolicy:   func(k *maptv1alpha1.Kind, policy maptv1alpha1.TerminationPolicy) { k.Spec.TerminationPolicy = &policy },
+	setGPUStack: func(k *maptv1alpha1.Kind, stack *maptv1alpha1.GPUStack) { k.Spec.GPUStack = stack },
+}
+
+var openshiftCase = engineCase[*maptv1alpha1.Openshift]{
+	newCluster: func(name string) *maptv1alpha1.Openshift {
+		return &maptv1alpha1.Openshift{
+			TypeMeta:   metav1.TypeMeta{APIVersion: maptv1alpha1.GroupVersion.String(), Kind: "Openshift"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: maptv1alpha1.OpenshiftSpec{
+				OpenshiftClusterConfig: maptv1alpha1.OpenshiftClusterConfig{OpenshiftVersion: "4.18.0"},
+			},
+		}
+	},
+	definition: func(hooks *hookCalls) Definition[*maptv1alpha1.Openshift] {
+		return testDefinition(clusters.OpenshiftClusterType, "mapt.redhat.com/test-openshift", func(o *maptv1alpha1.Openshift) Settings {
+			return Settings{MachineConfig: o.Spec.MachineConfig, TerminationPolicy: &o.Spec.TerminationPolicy, Hibernate: o.Spec.Hibernate, Schedule: o.Spec.Schedule,
+				GPUStack: o.Spec.GPUStack}
+		}, hooks)
+	},
+	setHibernate: func(o *maptv1alpha1.Openshift, hibernate bool) { o.Spec.Hibernate = hibernate },
+	setSchedule: func(o *maptv1alpha1.Openshift, schedule *maptv1alpha1.Schedule, spot bool) {
+		o.Spec.Schedule = schedule
+		o.Spec.MachineConfig.UseSpotInstances = spot
+	},
+	setPolicy: func(o *maptv1alpha1.Openshift, policy maptv1alpha1.TerminationPolicy) {
+		o.Spec.TerminationPolicy = policy
+	},
+	setGPUStack: func(o *maptv1alpha1.Openshift, stack *maptv1alpha1.GPUStack) { o.Spec.GPUStack = stack },
+}
+
+var _ = Describe("Engine", func() {
+	describeEngine("Kind", kindCase)
+	describeEngine("Openshift", openshiftCase)
+
+	It("rejects an incomplete definition", func() {
+		_, err := New(ctx, k8sClient, kindCase.newCluster("incomplete"), &testProvisioner{},
+			Definition[*maptv1alpha1.Kind]{ClusterType: clusters.KindClusterType}, logr.Discard())
+		Expect(err).To(MatchError(ContainSubstring("incomplete definition")))
+	})
+
+	DescribeTable("points the values of an access secret at the new address",
+		func(value, expected string) {
+			Expect(readdressValue(value, "10.0.0.1", "10.0.0.2")).To(Equal(expected))
+		},
+		Entry("the address", "10.0.0.1", "10.0.0.2"),
+		Entry("a URL of the address", "https://10.0.0.1:6443", "https://10.0.0.2:6443"),
+		Entry("a URL of a wildcard DNS name", "https://console.apps.10.0.0.1.nip.io", "https://console.apps.10.0.0.2.nip.io"),
+		Entry("an address starting with the address", "10.0.0.12", "10.0.0.12"),
+		Entry("a URL of an address starting with the address", "https://10.0.0.12:6443", "https://10.0.0.12:6443"),
+		Entry("a value embedding the address", "password-10.0.0.1", "password-10.0.0.1"),
+	)
+})
+
+// describeEngine runs the engine specs against one cluster type.
+func describeEngine[T Cluster](typeName string, c engineCase[T]) {
+	Context(typeName, func() {
+		var (
+			obj   T
+			prv   *testProvisioner
+			hooks *hookCalls
+			name  string
+		)
+
+		newEngine := func() *Engine[T] {
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: "default"}, obj)).To(Succeed())
+			// The typed client drops the type meta, which owner references of the access Secret need.
+			obj.GetObjectKind().SetGroupVersionKind(c.newCluster(name).GetObjectKind().GroupVersionKind())
+			e, err := New(ctx, k8sClient, obj, prv, c.definition(hooks), logr.Discard())
+			Expect(err).NotTo(HaveOccurred())
+			e.Limiter = concurrency.NewLimiter(0, 0)
+			return e
+		}
+
+		reconcile := func() {
+			e := newEngine()
+			_, err := e.EnsureFinalizerIsAdded()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = e.EnsureClusterIsProvisioned()
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			prv = &testProvisioner{}
+			hooks = &hookCalls{}
+			obj = c.newCluster("")
+			obj.SetGenerateName("engine-")
+			Expect(k8sClient.Create(ctx, obj)).To(Succeed())
+			name = obj.GetName()
+		})
+
+		AfterEach(func() {
+			current := c.newCluster(name)
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(current), current); apierrors.IsNotFound(err) {
+				return
+			}
+			current.SetFinalizers(nil)
+			Expect(client.IgnoreNotFound(k8sClient.Update(ctx, current))).To(Succeed())
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, current))).To(Succeed())
+		})
+
+		It("provisions the cluster and stores its access secret", func() {
+			reconcile()
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			status := obj.GetClusterStatus()
+			Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseRunning))
+			Expect(status.ClusterReady).To(BeTrue())
+			Expect(status.ProvisionId).NotTo(BeNil())
+			Expect(status.ObservedGeneration).To(Equal(obj.GetGeneration()))
+			Expect(status.Cost).NotTo(BeNil())
+			Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionReady)).To(BeTrue())
+			Expect(obj.GetFinalizers()).To(HaveLen(1))
+			Expect(prv.provisioned).To(Equal(1))
+			Expect(hooks.provisioned).To(Equal(1))
+
+			Expect(status.KubeconfigSecretName).NotTo(BeNil())
+			secret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: *status.KubeconfigSecretName, Namespace: "default"}, secret)).To(Succeed())
+			Expect(secret.Data).To(HaveKeyWithValue("kubeconfig", []byte(testKubeconfig(name, "10.0.0.1"))))
+			Expect(secret.OwnerReferences).To(HaveLen(1))
+			Expect(secret.OwnerReferences[0].UID).To(Equal(obj.GetUID()))
+		})
+
+		It("does not provision a running cluster again", func() {
+			reconcile()
+			reconcile()
+			Expect(prv.provisioned).To(Equal(1))
+		})
+
+		It("marks the cluster as failed and stalled when provisioning fails", func() {
+			prv.provisionErr = errors.New("no capacity")
+
+			e := newEngine()
+			_, err := e.EnsureClusterIsProvisioned()
+			Expect(err).To(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			status := obj.GetClusterStatus()
+			Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseFailed))
+			Expect(status.Message).To(ContainSubstring("no capacity"))
+			Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionStalled)).To(BeTrue())
+			Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionReady)).
+				To(HaveField("Reason", "ProvisioningFailed"))
+			Expect(hooks.provisioned).To(BeZero())
+		})
+
+		It("records the fallback strategies tried when provisioning fails", func() {
+			now := metav1.Now()
+			prv.provisionErr = &clusters.AttemptsError{
+				Attempts: []maptv1alpha1.ProvisioningAttempt{
+					{Strategy: maptv1alpha1.FallbackStrategy{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopePreferred},
+						Reason: clusters.ReasonPriceAboveMaximum, Message: "too expensive", StartTime: now},
+					{Strategy: maptv1alpha1.FallbackStrategy{Market: maptv1alpha1.InstanceMarketOnDemand, Placement: maptv1alpha1.PlacementScopePreferred},
+						Reason: clusters.ReasonInsufficientCapacity, Message: "InsufficientInstanceCapacity", StartTime: now},
+				},
+				Err: errors.New("InsufficientInstanceCapacity"),
+			}
+
+			e := newEngine()
+			_, err := e.EnsureClusterIsProvisioned()
+			Expect(err).To(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			status := obj.GetClusterStatus()
+			Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseFailed))
+			Expect(status.ProvisioningAttempts).To(HaveExactElements(
+				HaveField("Reason", clusters.ReasonPriceAboveMaximum),
+				HaveField("Reason", clusters.ReasonInsufficientCapacity),
+			))
+		})
+
+		It("queues the cluster while the concurrency limits are reached", func() {
+			e := newEngine()
+			e.Limiter = concurrency.NewLimiter(1, 0)
+			granted, _ := e.Limiter.Acquire(concurrency.Request{ID: "other-cluster"})
+			Expect(granted).To(BeTrue())
+
+			result, err := e.EnsureClusterIsProvisioned()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueDelay).To(Equal(SlotRequeueInterval))
+			Expect(prv.provisioned).To(BeZero())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			status := obj.GetClusterStatus()
+			Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseQueued))
+			Expect(status.QueuePosition).To(HaveValue(BeEquivalentTo(1)))
+		})
+
+		It("counts the cluster against the operator cloud credentials", func() {
+			e := newEngine()
+			e.Limiter = concurrency.NewLimiter(0, 1)
+			granted, _ := e.Limiter.Acquire(concurrency.Request{ID: "other-cluster", Account: clusters.CloudCredentialsSecretKey().String()})
+			Expect(granted).To(BeTrue())
+
+			result, err := e.EnsureClusterIsProvisioned()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueDelay).To(Equal(SlotRequeueInterval))
+			Expect(prv.provisioned).To(BeZero())
+		})
+
+		It("deprovisions the cluster and removes the finalizer on deletion", func() {
+			reconcile()
+			Expect(k8sClient.Delete(ctx, obj)).To(Succeed())
+
+			e := newEngine()
+			_, err := e.EnsureFinalizersAreCalled()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hooks.beforeDeprovision).To(Equal(1))
+			Expect(prv.deprovisioned).To(Equal(1))
+
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("keeps the finalizer when deprovisioning fails", func() {
+			reconcile()
+			prv.deprovisionErr = errors.New("cloud unavailable")
+			Expect(k8sClient.Delete(ctx, obj)).To(Succeed())
+
+			e := newEngine()
+			_, err := e.EnsureFinalizersAreCalled()
+			Expect(err).To(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			Expect(obj.GetFinalizers()).To(HaveLen(1))
+			status := obj.GetClusterStatus()
+			Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseFailed))
+			Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionInfrastructureProvisioned)).
+				To(HaveField("Reason", "DeprovisioningFailed"))
+		})
+
+		It("does not destroy the infrastructure when the BeforeDeprovision hook fails", func() {
+			reconcile()
+			hooks.beforeErr = errors.New("update cluster still running")
+			Expect(k8sClient.Delete(ctx, obj)).To(Succeed())
+
+			e := newEngine()
+			_, err := e.EnsureFinalizersAreCalled()
+			Expect(err).To(MatchError("update cluster still running"))
+			Expect(prv.deprovisioned).To(BeZero())
+		})
+
+		Describe("hibernation", func() {
+			setHibernate := func(hibernate bool) {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				c.setHibernate(obj, hibernate)
+				Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+			}
+
+			It("stops a running cluster and pauses its cost", func() {
+				reconcile()
+				setHibernate(true)
+
+				e := newEngine()
+				result, err := e.EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeTrue())
+				Expect(prv.hibernated).To(Equal(1))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseHibernated))
+				Expect(status.ClusterReady).To(BeFalse())
+				Expect(status.HibernatedAt).NotTo(BeNil())
+				Expect(status.AWSInstanceID).To(HaveValue(Equal("i-0123456789")))
+				Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionHibernated)).To(BeTrue())
+				Expect(meta.IsStatusConditionFalse(status.Conditions, maptv1alpha1.ConditionReady)).To(BeTrue())
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionReconciling)).To(BeNil())
+
+				_, err = newEngine().EnsureClusterCostIsUpdated()
+				Expect(err).NotTo(HaveOccurred())
+				_, err = newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(prv.hibernated).To(Equal(1))
+			})
+
+			It("resumes a hibernated cluster and updates its access secret when its address changed", func() {
+				reconcile()
+				setHibernate(true)
+				_, err := newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+
+				prv.resumeHost = "10.0.0.2"
+				setHibernate(false)
+				result, err := newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeFalse())
+				Expect(prv.resumed).To(Equal(1))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseRunning))
+				Expect(status.ClusterReady).To(BeTrue())
+				Expect(status.Host).To(Equal("10.0.0.2"))
+				Expect(status.HibernatedAt).To(BeNil())
+				Expect(status.HibernatedDuration).NotTo(BeNil())
+				Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionReady)).To(BeTrue())
+
+				secret := &corev1.Secret{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Name: *status.KubeconfigSecretName, Namespace: "default"}, secret)).To(Succeed())
+				kubeconfig, err := clientcmd.Load(secret.Data["kubeconfig"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(kubeconfig.Clusters).To(HaveKeyWithValue(name, And(
+					HaveField("Server", "https://10.0.0.2:6443"),
+					// The certificate of the API server was issued for its first address.
+					HaveField("TLSServerName", "10.0.0.1"),
+				)))
+			})
+
+			It("keeps a spot cluster running", func() {
+				reconcile()
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				c.setSchedule(obj, nil, true)
+				c.setHibernate(obj, true)
+				Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+
+				_, err := newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(prv.hibernated).To(BeZero())
+				Expect(prv.deprovisioned).To(BeZero())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseRunning))
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionHibernated)).
+					To(HaveField("Reason", "SpotInstance"))
+			})
+
+			It("keeps the cluster running when the provisioner cannot hibernate it", func() {
+				reconcile()
+				setHibernate(true)
+
+				e := newEngine()
+				e.Provisioner = nonHibernatingProvisioner{prv}
+				_, err := e.EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseRunning))
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionHibernated)).
+					To(HaveField("Reason", "HibernationNotSupported"))
+			})
+		})
+
+		Describe("schedule", func() {
+			// The closed window next opens within a minute but only closes once a year; the open one
+			// the reverse.
+			closed := &maptv1alpha1.Schedule{Start: "* * * * *", Stop: "0 0 1 1 *"}
+			open := &maptv1alpha1.Schedule{Start: "0 0 1 1 *", Stop: "* * * * *"}
+
+			setSchedule := func(schedule *maptv1alpha1.Schedule, spot bool) {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				c.setSchedule(obj, schedule, spot)
+				Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+			}
+
+			It("stops an on-demand cluster outside its schedule and records the next transition", func() {
+				reconcile()
+				setSchedule(closed, false)
+
+				result, err := newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeTrue())
+				Expect(prv.hibernated).To(Equal(1))
+				Expect(prv.deprovisioned).To(BeZero())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseHibernated))
+				Expect(status.NextTransition).NotTo(BeNil())
+				Expect(status.NextTransition.Time).To(BeTemporally("~", time.Now(), time.Minute))
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionHibernated)).
+					To(HaveField("Reason", "OutsideSchedule"))
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionReconciling)).To(BeNil())
+			})
+
+			It("destroys a spot cluster outside its schedule and provisions it again when the window opens", func() {
+				reconcile()
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				secretName := *obj.GetClusterStatus().KubeconfigSecretName
+				provisionStart := obj.GetClusterStatus().ProvisionStartTime.Time
+				setSchedule(closed, true)
+
+				result, err := newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeTrue())
+				Expect(prv.hibernated).To(BeZero())
+				Expect(prv.deprovisioned).To(Equal(1))
+				Expect(hooks.beforeDeprovision).To(Equal(1))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseHibernated))
+				Expect(status.ProvisionId).To(BeNil())
+				Expect(status.ClusterReady).To(BeFalse())
+				Expect(status.InfrastructureStartTime).To(BeNil())
+				Expect(status.PreviousUsage).NotTo(BeNil())
+
+				setSchedule(open, true)
+				result, err = newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeFalse())
+				reconcile()
+				Expect(prv.provisioned).To(Equal(2))
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status = obj.GetClusterStatus()
+				Expect(status.Phase).To(Equal(maptv1alpha1.ClusterPhaseRunning))
+				Expect(status.KubeconfigSecretName).To(HaveValue(Equal(secretName)))
+				Expect(status.HibernatedAt).To(BeNil())
+				// The lifetime and the cost of the cluster carry over to its new infrastructure.
+				Expect(status.ProvisionStartTime.Time).To(BeTemporally("==", provisionStart))
+				Expect(status.InfrastructureStartTime).NotTo(BeNil())
+				Expect(status.PreviousUsage).NotTo(BeNil())
+				Expect(status.Cost).NotTo(BeNil())
+				Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionReady)).To(BeTrue())
+			})
+
+			It("defers the provisioning of a new cluster until its schedule brings it up", func() {
+				setSchedule(closed, true)
+
+				result, err := newEngine().EnsureHibernationIsApplied()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.CancelRequest).To(BeTrue())
+				Expect(prv.provisioned).To(BeZero())
+
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				Expect(obj.GetClusterStatus().Phase).To(Equal(maptv1alpha1.ClusterPhaseHibernated))
+			})
+		})
+
+		Describe("GPU stack", func() {
+			setGPUStack := func(stack *maptv1alpha1.GPUStack) {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				c.setGPUStack(obj, stack)
+				Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+			}
+
+			It("holds the Ready condition until the GPU stack is installed and clears it once removed", func() {
+				setGPUStack(&maptv1alpha1.GPUStack{})
+				reconcile()
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				status := obj.GetClusterStatus()
+				Expect(status.GPUStack).To(HaveField("Phase", maptv1alpha1.AddonPhasePending))
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionGPUReady)).To(HaveField("Reason", "GPUStackPending"))
+				Expect(meta.IsStatusConditionFalse(status.Conditions, maptv1alpha1.ConditionReady)).To(BeTrue())
+
+				e := newEngine()
+				e.RemoteClient = func([]byte) (client.Client, error) { return nil, errors.New("cluster unreachable") }
+				result, err := e.EnsureGPUStackIsInstalled()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueDelay).To(Equal(GPUStackRequeueInterval))
+				status = obj.GetClusterStatus()
+				Expect(status.GPUStack).To(HaveField("Phase", maptv1alpha1.AddonPhaseFailed))
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionGPUReady)).To(HaveField("Message", ContainSubstring("cluster unreachable")))
+				Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionReconciling)).To(BeTrue())
+
+				setGPUStack(nil)
+				result, err = newEngine().EnsureGPUStackIsInstalled()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueDelay).To(BeZero())
+				status = obj.GetClusterStatus()
+				Expect(status.GPUStack).To(BeNil())
+				Expect(meta.FindStatusCondition(status.Conditions, maptv1alpha1.ConditionGPUReady)).To(BeNil())
+				Expect(meta.IsStatusConditionTrue(status.Conditions, maptv1alpha1.ConditionReady)).To(BeTrue())
+			})
+		})
+
+		Describe("expiration", func() {
+			setPolicy := func(policy maptv1alpha1.TerminationPolicy) {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				c.setPolicy(obj, policy)
+				Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+			}
+
+			expectDeleted := func(deleted bool) {
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				Expect(obj.GetDeletionTimestamp() != nil).To(Equal(deleted))
+			}
+
+			It("records the expiration and extends it with the extend-ttl annotation", func() {
+				reconcile()
+				setPolicy(maptv1alpha1.TerminationPolicy{DeleteAfterSeconds: ptr.To[int64](3600)})
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				obj.SetAnnotations(map[string]string{metadata.ExtendTTLAnnotation: "2h"})
+				Expect(k8sClient.Update(ctx, obj)).To(Succeed())
+
+				_, err := newEngine().EnsureExpirationIsEnforced()
+				Expect(err).NotTo(HaveOccurred())
+
+				expectDeleted(false)
+				Expect(obj.GetAnnotations()).NotTo(HaveKey(metadata.ExtendTTLAnnotation))
+				status := obj.GetClusterStatus()
+				Expect(status.TTLExtensions).To(HaveLen(1))
+				Expect(status.TTLExtensions[0].Duration.Duration).To(Equal(2 * time.Hour))
+				Expect(status.ExpirationTimestamp.Time).To(BeTemporally("~", status.ProvisionStartTime.Add(3*time.Hour), time.Second))
+			})
+
+			It("deletes a cluster once it expires", func() {
+				reconcile()
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+				started := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+				obj.GetClusterStatus().ProvisionStartTime = &started
+				Expect(k8sClient.Status().Update(ctx, obj)).To(Succeed())
+				setPolicy(maptv1alpha1.TerminationPolicy{DeleteAfterSeconds: ptr.To[int64](3600), ExtendBy: &metav1.Duration{Duration: 30 * time.Minute}})
+
+				result, err := newEngine().EnsureExpirationIsEnforced()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueRequest).To(BeTrue())
+				expectDeleted(true)
+			})
+
+			It("caps the lifetime with the maxLifetime of the namespace quotas", func() {
+				reconcile()
+				q := &maptv1alpha1.MaptQuota{
+					ObjectMeta: metav1.ObjectMeta{GenerateName: "lifetime-", Namespace: "default"},
+					Spec:       maptv1alpha1.MaptQuotaSpec{MaxLifetime: &metav1.Duration{Duration: time.Nanosecond}},
+				}
+				Expect(k8sClient.Create(ctx, q)).To(Succeed())
+				DeferCleanup(func() { Expect(k8sClient.Delete(ctx, q)).To(Succeed()) })
+
+				_, err := newEngine().EnsureExpirationIsEnforced()
+				Expect(err).NotTo(HaveOccurred())
+				expectDeleted(true)
+			})
+
+			It("expires the cluster once its activity lease is no longer renewed", func() {
+				reconcile()
+				setPolicy(maptv1alpha1.TerminationPolicy{InactivityTimeout: &metav1.Duration{Duration: time.Hour}})
+
+				_, err := newEngine().EnsureExpirationIsEnforced()
+				Expect(err).NotTo(HaveOccurred())
+				expectDeleted(false)
+				leaseName := obj.GetClusterStatus().ActivityLeaseName
+				Expect(leaseName).To(Equal(name + "-activity"))
+
+				lease := &coordinationv1.Lease{}
+				Expect(k8sClient.Get(ctx, client.ObjectKey{Name: leaseName, Namespace: "default"}, lease)).To(Succeed())
+				Expect(lease.Spec.LeaseDurationSeconds).To(HaveValue(BeEquivalentTo(3600)))
+				Expect(lease.OwnerReferences).To(HaveLen(1))
+				stale := metav1.NewMicroTime(time.Now().Add(-2 * time.Hour))
+				lease.Spec.RenewTime = &stale
+				Expect(k8sClient.Update(ctx, lease)).To(Succeed())
+
+				_, err = newEngine().EnsureExpirationIsEnforced()
+				Expect(err).NotTo(HaveOccurred())
+				expectDeleted(true)
+			})
+		})
+	})
+}
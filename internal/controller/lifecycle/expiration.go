@@ -0,0 +1,185 @@
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/metadata"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	"github.com/mapt-oss/mapt-operator/pkg/quota"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// activityLeaseSuffix is appended to the name of a cluster to name its activity Lease.
+const activityLeaseSuffix = "-activity"
+
+// EnsureExpirationIsEnforced applies the TTL extensions requested with the extend-ttl annotation,
+// maintains the activity Lease of the cluster, records its expiration and deletes the cluster once
+// it has expired. Clusters that have not started provisioning never expire.
+func (e *Engine[T]) EnsureExpirationIsEnforced() (controller.OperationResult, error) {
+	if e.Object.GetDeletionTimestamp() != nil || e.Object.GetClusterStatus().ProvisionStartTime == nil {
+		return controller.ContinueProcessing()
+	}
+
+	if err := e.applyTTLExtension(); err != nil {
+		e.Log.Error(err, "Failed to apply the requested TTL extension.")
+		return controller.RequeueWithError(err)
+	}
+	lastActivity, err := e.ensureActivityLease()
+	if err != nil {
+		e.Log.Error(err, "Failed to maintain the activity lease.")
+		return controller.RequeueWithError(err)
+	}
+	maxLifetime, err := quota.MaxLifetime(e.Ctx, e.Client, e.Object.GetNamespace())
+	if err != nil {
+		e.Log.Error(err, "Failed to evaluate the maximum lifetime of the namespace.")
+		return controller.RequeueWithError(err)
+	}
+
+	status := e.Object.GetClusterStatus()
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	lifetime := controllerutils.Lifetime{
+		Policy:       policy,
+		LastActivity: lastActivity,
+		Max:          maxLifetime,
+	}
+	for _, ext := range status.TTLExtensions {
+		lifetime.Extensions += ext.Duration.Duration
+	}
+	expiration := lifetime.Expiration(status.ProvisionStartTime.Time)
+
+	if !sameTime(expiration, status.ExpirationTimestamp) {
+		if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			builder := NewStatusBuilder(e.Object)
+			builder.Status.ExpirationTimestamp = expiration
+			*s = *builder.Expiring(policy).Status
+		}); err != nil {
+			e.Log.Error(err, "Failed to record the expiration of the cluster.")
+			return controller.RequeueWithError(err)
+		}
+	}
+
+	if expiration == nil || time.Now().Before(expiration.Time) {
+		return controller.ContinueProcessing()
+	}
+
+	e.Log.Info("Cluster expired; deleting it.", "expiration", expiration.Time)
+	if err := e.Client.Delete(e.Ctx, e.Object); client.IgnoreNotFound(err) != nil {
+		e.Log.Error(err, "Failed to delete expired cluster.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.Requeue()
+}
+
+// applyTTLExtension removes the extend-ttl annotation of the cluster and records the extension it
+// requests in the status. The annotation is removed first, so that an extension is never applied
+// twice; it is lost if recording it fails, and has to be requested again.
+func (e *Engine[T]) applyTTLExtension() error {
+	value, ok := e.Object.GetAnnotations()[metadata.ExtendTTLAnnotation]
+	if !ok {
+		return nil
+	}
+
+	patch := client.MergeFromWithOptions(e.Object.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+	annotations := e.Object.GetAnnotations()
+	delete(annotations, metadata.ExtendTTLAnnotation)
+	e.Object.SetAnnotations(annotations)
+	if err := e.Client.Patch(e.Ctx, e.Object, patch); err != nil {
+		return fmt.Errorf("failed to remove the %s annotation: %w", metadata.ExtendTTLAnnotation, err)
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		// The webhooks reject such values; they can only come from clusters admitted without them.
+		e.Log.Info("Ignoring invalid TTL extension.", "value", value)
+		return nil
+	}
+	e.Log.Info("Extending the expiration of the cluster.", "extendBy", d)
+	return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		s.TTLExtensions = append(s.TTLExtensions, v1alpha1.TTLExtension{
+			Duration:  metav1.Duration{Duration: d},
+			AppliedAt: metav1.Now(),
+		})
+	})
+}
+
+// ensureActivityLease maintains the activity Lease of a cluster with an inactivity timeout and
+// returns when it was last renewed. The Lease is created once the cluster runs, and deleted when
+// the timeout is unset. It returns nil when the cluster has no activity Lease.
+func (e *Engine[T]) ensureActivityLease() (*time.Time, error) {
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	status := e.Object.GetClusterStatus()
+	key := client.ObjectKey{Name: e.Object.GetName() + activityLeaseSuffix, Namespace: e.Object.GetNamespace()}
+
+	if policy == nil || policy.InactivityTimeout == nil {
+		if status.ActivityLeaseName == "" {
+			return nil, nil
+		}
+		lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: status.ActivityLeaseName, Namespace: key.Namespace}}
+		if err := e.Client.Delete(e.Ctx, lease); client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("failed to delete activity lease: %w", err)
+		}
+		return nil, e.UpdateStatus(func(s *v1alpha1.ClusterStatus) { s.ActivityLeaseName = "" })
+	}
+
+	seconds := int32(policy.InactivityTimeout.Duration / time.Second)
+	lease := &coordinationv1.Lease{}
+	err := e.Client.Get(e.Ctx, key, lease)
+	switch {
+	case apierrors.IsNotFound(err):
+		if status.Phase != v1alpha1.ClusterPhaseRunning {
+			return nil, nil
+		}
+		now := metav1.NewMicroTime(time.Now())
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			Spec: coordinationv1.LeaseSpec{
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		if err := controllerutil.SetControllerReference(e.Object, lease, e.Client.Scheme()); err != nil {
+			return nil, fmt.Errorf("failed to set owner of activity lease: %w", err)
+		}
+		if err := e.Client.Create(e.Ctx, lease); err != nil {
+			return nil, fmt.Errorf("failed to create activity lease: %w", err)
+		}
+		e.Log.Info("Created activity lease.", "lease", key.Name)
+	case err != nil:
+		return nil, fmt.Errorf("failed to get activity lease: %w", err)
+	case lease.Spec.LeaseDurationSeconds == nil || *lease.Spec.LeaseDurationSeconds != seconds:
+		lease.Spec.LeaseDurationSeconds = &seconds
+		if err := e.Client.Update(e.Ctx, lease); err != nil {
+			return nil, fmt.Errorf("failed to update activity lease: %w", err)
+		}
+	}
+
+	if status.ActivityLeaseName != key.Name {
+		if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) { s.ActivityLeaseName = key.Name }); err != nil {
+			return nil, err
+		}
+	}
+
+	renewed := lease.CreationTimestamp.Time
+	if lease.Spec.RenewTime != nil {
+		renewed = lease.Spec.RenewTime.Time
+	} else if lease.Spec.AcquireTime != nil {
+		renewed = lease.Spec.AcquireTime.Time
+	}
+	return &renewed, nil
+}
+
+// sameTime reports whether both times are unset or equal.
+func sameTime(a, b *metav1.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
@@ -0,0 +1,195 @@
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/addons"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	"github.com/mapt-oss/mapt-operator/pkg/gpustack"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GPUStackRequeueInterval is how often the GPU stack of a cluster is checked until it is ready.
+const GPUStackRequeueInterval = 30 * time.Second
+
+// EnsureGPUStackIsInstalled installs the GPU stack of a running cluster, stage by stage, and
+// reports it ready once a node of the cluster reports allocatable nvidia.com/gpu. Progress is
+// reported in status.gpuStack and the GPUReady condition. Once ready, the stack is only applied
+// again when the spec changes; removing the GPU stack from the spec leaves it on the cluster.
+func (e *Engine[T]) EnsureGPUStackIsInstalled() (controller.OperationResult, error) {
+	status := e.Object.GetClusterStatus()
+	if e.Object.GetDeletionTimestamp() != nil || status.Phase != v1alpha1.ClusterPhaseRunning {
+		return controller.ContinueProcessing()
+	}
+
+	stack := e.def.Settings(e.Object).GPUStack
+	if stack == nil {
+		if status.GPUStack == nil && meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionGPUReady) == nil {
+			return controller.ContinueProcessing()
+		}
+		return e.recordGPUStack(nil)
+	}
+	if !e.gpuStackPending() {
+		return controller.ContinueProcessing()
+	}
+
+	platform := e.gpuPlatform()
+	updated := &v1alpha1.GPUStackStatus{
+		Phase:              v1alpha1.AddonPhasePending,
+		Version:            gpustack.Version(platform, *stack),
+		ObservedGeneration: e.Object.GetGeneration(),
+	}
+	if status.GPUStack != nil {
+		updated.LastAppliedTime = status.GPUStack.LastAppliedTime
+	}
+	e.installGPUStack(platform, *stack, updated)
+	return e.recordGPUStack(updated)
+}
+
+// ResetGPUStack marks the GPU stack of a cluster whose infrastructure was replaced as Pending, so
+// that it is installed on the new cluster.
+func (e *Engine[T]) ResetGPUStack(s *v1alpha1.ClusterStatus) {
+	stack := e.def.Settings(e.Object).GPUStack
+	if stack == nil {
+		s.GPUStack = nil
+		meta.RemoveStatusCondition(&s.Conditions, v1alpha1.ConditionGPUReady)
+		return
+	}
+	s.GPUStack = &v1alpha1.GPUStackStatus{
+		Phase:   v1alpha1.AddonPhasePending,
+		Version: gpustack.Version(e.gpuPlatform(), *stack),
+	}
+	status, reason, message := gpuStackCondition(s.GPUStack)
+	controllerutils.SetCondition(&s.Conditions, e.Object.GetGeneration(), v1alpha1.ConditionGPUReady, status, reason, message)
+}
+
+// installGPUStack applies the stages of the GPU stack in order, stopping at the first one that is
+// not ready, and counts the GPUs of the cluster once they all are. Progress is reported in s.
+func (e *Engine[T]) installGPUStack(platform gpustack.Platform, stack v1alpha1.GPUStack, s *v1alpha1.GPUStackStatus) {
+	stages, err := gpustack.Stages(platform, stack)
+	if err != nil {
+		s.Phase = v1alpha1.AddonPhaseFailed
+		s.Message = err.Error()
+		return
+	}
+	remote, err := e.RemoteCluster()
+	if err != nil {
+		e.Log.Error(err, "Failed to connect to the cluster to install its GPU stack.")
+		s.Phase = v1alpha1.AddonPhaseFailed
+		s.Message = err.Error()
+		return
+	}
+
+	for _, stage := range stages {
+		s.Stage = stage.Name
+		if err := addons.Apply(e.Ctx, remote, stage.Objects); err != nil {
+			if gpustack.IsNotServed(err) {
+				// The operator defining the resources of the stage is still being installed.
+				s.Phase = v1alpha1.AddonPhaseInstalling
+				s.Message = err.Error()
+				return
+			}
+			e.Log.Error(err, "Failed to install the GPU stack.", "stage", stage.Name)
+			s.Phase = v1alpha1.AddonPhaseFailed
+			s.Message = err.Error()
+			return
+		}
+		now := metav1.Now()
+		s.LastAppliedTime = &now
+
+		ready, message, err := gpustack.Ready(e.Ctx, remote, stage)
+		if err != nil {
+			message = err.Error()
+		}
+		if err != nil || !ready {
+			s.Phase = v1alpha1.AddonPhaseInstalling
+			s.Message = message
+			return
+		}
+	}
+
+	s.Stage = ""
+	gpus, err := gpustack.AllocatableGPUs(e.Ctx, remote)
+	if err != nil {
+		s.Phase = v1alpha1.AddonPhaseInstalling
+		s.Message = err.Error()
+		return
+	}
+	s.AllocatableGPUs = gpus
+	if gpus == 0 {
+		s.Phase = v1alpha1.AddonPhaseInstalling
+		s.Message = fmt.Sprintf("No node reports allocatable %s yet.", gpustack.ResourceName)
+		return
+	}
+	e.Log.Info("GPU stack is ready.", "allocatableGPUs", gpus)
+	s.Phase = v1alpha1.AddonPhaseReady
+	s.Message = ""
+}
+
+// recordGPUStack stores the status of the GPU stack and the GPUReady condition derived from it,
+// and requeues the cluster until the stack is ready. A nil status clears both.
+func (e *Engine[T]) recordGPUStack(s *v1alpha1.GPUStackStatus) (controller.OperationResult, error) {
+	current := e.Object.GetClusterStatus()
+	conditions := append([]metav1.Condition{}, current.Conditions...)
+	if s == nil {
+		meta.RemoveStatusCondition(&conditions, v1alpha1.ConditionGPUReady)
+	} else {
+		status, reason, message := gpuStackCondition(s)
+		controllerutils.SetCondition(&conditions, e.Object.GetGeneration(), v1alpha1.ConditionGPUReady, status, reason, message)
+	}
+
+	if !equality.Semantic.DeepEqual(s, current.GPUStack) || !equality.Semantic.DeepEqual(conditions, current.Conditions) {
+		if err := e.UpdateStatus(func(status *v1alpha1.ClusterStatus) {
+			status.Conditions = conditions
+			status.GPUStack = s
+		}); err != nil {
+			e.Log.Error(err, "Failed to record the GPU stack of the cluster.")
+			return controller.RequeueWithError(err)
+		}
+	}
+
+	if s != nil && s.Phase != v1alpha1.AddonPhaseReady {
+		return controller.RequeueAfter(GPUStackRequeueInterval, nil)
+	}
+	return controller.ContinueProcessing()
+}
+
+// gpuStackPending reports whether the cluster has a GPU stack that is not ready for its current
+// generation.
+func (e *Engine[T]) gpuStackPending() bool {
+	if e.def.Settings(e.Object).GPUStack == nil {
+		return false
+	}
+	s := e.Object.GetClusterStatus().GPUStack
+	return s == nil || s.Phase != v1alpha1.AddonPhaseReady || s.ObservedGeneration != e.Object.GetGeneration()
+}
+
+// gpuPlatform returns the flavour of the GPU stack installed on the cluster type.
+func (e *Engine[T]) gpuPlatform() gpustack.Platform {
+	if e.def.ClusterType == clusters.OpenshiftClusterType {
+		return gpustack.Openshift
+	}
+	return gpustack.Kind
+}
+
+// gpuStackCondition returns the status, reason and message of the GPUReady condition.
+func gpuStackCondition(s *v1alpha1.GPUStackStatus) (metav1.ConditionStatus, string, string) {
+	switch s.Phase {
+	case v1alpha1.AddonPhaseReady:
+		return metav1.ConditionTrue, "GPUReady", fmt.Sprintf("The cluster can allocate %d %s.", s.AllocatableGPUs, gpustack.ResourceName)
+	case v1alpha1.AddonPhaseFailed:
+		return metav1.ConditionFalse, "GPUStackFailed", fmt.Sprintf("The GPU stack could not be installed: %s", s.Message)
+	case v1alpha1.AddonPhaseInstalling:
+		if s.Stage != "" {
+			return metav1.ConditionFalse, "GPUStackInstalling", fmt.Sprintf("Installing %s: %s", s.Stage, s.Message)
+		}
+		return metav1.ConditionFalse, "GPUStackInstalling", s.Message
+	}
+	return metav1.ConditionFalse, "GPUStackPending", "The GPU stack has not been installed yet."
+}
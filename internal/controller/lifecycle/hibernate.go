@@ -0,0 +1,333 @@
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EnsureHibernationIsApplied brings a cluster down when spec.hibernate is set or it is outside its
+// schedule, and up again otherwise. Running clusters are hibernated by stopping their machine, except
+// spot clusters, whose instance cannot be stopped: outside their schedule their infrastructure is
+// destroyed and provisioned again when the window opens, and spec.hibernate leaves them running. The
+// remaining operations are skipped while the cluster is down.
+func (e *Engine[T]) EnsureHibernationIsApplied() (controller.OperationResult, error) {
+	if e.Object.GetDeletionTimestamp() != nil {
+		return controller.ContinueProcessing()
+	}
+
+	outside, err := e.trackSchedule(time.Now())
+	if err != nil {
+		return controller.RequeueWithError(err)
+	}
+	settings := e.def.Settings(e.Object)
+	down := settings.Hibernate || outside
+	status := e.Object.GetClusterStatus()
+	switch status.Phase {
+	case "", v1alpha1.ClusterPhasePending, v1alpha1.ClusterPhaseQueued:
+		if outside && !e.provisioned() {
+			return e.holdUntilSchedule()
+		}
+	case v1alpha1.ClusterPhaseRunning:
+		if !down || (e.def.UpdateInProgress != nil && e.def.UpdateInProgress(e.Object)) {
+			return controller.ContinueProcessing()
+		}
+		if settings.MachineConfig.UseSpotInstances {
+			if settings.Hibernate {
+				// The webhooks reject such clusters; they can only come from clusters admitted without them.
+				return e.markHibernationUnsupported("SpotInstance", "Spot instances cannot be stopped; the cluster keeps running.")
+			}
+			return e.tearDown()
+		}
+		return e.hibernate(!settings.Hibernate)
+	case v1alpha1.ClusterPhaseHibernated:
+		if !down {
+			if !e.provisioned() {
+				return e.bringUp()
+			}
+			return e.resume()
+		}
+		if status.ObservedGeneration != e.Object.GetGeneration() {
+			if err := e.UpdateStatus(func(*v1alpha1.ClusterStatus) {}); err != nil {
+				e.Log.Error(err, "Failed to record the observed generation of a hibernated cluster.")
+				return controller.RequeueWithError(err)
+			}
+		}
+		return controller.StopProcessing()
+	}
+	return controller.ContinueProcessing()
+}
+
+// hibernate stops the machine of the cluster and moves it to the Hibernated phase. scheduled is set
+// when the cluster is brought down by its schedule rather than by spec.hibernate; its infrastructure
+// is then destroyed if the machine cannot be stopped.
+func (e *Engine[T]) hibernate(scheduled bool) (controller.OperationResult, error) {
+	hibernator, ok := e.Provisioner.(clusters.Hibernator)
+	if !ok {
+		if scheduled {
+			return e.tearDown()
+		}
+		return e.markHibernationUnsupported(hibernationNotSupported, hibernationNotSupportedMessage)
+	}
+
+	e.Log.Info("Hibernating cluster.")
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Message("Hibernating the cluster: its instance is being stopped.").
+			Status
+	}); err != nil {
+		e.Log.Error(err, "Failed to mark cluster as hibernating.")
+		return controller.RequeueWithError(err)
+	}
+
+	instanceID, err := hibernator.Hibernate(e.Ctx, &clusters.MaptCluster{
+		Type:   e.def.ClusterType,
+		Object: e.Object,
+	})
+	if errors.Is(err, clusters.ErrHibernationNotSupported) {
+		if scheduled {
+			return e.tearDown()
+		}
+		return e.markHibernationUnsupported(hibernationNotSupported, hibernationNotSupportedMessage)
+	}
+	if err != nil {
+		e.Log.Error(err, "Failed to hibernate cluster.")
+		_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Message(fmt.Sprintf("Failed to hibernate cluster: %s", err.Error())).
+				Condition(v1alpha1.ConditionHibernated, metav1.ConditionFalse, "HibernationFailed", fmt.Sprintf("Could not stop the instance: %s", err.Error())).
+				Status
+		})
+		return controller.RequeueWithError(err)
+	}
+
+	hourlyRate, _ := e.hourlyRate()
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	now := metav1.Now()
+	reason, message, healthMessage := "Hibernated", "Cluster is hibernated: its instance is stopped and its disks are preserved.",
+		"The instance of the cluster is stopped; unset spec.hibernate to resume it."
+	if scheduled {
+		reason, message, healthMessage = "OutsideSchedule", "Cluster is hibernated outside its schedule: its instance is stopped and its disks are preserved.",
+			"The instance of the cluster is stopped until its schedule brings it up."
+	}
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		builder := NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseHibernated).
+			Message(message).
+			Condition(v1alpha1.ConditionHealthy, metav1.ConditionFalse, reason, healthMessage).
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionTrue, reason, "The instance of the cluster is stopped.")
+		builder.Status.HibernatedAt = &now
+		if instanceID != "" {
+			builder.Status.AWSInstanceID = &instanceID
+		}
+		*s = *builder.Cost(hourlyRate, policy).Status
+		s.ClusterReady = false
+	}); err != nil {
+		e.Log.Error(err, "Failed to update status to Hibernated.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.StopProcessing()
+}
+
+// resume starts the machine of a hibernated cluster, updates its access Secret if its address
+// changed and moves it back to the Running phase.
+func (e *Engine[T]) resume() (controller.OperationResult, error) {
+	hibernator, ok := e.Provisioner.(clusters.Hibernator)
+	if !ok {
+		return controller.RequeueWithError(fmt.Errorf("cannot resume cluster: %w by the provisioner", clusters.ErrHibernationNotSupported))
+	}
+
+	e.Log.Info("Resuming cluster from hibernation.")
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Message("Resuming the cluster: its instance is being started.").
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionTrue, "Resuming", "The instance of the cluster is being started.").
+			Status
+	}); err != nil {
+		e.Log.Error(err, "Failed to mark cluster as resuming.")
+		return controller.RequeueWithError(err)
+	}
+
+	host, err := hibernator.Resume(e.Ctx, &clusters.MaptCluster{
+		Type:   e.def.ClusterType,
+		Object: e.Object,
+	})
+	if err != nil {
+		e.Log.Error(err, "Failed to resume cluster.")
+		_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Message(fmt.Sprintf("Failed to resume cluster: %s", err.Error())).
+				Condition(v1alpha1.ConditionHibernated, metav1.ConditionTrue, "ResumeFailed", fmt.Sprintf("Could not start the instance: %s", err.Error())).
+				Status
+		})
+		return controller.RequeueWithError(err)
+	}
+
+	if previous := e.Object.GetClusterStatus().Host; host != "" && previous != "" && host != previous {
+		e.Log.Info("Cluster address changed; updating the access secret.", "previous", previous, "host", host)
+		if err := e.replaceHost(previous, host); err != nil {
+			e.Log.Error(err, "Failed to update the access secret of the resumed cluster.")
+			_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+				*s = *NewStatusBuilder(e.Object).
+					Message(fmt.Sprintf("Error updating the access secret: %s", err.Error())).
+					Condition(v1alpha1.ConditionAccessSecretReady, metav1.ConditionFalse, "SecretUpdateFailed", fmt.Sprintf("Could not update the access secret: %s", err.Error())).
+					Status
+			})
+			return controller.RequeueWithError(err)
+		}
+	}
+
+	hourlyRate, _ := e.hourlyRate()
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		builder := NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseRunning).
+			Message("Cluster resumed from hibernation and ready.").
+			Condition(v1alpha1.ConditionAccessSecretReady, metav1.ConditionTrue, "SecretReady", "The access secret holds the cluster credentials.").
+			Condition(v1alpha1.ConditionHealthy, metav1.ConditionTrue, "ClusterRunning", "The cluster has been resumed and is ready for use.").
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionFalse, "Resumed", "The instance of the cluster is running.").
+			Host(host)
+		builder.Status.HibernatedDuration = &metav1.Duration{Duration: hibernatedFor(builder.Status, time.Now())}
+		builder.Status.HibernatedAt = nil
+		*s = *builder.Cost(hourlyRate, policy).Expiring(policy).Status
+		s.ClusterReady = true
+	}); err != nil {
+		e.Log.Error(err, "Failed to update status to Running after resuming.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.ContinueProcessing()
+}
+
+const (
+	hibernationNotSupported        = "HibernationNotSupported"
+	hibernationNotSupportedMessage = "The provider of this cluster type cannot stop its instance; the cluster keeps running."
+)
+
+// markHibernationUnsupported reports, with reason and message, that the cluster stays running
+// because its instance cannot be stopped.
+func (e *Engine[T]) markHibernationUnsupported(reason, message string) (controller.OperationResult, error) {
+	if c := meta.FindStatusCondition(e.Object.GetClusterStatus().Conditions, v1alpha1.ConditionHibernated); c != nil && c.Reason == reason {
+		return controller.ContinueProcessing()
+	}
+	e.Log.Info("Hibernation requested, but the instance of the cluster cannot be stopped.", "reason", reason)
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionFalse, reason, message).
+			Status
+	}); err != nil {
+		e.Log.Error(err, "Failed to report that hibernation is not supported.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.ContinueProcessing()
+}
+
+// replaceHost points the access Secret of the cluster at its new address. The server of each
+// cluster of the kubeconfig is updated, while its certificate is still verified against the
+// address it was issued for; the other values are updated when they are the previous address or a
+// URL of it.
+func (e *Engine[T]) replaceHost(previous, host string) error {
+	name := e.Object.GetClusterStatus().KubeconfigSecretName
+	if name == nil {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := e.Client.Get(e.Ctx, client.ObjectKey{Name: *name, Namespace: e.Object.GetNamespace()}, secret); err != nil {
+		return fmt.Errorf("failed to get access secret: %w", err)
+	}
+	for k, v := range secret.Data {
+		if k == kubeconfigKey {
+			kubeconfig, err := readdressKubeconfig(v, previous, host)
+			if err != nil {
+				return err
+			}
+			secret.Data[k] = kubeconfig
+			continue
+		}
+		secret.Data[k] = []byte(readdressValue(string(v), previous, host))
+	}
+	if err := e.Client.Update(e.Ctx, secret); err != nil {
+		return fmt.Errorf("failed to update access secret: %w", err)
+	}
+	return nil
+}
+
+// kubeconfigKey is the key of the kubeconfig in the access Secret of every cluster type.
+const kubeconfigKey = "kubeconfig"
+
+// readdressKubeconfig points the clusters of a kubeconfig served at previous to host. Their
+// certificate was issued for the previous address, so it is still verified against it.
+func readdressKubeconfig(data []byte, previous, host string) ([]byte, error) {
+	cfg, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig of access secret: %w", err)
+	}
+	for _, cluster := range cfg.Clusters {
+		server, err := url.Parse(cluster.Server)
+		if err != nil {
+			continue
+		}
+		hostname, ok := readdressHostname(server.Hostname(), previous, host)
+		if !ok {
+			continue
+		}
+		if cluster.TLSServerName == "" {
+			cluster.TLSServerName = server.Hostname()
+		}
+		if port := server.Port(); port != "" {
+			hostname = net.JoinHostPort(hostname, port)
+		}
+		server.Host = hostname
+		cluster.Server = server.String()
+	}
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize kubeconfig of access secret: %w", err)
+	}
+	return out, nil
+}
+
+// readdressValue returns value pointed at host when it is previous or a URL of it, and value
+// unchanged otherwise.
+func readdressValue(value, previous, host string) string {
+	if value == previous {
+		return host
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		return value
+	}
+	hostname, ok := readdressHostname(u.Hostname(), previous, host)
+	if !ok {
+		return value
+	}
+	if port := u.Port(); port != "" {
+		hostname = net.JoinHostPort(hostname, port)
+	}
+	u.Host = hostname
+	return u.String()
+}
+
+// readdressHostname replaces previous with host in hostname, when it is previous or embeds it as
+// whole labels, as the names of wildcard DNS services such as nip.io do. It reports whether
+// hostname referred to previous.
+func readdressHostname(hostname, previous, host string) (string, bool) {
+	if hostname == previous {
+		return host, true
+	}
+	if i := strings.Index("."+hostname+".", "."+previous+"."); i >= 0 {
+		return hostname[:i] + host + hostname[i+len(previous):], true
+	}
+	return hostname, false
+}
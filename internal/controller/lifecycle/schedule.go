@@ -0,0 +1,181 @@
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/konflux-ci/operator-toolkit/controller"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/schedule"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// window returns the availability window defined by the schedule of the cluster, or nil when the
+// cluster has no schedule.
+func (e *Engine[T]) window() (*schedule.Window, error) {
+	s := e.def.Settings(e.Object).Schedule
+	if s == nil {
+		return nil, nil
+	}
+	return schedule.NewWindow(s.Start, s.Stop, s.TimeZone)
+}
+
+// outsideSchedule reports whether the schedule of the cluster keeps it down at now. Invalid
+// schedules, which the webhooks reject, are ignored.
+func (e *Engine[T]) outsideSchedule(now time.Time) bool {
+	window, err := e.window()
+	if window == nil || err != nil {
+		return false
+	}
+	open, _ := window.At(now)
+	return !open
+}
+
+// trackSchedule records in the status when the schedule of the cluster next brings it up or down,
+// and reports whether the cluster is outside its schedule at now.
+func (e *Engine[T]) trackSchedule(now time.Time) (bool, error) {
+	var next *metav1.Time
+	outside := false
+	window, err := e.window()
+	if err != nil {
+		e.Log.Error(err, "Ignoring invalid schedule.")
+	}
+	if window != nil {
+		open, t := window.At(now)
+		outside = !open
+		if !t.IsZero() {
+			next = &metav1.Time{Time: t}
+		}
+	}
+
+	if sameTime(next, e.Object.GetClusterStatus().NextTransition) {
+		return outside, nil
+	}
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		s.NextTransition = next
+	}); err != nil {
+		e.Log.Error(err, "Failed to record the next transition of the schedule.")
+		return outside, err
+	}
+	return outside, nil
+}
+
+// holdUntilSchedule keeps a cluster that has not been provisioned yet down until its schedule
+// brings it up.
+func (e *Engine[T]) holdUntilSchedule() (controller.OperationResult, error) {
+	e.Log.Info("Cluster is outside its schedule; provisioning is deferred.")
+	e.Limiter.Forget(string(e.Object.GetUID()))
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseHibernated).
+			Message("Cluster is outside its schedule: it is provisioned when the window opens.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "OutsideSchedule", "Provisioning waits for the schedule of the cluster to bring it up.").
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionTrue, "OutsideSchedule", "The cluster is down until its schedule brings it up.").
+			QueuePosition(nil).
+			Status
+	}); err != nil {
+		e.Log.Error(err, "Failed to mark cluster as outside its schedule.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.StopProcessing()
+}
+
+// tearDown destroys the infrastructure of a cluster outside its schedule whose instance cannot be
+// stopped, and moves it to the Hibernated phase. The access Secret is kept, and updated in place
+// once the schedule brings the cluster up again.
+func (e *Engine[T]) tearDown() (controller.OperationResult, error) {
+	granted, err := e.AcquireSlot(func(position int32) error {
+		return e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Message(fmt.Sprintf("Waiting for a deprovisioning slot to bring the cluster down outside its schedule (position %d in queue).", position)).
+				QueuePosition(&position).
+				Status
+		})
+	})
+	if err != nil {
+		e.Log.Error(err, "Failed to mark cluster as waiting for a deprovisioning slot.")
+		return controller.RequeueWithError(err)
+	}
+	if !granted {
+		return controller.RequeueAfter(SlotRequeueInterval, nil)
+	}
+	defer e.Limiter.Release(string(e.Object.GetUID()))
+
+	if e.def.BeforeDeprovision != nil {
+		if err := e.def.BeforeDeprovision(); err != nil {
+			e.Log.Error(err, "Failed to prepare the cluster for being brought down.")
+			return controller.RequeueWithError(err)
+		}
+	}
+
+	e.Log.Info("Destroying cluster outside its schedule.")
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Message("Bringing the cluster down outside its schedule: its instance cannot be stopped, so its infrastructure is being destroyed.").
+			QueuePosition(nil).
+			Status
+	}); err != nil {
+		e.Log.Error(err, "Failed to mark cluster as being brought down.")
+		return controller.RequeueWithError(err)
+	}
+
+	if err := e.Provisioner.Deprovision(e.Ctx, &clusters.MaptCluster{
+		Type:   e.def.ClusterType,
+		Object: e.Object,
+	}); err != nil {
+		e.Log.Error(err, "Failed to destroy cluster outside its schedule.")
+		_ = e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+			*s = *NewStatusBuilder(e.Object).
+				Message(fmt.Sprintf("Failed to bring the cluster down outside its schedule: %s", err.Error())).
+				Condition(v1alpha1.ConditionHibernated, metav1.ConditionFalse, "TeardownFailed", fmt.Sprintf("Could not destroy the infrastructure of the cluster: %s", err.Error())).
+				Status
+		})
+		return controller.RequeueWithError(err)
+	}
+
+	hourlyRate, _ := e.hourlyRate()
+	policy := e.def.Settings(e.Object).TerminationPolicy
+	now := metav1.Now()
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		builder := NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhaseHibernated).
+			Message("Cluster is down outside its schedule: its infrastructure is destroyed and provisioned again when the window opens.").
+			Condition(v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "OutsideSchedule", "The infrastructure of the cluster is destroyed outside its schedule.").
+			Condition(v1alpha1.ConditionAccessSecretReady, metav1.ConditionFalse, "OutsideSchedule", "The access secret is updated once the cluster is provisioned again.").
+			Condition(v1alpha1.ConditionHealthy, metav1.ConditionFalse, "OutsideSchedule", "The cluster is down outside its schedule.").
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionTrue, "OutsideSchedule", "The cluster is destroyed until its schedule brings it up.")
+		*s = *builder.RetireInfrastructure(hourlyRate, policy).Status
+		s.HibernatedAt = &now
+		s.ClusterReady = false
+		s.ProvisionId = nil
+		s.AWSInstanceID = nil
+		s.Region = ""
+		s.AvailabilityZone = ""
+		s.InstanceType = ""
+	}); err != nil {
+		e.Log.Error(err, "Failed to update status after bringing the cluster down.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.StopProcessing()
+}
+
+// bringUp sends a cluster whose infrastructure was destroyed outside its schedule back to Pending,
+// so that the following operations provision it again. The cluster keeps its lifetime, measured
+// from its first provisioning, and the cost of its previous infrastructure.
+func (e *Engine[T]) bringUp() (controller.OperationResult, error) {
+	e.Log.Info("Schedule brings the cluster up; provisioning it again.")
+	if err := e.UpdateStatus(func(s *v1alpha1.ClusterStatus) {
+		*s = *NewStatusBuilder(e.Object).
+			Phase(v1alpha1.ClusterPhasePending).
+			Message("The schedule of the cluster brings it up: provisioning it again.").
+			Condition(v1alpha1.ConditionHibernated, metav1.ConditionFalse, "WithinSchedule", "The schedule of the cluster brings it up.").
+			Status
+		s.AveragePrice = ""
+		s.HibernatedAt = nil
+	}); err != nil {
+		e.Log.Error(err, "Failed to mark cluster as pending after its schedule brought it up.")
+		return controller.RequeueWithError(err)
+	}
+	return controller.ContinueProcessing()
+}
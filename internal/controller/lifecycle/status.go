@@ -0,0 +1,213 @@
+package lifecycle
+
+import (
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// StatusBuilder chains changes to a copy of the shared status of a cluster.
+type StatusBuilder struct {
+	// Status is the changed copy.
+	Status     *v1alpha1.ClusterStatus
+	generation int64
+}
+
+// NewStatusBuilder returns a builder working on a copy of the status of obj.
+func NewStatusBuilder(obj Cluster) *StatusBuilder {
+	status := obj.GetClusterStatus()
+	if status.Conditions == nil {
+		status.Conditions = []metav1.Condition{}
+	}
+	// DeepCopy to avoid mutating original
+	return &StatusBuilder{Status: status.DeepCopy(), generation: obj.GetGeneration()}
+}
+
+// Phase sets the lifecycle phase.
+func (s *StatusBuilder) Phase(phase v1alpha1.ClusterPhase) *StatusBuilder {
+	s.Status.Phase = phase
+	return s
+}
+
+// Message sets the human-readable status message.
+func (s *StatusBuilder) Message(msg string) *StatusBuilder {
+	s.Status.Message = msg
+	return s
+}
+
+// Condition sets a condition, recording the generation it was computed for.
+func (s *StatusBuilder) Condition(condType string, status metav1.ConditionStatus, reason, msg string) *StatusBuilder {
+	controllerutils.SetCondition(&s.Status.Conditions, s.generation, condType, status, reason, msg)
+	return s
+}
+
+// BackendID records the provision ID; an empty id is ignored.
+func (s *StatusBuilder) BackendID(id string) *StatusBuilder {
+	if id != "" {
+		s.Status.ProvisionId = &id
+	}
+	return s
+}
+
+// Host records the address the cluster is reachable at; an empty host is ignored.
+func (s *StatusBuilder) Host(host string) *StatusBuilder {
+	if host != "" {
+		s.Status.Host = host
+	}
+	return s
+}
+
+// Placement records the region, availability zone and instance type the cluster runs on; an
+// unknown placement is ignored.
+func (s *StatusBuilder) Placement(p clusters.Placement) *StatusBuilder {
+	if p.InstanceType != "" {
+		s.Status.Region = p.Region
+		s.Status.AvailabilityZone = p.AvailabilityZone
+		s.Status.InstanceType = p.InstanceType
+	}
+	return s
+}
+
+// ProvisioningAttempts records the fallback strategies tried by the last provisioning; an empty
+// list is ignored.
+func (s *StatusBuilder) ProvisioningAttempts(attempts []v1alpha1.ProvisioningAttempt) *StatusBuilder {
+	if len(attempts) > 0 {
+		s.Status.ProvisioningAttempts = attempts
+	}
+	return s
+}
+
+// KubeconfigSecret records the name of the access Secret; an empty name is ignored.
+func (s *StatusBuilder) KubeconfigSecret(name string) *StatusBuilder {
+	if name != "" {
+		s.Status.KubeconfigSecretName = &name
+	}
+	return s
+}
+
+// AvgPrice records the hourly price of the cluster.
+func (s *StatusBuilder) AvgPrice(avgPrice float64) *StatusBuilder {
+	s.Status.AveragePrice = controllerutils.FormatPrice(avgPrice)
+	return s
+}
+
+// Cost recalculates the cluster cost from the hourly rate and the provisioning timestamps of its
+// current infrastructure, adding the usage of the infrastructure it ran on before.
+func (s *StatusBuilder) Cost(hourlyRate controllerutils.HourlyRate, policy *v1alpha1.TerminationPolicy) *StatusBuilder {
+	expiration := controllerutils.ExpirationTime(s.Status.ExpirationTimestamp, s.Status.ProvisionStartTime, policy)
+	now := time.Now()
+	cost := controllerutils.CalculateCost(hourlyRate, infrastructureStartTime(s.Status), expiration, hibernatedFor(s.Status, now), now)
+	s.Status.Cost = controllerutils.AddUsage(cost, s.Status.PreviousUsage)
+	return s
+}
+
+// RetireInfrastructure records the cost of the current infrastructure of the cluster, which is
+// being destroyed, as its previous usage, and clears its provisioning and hibernation timestamps.
+// The lifetime of the cluster is kept: it is still measured from its first provisioning.
+func (s *StatusBuilder) RetireInfrastructure(hourlyRate controllerutils.HourlyRate, policy *v1alpha1.TerminationPolicy) *StatusBuilder {
+	s.Cost(hourlyRate, policy)
+	s.Status.PreviousUsage = controllerutils.CarryOver(s.Status.Cost)
+	s.Status.InfrastructureStartTime = nil
+	s.Status.HibernatedAt = nil
+	s.Status.HibernatedDuration = nil
+	return s
+}
+
+// Expiring reports whether the cluster is about to reach its expiration.
+func (s *StatusBuilder) Expiring(policy *v1alpha1.TerminationPolicy) *StatusBuilder {
+	expiration := controllerutils.ExpirationTime(s.Status.ExpirationTimestamp, s.Status.ProvisionStartTime, policy)
+	status, reason, msg := controllerutils.ExpiringCondition(expiration, time.Now())
+	return s.Condition(v1alpha1.ConditionExpiring, status, reason, msg)
+}
+
+// ProvisionStartTime records when provisioning of the current infrastructure started. The start
+// of the first provisioning, which the lifetime of the cluster is measured from, is never changed
+// once set.
+func (s *StatusBuilder) ProvisionStartTime(t metav1.Time) *StatusBuilder {
+	if s.Status.ProvisionStartTime == nil {
+		s.Status.ProvisionStartTime = &t
+	}
+	s.Status.InfrastructureStartTime = &t
+	return s
+}
+
+// QueuePosition records the position of the cluster in the provisioning queue; nil clears it.
+func (s *StatusBuilder) QueuePosition(position *int32) *StatusBuilder {
+	s.Status.QueuePosition = position
+	return s
+}
+
+// StateBackend records the backend holding the provisioning state; it is never changed once set.
+func (s *StatusBuilder) StateBackend(backend *v1alpha1.StateBackend) *StatusBuilder {
+	if s.Status.StateBackend == nil {
+		s.Status.StateBackend = backend
+	}
+	return s
+}
+
+// UpdateStatus applies update to the in-memory cluster, derives the aggregate conditions and
+// patches the status subresource. Fields specific to the cluster type may be changed by update
+// through the cluster object itself.
+func (e *Engine[T]) UpdateStatus(update func(*v1alpha1.ClusterStatus)) error {
+	// Create a deep copy of the current object to preserve the original for patching
+	original := e.Object.DeepCopyObject().(client.Object)
+
+	// Apply updates to the current in-memory object
+	status := e.Object.GetClusterStatus()
+	update(status)
+	if status.Phase != v1alpha1.ClusterPhaseRunning || e.def.UpdateInProgress == nil {
+		status.ObservedGeneration = e.Object.GetGeneration()
+	}
+	controllerutils.SyncAggregateConditions(&status.Conditions, e.Object.GetGeneration(),
+		e.lifecycleState(), string(status.Phase), status.Message)
+
+	// Patch ONLY the status subresource
+	return e.Client.Status().Patch(e.Ctx, e.Object, client.MergeFrom(original))
+}
+
+// lifecycleState classifies the cluster for the kstatus conditions. A running cluster is still
+// reconciling until its current generation has been handled and its GPU stack is ready.
+func (e *Engine[T]) lifecycleState() controllerutils.LifecycleState {
+	status := e.Object.GetClusterStatus()
+	switch status.Phase {
+	case v1alpha1.ClusterPhaseFailed:
+		return controllerutils.LifecycleStalled
+	case v1alpha1.ClusterPhaseRunning:
+		if e.Object.GetDeletionTimestamp() == nil && status.ObservedGeneration == e.Object.GetGeneration() &&
+			(e.def.UpdateInProgress == nil || !e.def.UpdateInProgress(e.Object)) && !e.gpuStackPending() {
+			return controllerutils.LifecycleCurrent
+		}
+	case v1alpha1.ClusterPhaseHibernated:
+		if e.Object.GetDeletionTimestamp() == nil && status.ObservedGeneration == e.Object.GetGeneration() &&
+			(e.def.Settings(e.Object).Hibernate || e.outsideSchedule(time.Now())) {
+			return controllerutils.LifecycleCurrent
+		}
+	}
+	return controllerutils.LifecycleInProgress
+}
+
+// infrastructureStartTime returns when provisioning of the current infrastructure of the cluster
+// started, or nil when it has none. Clusters provisioned before it was recorded only have a
+// provisionStartTime.
+func infrastructureStartTime(status *v1alpha1.ClusterStatus) *metav1.Time {
+	if status.InfrastructureStartTime != nil || status.PreviousUsage != nil {
+		return status.InfrastructureStartTime
+	}
+	return status.ProvisionStartTime
+}
+
+// hibernatedFor returns the time the cluster spent hibernated up to now.
+func hibernatedFor(status *v1alpha1.ClusterStatus, now time.Time) time.Duration {
+	var d time.Duration
+	if status.HibernatedDuration != nil {
+		d = status.HibernatedDuration.Duration
+	}
+	if status.HibernatedAt != nil {
+		d += now.Sub(status.HibernatedAt.Time)
+	}
+	return d
+}
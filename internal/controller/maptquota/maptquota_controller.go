@@ -0,0 +1,91 @@
+package maptquota
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	"github.com/mapt-oss/mapt-operator/pkg/quota"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// MaptQuotaReconciler keeps the usage reported in MaptQuota status up to date.
+// Enforcement itself happens in the admission webhooks and in the cluster controllers.
+type MaptQuotaReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func (r *MaptQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx).WithValues("controller", "MaptQuotaReconciler", "resource", req.NamespacedName)
+
+	var q v1alpha1.MaptQuota
+	if err := r.Get(ctx, req.NamespacedName, &q); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to fetch MaptQuota resource")
+	}
+
+	used, err := quota.NamespaceUsage(ctx, r.Client, q.Namespace, quota.ActiveClusters, nil)
+	if err != nil {
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to compute namespace usage")
+	}
+	queued, err := quota.QueuedClusters(ctx, r.Client, q.Namespace)
+	if err != nil {
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to count queued clusters")
+	}
+
+	if q.Status.LastUpdateTime != nil && equality.Semantic.DeepEqual(q.Status.Used, used.ToAPI()) && q.Status.QueuedClusters == queued {
+		return ctrl.Result{RequeueAfter: 10 * time.Minute}, nil
+	}
+
+	original := q.DeepCopy()
+	now := metav1.Now()
+	q.Status.Used = used.ToAPI()
+	q.Status.QueuedClusters = queued
+	q.Status.LastUpdateTime = &now
+	if err := r.Status().Patch(ctx, &q, client.MergeFrom(original)); err != nil {
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to update MaptQuota status")
+	}
+	return ctrl.Result{RequeueAfter: 10 * time.Minute}, nil
+}
+
+// quotasInNamespace enqueues every MaptQuota of the namespace of a changed cluster.
+func (r *MaptQuotaReconciler) quotasInNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	var quotas v1alpha1.MaptQuotaList
+	if err := r.List(ctx, &quotas, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(quotas.Items))
+	for _, q := range quotas.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: q.Name, Namespace: q.Namespace},
+		})
+	}
+	return requests
+}
+
+func (r *MaptQuotaReconciler) Register(mgr ctrl.Manager, log *logr.Logger, _ crcluster.Cluster) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.MaptQuota{}).
+		Watches(&v1alpha1.Kind{}, handler.EnqueueRequestsFromMapFunc(r.quotasInNamespace)).
+		Watches(&v1alpha1.Openshift{}, handler.EnqueueRequestsFromMapFunc(r.quotasInNamespace)).
+		Named("maptquota").
+		Complete(r)
+}
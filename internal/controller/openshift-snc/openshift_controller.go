@@ -7,14 +7,18 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/konflux-ci/operator-toolkit/controller"
 	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/controller/lifecycle"
 	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/concurrency"
 	"github.com/mapt-oss/mapt-operator/pkg/controllerutils"
+	coordinationv1 "k8s.io/api/coordination/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	crcluster "sigs.k8s.io/controller-runtime/pkg/cluster"
+	crcontroller "sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -22,6 +26,14 @@ type OpenshiftReconciler struct {
 	client.Client
 	Scheme      *runtime.Scheme
 	Provisioner clusters.GenericMaptProvisioner
+	// Limiter bounds concurrent provisioning operations. The operator-wide limiter is used when nil.
+	Limiter *concurrency.Limiter
+	// MaxConcurrentReconciles is the number of clusters reconciled at once. A single worker is used
+	// when zero, which serializes provisioning regardless of the concurrency limits.
+	MaxConcurrentReconciles int
+	// RemoteClient connects to provisioned clusters to install their GPU stack. Clients are built
+	// from the kubeconfig of the cluster when nil.
+	RemoteClient lifecycle.RemoteClientFunc
 }
 
 func (r *OpenshiftReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -45,20 +57,38 @@ func (r *OpenshiftReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
-	adapter := newAdapter(ctx, r.Client, prov, &openshift, logger)
+	adapter, err := newAdapter(ctx, r.Client, prov, &openshift, logger)
+	if err != nil {
+		return ctrl.Result{}, controllerutils.LogError(logger, err, "Failed to create adapter")
+	}
+	if r.Limiter != nil {
+		adapter.Limiter = r.Limiter
+	}
+	if r.RemoteClient != nil {
+		adapter.RemoteClient = r.RemoteClient
+	}
 
 	result, err := controller.ReconcileHandler([]controller.Operation{
-		adapter.EnsureFinalizerIsAdded,
 		adapter.EnsureFinalizersAreCalled,
-		adapter.EnsureOpenshiftClusterIsProvisioned,
+		adapter.EnsureFinalizerIsAdded,
+		adapter.EnsureExpirationIsEnforced,
+		adapter.EnsureClusterCostIsUpdated,
+		adapter.EnsureHibernationIsApplied,
+		adapter.EnsureGPUStackIsInstalled,
+		adapter.EnsureQuotaIsAvailable,
+		adapter.EnsureClusterIsProvisioned,
 	})
 
 	if err != nil {
 		return result, controllerutils.LogError(logger, err, "Reconciliation failed")
 	}
 
-	logger.Info("Reconciliation successful. Requeueing after 10 hours")
-	result.RequeueAfter = 10 * time.Hour
+	result = controllerutils.RequeueBy(result, openshift.Status.NextTransition, time.Now())
+	result = controllerutils.RequeueBy(result, openshift.Status.ExpirationTimestamp, time.Now())
+	if result.RequeueAfter == 0 {
+		logger.Info("Reconciliation successful. Requeueing after 10 hours")
+		result.RequeueAfter = 10 * time.Hour
+	}
 	return result, nil
 }
 
@@ -69,6 +99,8 @@ func (r *OpenshiftReconciler) Register(mgr ctrl.Manager, log *logr.Logger, _ crc
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.Openshift{}).
 		Owns(&corev1.Secret{}).
+		Owns(&coordinationv1.Lease{}).
 		Named("openshift").
+		WithOptions(crcontroller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
 		Complete(r)
 }
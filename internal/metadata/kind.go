@@ -4,3 +4,10 @@ const (
 	KindFinalizer         = "kind.mapt.redhat.com/finalizer"
 	OpenshiftSncFinalizer = "openshift-snc.mapt.redhat.com/finalizer"
 )
+
+// ExtendTTLAnnotation postpones the expiration of a cluster by the duration it holds (e.g. "2h").
+// The operator applies it once, records it in status.ttlExtensions and removes the annotation.
+const ExtendTTLAnnotation = "mapt.redhat.com/extend-ttl"
+
+// ClusterBootstrapLabel is set on the Jobs of a ClusterBootstrap to the name of the ClusterBootstrap.
+const ClusterBootstrapLabel = "mapt.redhat.com/cluster-bootstrap"
@@ -0,0 +1,156 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/gpustack"
+	"github.com/mapt-oss/mapt-operator/pkg/quota"
+)
+
+// kindlog is for logging in this package.
+var kindlog = logf.Log.WithName("kind-resource")
+
+// SetupKindWebhookWithManager registers the webhook for Kind in the manager.
+func SetupKindWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&maptv1alpha1.Kind{}).
+		WithValidator(&KindCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-mapt-redhat-com-v1alpha1-kind,mutating=false,failurePolicy=fail,sideEffects=None,groups=mapt.redhat.com,resources=kinds,verbs=create;update,versions=v1alpha1,name=vkind-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// KindCustomValidator validates Kind resources when they are created or updated.
+// Creation, and updates requesting a larger machine, are rejected when they would exceed a
+// MaptQuota of the namespace, and both creation and updates changing the spec are rejected when
+// spec.schedule cannot be evaluated, a spot cluster is hibernated or the extend-ttl annotation is
+// invalid. Updates of a cluster being deleted are always admitted. Fallback strategies with an
+// AnyZone placement that has no zones to lift are admitted with a warning.
+type KindCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &KindCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Kind.
+func (v *KindCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	kind, ok := obj.(*maptv1alpha1.Kind)
+	if !ok {
+		return nil, fmt.Errorf("expected a Kind object but got %T", obj)
+	}
+	kindlog.Info("Validation for Kind upon creation", "name", kind.GetName())
+
+	if err := validateKindClusterConfig(kind.Spec.KindClusterConfig); err != nil {
+		return nil, err
+	}
+	if err := validateAddons(kind.Spec.Addons); err != nil {
+		return nil, err
+	}
+	if err := validateMachineConfig(kind.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateCloudConfig(ctx, v.Client, kind.Spec.CloudConfig, kind.Spec.MachineConfig.Fallback); err != nil {
+		return nil, err
+	}
+	if err := validateNetwork(kind.Spec.Network); err != nil {
+		return nil, err
+	}
+	if err := validateGPUStack(gpustack.Kind, kind.Spec.GPUStack, kind.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(kind.Spec.Schedule); err != nil {
+		return nil, err
+	}
+	if err := validateHibernation(kind.Spec.Hibernate, kind.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateTTLExtension(ctx, nil, kind, kind.Spec.TerminationPolicy); err != nil {
+		return nil, err
+	}
+	return fallbackWarnings(kind.Spec.MachineConfig.Fallback, kind.Spec.CloudConfig.AvailabilityZones), quota.Check(ctx, v.Client, kind, kind.Spec.MachineConfig, quota.AllClusters)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Kind.
+func (v *KindCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	kind, ok := newObj.(*maptv1alpha1.Kind)
+	if !ok {
+		return nil, fmt.Errorf("expected a Kind object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*maptv1alpha1.Kind)
+	if !ok {
+		return nil, fmt.Errorf("expected a Kind object for the oldObj but got %T", oldObj)
+	}
+	// Updates of a cluster being deleted, or leaving its spec alone such as the controller removing
+	// its finalizer, are not validated again: a spec that no longer validates, e.g. after the
+	// operator was upgraded, must not keep the cluster from being finalized.
+	if kind.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+	if equality.Semantic.DeepEqual(old.Spec, kind.Spec) {
+		return nil, validateTTLExtension(ctx, oldObj, kind, kind.Spec.TerminationPolicy)
+	}
+	if err := validateKindClusterConfig(kind.Spec.KindClusterConfig); err != nil {
+		return nil, err
+	}
+	if err := validateAddons(kind.Spec.Addons); err != nil {
+		return nil, err
+	}
+	if err := validateMachineConfig(kind.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateCloudConfig(ctx, v.Client, kind.Spec.CloudConfig, kind.Spec.MachineConfig.Fallback); err != nil {
+		return nil, err
+	}
+	if err := validateNetwork(kind.Spec.Network); err != nil {
+		return nil, err
+	}
+	if err := validateGPUStack(gpustack.Kind, kind.Spec.GPUStack, kind.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(kind.Spec.Schedule); err != nil {
+		return nil, err
+	}
+	if err := validateHibernation(kind.Spec.Hibernate, kind.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateTTLExtension(ctx, oldObj, kind, kind.Spec.TerminationPolicy); err != nil {
+		return nil, err
+	}
+	warnings := fallbackWarnings(kind.Spec.MachineConfig.Fallback, kind.Spec.CloudConfig.AvailabilityZones)
+	// A cluster requesting more resources must fit in the quotas as on creation, while shrinking
+	// it is always admitted, even when the namespace is over a quota lowered since.
+	if quota.UsageFor(kind.Spec.MachineConfig).Within(quota.UsageFor(old.Spec.MachineConfig)) {
+		return warnings, nil
+	}
+	return warnings, quota.Check(ctx, v.Client, kind, kind.Spec.MachineConfig, quota.AllClusters)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Kind.
+func (v *KindCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
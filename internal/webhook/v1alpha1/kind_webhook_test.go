@@ -0,0 +1,571 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/metadata"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/quota"
+)
+
+var _ = Describe("Kind Webhook", func() {
+	var (
+		ctx        context.Context
+		testScheme *runtime.Scheme
+		validator  *KindCustomValidator
+		existing   []client.Object
+	)
+
+	newKind := func(name string, cpus int32, gpu *maptv1alpha1.GPURequirements) *maptv1alpha1.Kind {
+		return &maptv1alpha1.Kind{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: maptv1alpha1.KindSpec{
+				MachineConfig: maptv1alpha1.MachineConfig{CPUs: cpus, MemoryGiB: 16, GPU: gpu != nil, GPURequirements: gpu},
+			},
+		}
+	}
+
+	oneGPU := &maptv1alpha1.GPURequirements{Count: 1}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		testScheme = runtime.NewScheme()
+		Expect(corev1.AddToScheme(testScheme)).To(Succeed())
+		Expect(maptv1alpha1.AddToScheme(testScheme)).To(Succeed())
+		existing = []client.Object{
+			&maptv1alpha1.MaptQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "team-quota", Namespace: "default"},
+				Spec: maptv1alpha1.MaptQuotaSpec{
+					MaxClusters:    ptr.To[int32](2),
+					MaxCPUs:        ptr.To[int32](24),
+					MaxGPUClusters: ptr.To[int32](0),
+				},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		validator = &KindCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build(),
+		}
+	})
+
+	Context("When creating a Kind under a MaptQuota", func() {
+		It("admits a cluster within the quota", func() {
+			_, err := validator.ValidateCreate(ctx, newKind("first", 8, nil))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a cluster exceeding the cluster count", func() {
+			existing = append(existing, newKind("a", 4, nil), newKind("b", 4, nil))
+			validator.Client = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build()
+
+			_, err := validator.ValidateCreate(ctx, newKind("third", 4, nil))
+			Expect(err).To(HaveOccurred())
+			Expect(quota.IsExceeded(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("clusters: requested 3, limit 2"))
+		})
+
+		It("rejects a cluster exceeding the vCPU limit", func() {
+			existing = append(existing, newKind("a", 16, nil))
+			validator.Client = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build()
+
+			_, err := validator.ValidateCreate(ctx, newKind("second", 16, nil))
+			Expect(err).To(MatchError(ContainSubstring("cpus: requested 32, limit 24")))
+		})
+
+		It("rejects GPU clusters when none are allowed", func() {
+			_, err := validator.ValidateCreate(ctx, newKind("gpu", 8, oneGPU))
+			Expect(err).To(MatchError(ContainSubstring("gpuClusters")))
+		})
+
+		It("ignores failed clusters", func() {
+			failed := newKind("failed", 16, nil)
+			failed.Status.Phase = maptv1alpha1.KindPhaseFailed
+			existing = append(existing, failed, newKind("a", 4, nil))
+			validator.Client = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build()
+
+			_, err := validator.ValidateCreate(ctx, newKind("second", 4, nil))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When updating a Kind under a MaptQuota", func() {
+		// resize returns a copy of cluster requesting cpus.
+		resize := func(cluster *maptv1alpha1.Kind, cpus int32) *maptv1alpha1.Kind {
+			resized := cluster.DeepCopy()
+			resized.Spec.MachineConfig.CPUs = cpus
+			return resized
+		}
+
+		It("rejects a cluster growing beyond the vCPU limit", func() {
+			cluster := newKind("a", 16, nil)
+			existing = append(existing, cluster, newKind("b", 4, nil))
+			validator.Client = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build()
+
+			_, err := validator.ValidateUpdate(ctx, cluster, resize(cluster, 24))
+			Expect(err).To(MatchError(ContainSubstring("cpus: requested 28, limit 24")))
+		})
+
+		It("admits a cluster growing within the quota", func() {
+			cluster := newKind("a", 8, nil)
+			existing = append(existing, cluster)
+			validator.Client = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build()
+
+			_, err := validator.ValidateUpdate(ctx, cluster, resize(cluster, 24))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("admits a cluster shrinking in a namespace over its quota", func() {
+			cluster := newKind("a", 16, nil)
+			existing = append(existing, cluster, newKind("b", 16, nil))
+			validator.Client = fake.NewClientBuilder().WithScheme(testScheme).WithObjects(existing...).Build()
+
+			_, err := validator.ValidateUpdate(ctx, cluster, resize(cluster, 12))
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When hibernating a Kind", func() {
+		hibernated := func(spot bool) *maptv1alpha1.Kind {
+			kind := newKind("hibernated", 4, nil)
+			kind.Spec.Hibernate = true
+			kind.Spec.MachineConfig.UseSpotInstances = spot
+			return kind
+		}
+
+		It("admits an on-demand cluster", func() {
+			_, err := validator.ValidateUpdate(ctx, newKind("hibernated", 4, nil), hibernated(false))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a spot cluster", func() {
+			_, err := validator.ValidateUpdate(ctx, newKind("hibernated", 4, nil), hibernated(true))
+			Expect(err).To(MatchError(ContainSubstring("spot instances cannot be stopped")))
+		})
+	})
+
+	Context("When validating the schedule of a Kind", func() {
+		withSchedule := func(start, stop, tz string) *maptv1alpha1.Kind {
+			kind := newKind("scheduled", 4, nil)
+			kind.Spec.Schedule = &maptv1alpha1.Schedule{Start: start, Stop: stop, TimeZone: tz}
+			return kind
+		}
+
+		It("admits a valid schedule", func() {
+			_, err := validator.ValidateCreate(ctx, withSchedule("0 8 * * MON-FRI", "0 19 * * MON-FRI", "Europe/Madrid"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an invalid cron expression on creation", func() {
+			_, err := validator.ValidateCreate(ctx, withSchedule("0 25 * * *", "0 19 * * *", ""))
+			Expect(err).To(MatchError(ContainSubstring("invalid spec.schedule: start")))
+		})
+
+		It("rejects an unknown time zone on update", func() {
+			old := newKind("scheduled", 4, nil)
+			_, err := validator.ValidateUpdate(ctx, old, withSchedule("0 8 * * *", "0 19 * * *", "Mars/Olympus"))
+			Expect(err).To(MatchError(ContainSubstring("invalid time zone")))
+		})
+	})
+
+	Context("When updating a Kind whose spec no longer validates", func() {
+		invalid := func() *maptv1alpha1.Kind {
+			kind := newKind("stale", 4, nil)
+			kind.Spec.Schedule = &maptv1alpha1.Schedule{Start: "0 25 * * *", Stop: "0 19 * * *"}
+			return kind
+		}
+
+		It("admits updates leaving the spec alone", func() {
+			kind := invalid()
+			kind.Finalizers = []string{"mapt.redhat.com/finalizer"}
+			_, err := validator.ValidateUpdate(ctx, invalid(), kind)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("admits updates of a cluster being deleted", func() {
+			kind := invalid()
+			kind.Spec.MachineConfig.CPUs = 64
+			kind.DeletionTimestamp = ptr.To(metav1.Now())
+			_, err := validator.ValidateUpdate(ctx, invalid(), kind)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects updates changing the spec", func() {
+			kind := invalid()
+			kind.Spec.MachineConfig.CPUs = 8
+			_, err := validator.ValidateUpdate(ctx, invalid(), kind)
+			Expect(err).To(MatchError(ContainSubstring("invalid spec.schedule")))
+		})
+	})
+
+	Context("When validating the cluster configuration of a Kind", func() {
+		withConfig := func(cfg maptv1alpha1.KindClusterConfig) *maptv1alpha1.Kind {
+			kind := newKind("configured", 4, nil)
+			kind.Spec.KindClusterConfig = cfg
+			return kind
+		}
+
+		It("admits a multi-node cluster with a raw configuration", func() {
+			_, err := validator.ValidateCreate(ctx, withConfig(maptv1alpha1.KindClusterConfig{
+				KubernetesVersion: "v1.32",
+				Nodes:             &maptv1alpha1.KindNodes{ControlPlanes: 1, Workers: 2},
+				FeatureGates:      map[string]bool{"InPlacePodVerticalScaling": true},
+				KindConfig:        "apiVersion: kind.x-k8s.io/v1alpha4\nkind: Cluster\n",
+			}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a raw configuration of another kind on creation", func() {
+			_, err := validator.ValidateCreate(ctx, withConfig(maptv1alpha1.KindClusterConfig{
+				KubernetesVersion: "v1.32",
+				KindConfig:        "apiVersion: v1\nkind: ConfigMap\n",
+			}))
+			Expect(err).To(MatchError(ContainSubstring("invalid spec.kindClusterConfig")))
+		})
+
+		It("rejects an invalid subnet on update", func() {
+			_, err := validator.ValidateUpdate(ctx, newKind("configured", 4, nil), withConfig(maptv1alpha1.KindClusterConfig{
+				KubernetesVersion: "v1.32",
+				Networking:        &maptv1alpha1.KindNetworking{ServiceSubnet: "10.96.0.0/40"},
+			}))
+			Expect(err).To(MatchError(ContainSubstring("networking.serviceSubnet")))
+		})
+	})
+
+	Context("When installing add-ons on a Kind", func() {
+		It("admits well-known and custom add-ons", func() {
+			kind := newKind("addons", 4, nil)
+			kind.Spec.Addons = []maptv1alpha1.KindAddon{
+				{Name: "ingress-nginx"},
+				{Name: "demo", ConfigMapRef: &corev1.LocalObjectReference{Name: "demo-manifests"}},
+			}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an unknown add-on without a source", func() {
+			kind := newKind("addons", 4, nil)
+			kind.Spec.Addons = []maptv1alpha1.KindAddon{{Name: "istio"}}
+			_, err := validator.ValidateUpdate(ctx, newKind("addons", 4, nil), kind)
+			Expect(err).To(MatchError(ContainSubstring("invalid spec.addons[0]")))
+		})
+	})
+
+	Context("When requesting GPUs for a Kind", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		It("admits requirements met by a GPU instance type", func() {
+			_, err := validator.ValidateCreate(ctx, newKind("gpu", 8, &maptv1alpha1.GPURequirements{Model: maptv1alpha1.GPUModelL4, Count: 1}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects requirements no GPU instance type meets", func() {
+			kind := newKind("gpu", 8, &maptv1alpha1.GPURequirements{Model: maptv1alpha1.GPUModelL4, MinMemoryGiB: 48})
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("no GPU instance type offers")))
+		})
+
+		It("rejects GPUs on arm64", func() {
+			kind := newKind("gpu", 8, oneGPU)
+			kind.Spec.MachineConfig.Architecture = "arm64"
+			_, err := validator.ValidateUpdate(ctx, newKind("gpu", 8, oneGPU), kind)
+			Expect(err).To(MatchError(ContainSubstring("x86_64")))
+		})
+	})
+
+	Context("When restricting the placement of a Kind", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		It("admits an allow-list of instance types", func() {
+			kind := newKind("placed", 4, nil)
+			kind.Spec.MachineConfig.InstanceTypes = []string{"m6i.xlarge", "m5.xlarge"}
+			kind.Spec.MachineConfig.ExcludedInstanceTypes = []string{"m5.xlarge"}
+			kind.Spec.CloudConfig.Regions = []string{"us-east-1"}
+			kind.Spec.CloudConfig.AvailabilityZones = []string{"us-east-1a", "us-east-1b"}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an allow-list emptied by the excluded instance types", func() {
+			kind := newKind("placed", 4, nil)
+			kind.Spec.MachineConfig.InstanceTypes = []string{"m6i.xlarge"}
+			kind.Spec.MachineConfig.ExcludedInstanceTypes = []string{"m6i.xlarge"}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("excludedInstanceTypes")))
+		})
+
+		It("rejects instance types that do not meet the GPU requirements", func() {
+			kind := newKind("placed", 4, oneGPU)
+			kind.Spec.MachineConfig.InstanceTypes = []string{"m6i.xlarge"}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("meets the GPU requirements")))
+		})
+
+		It("keeps the instance types supporting nested virtualization", func() {
+			kind := newKind("placed", 4, nil)
+			kind.Spec.MachineConfig.NestedVirtualizationEnabled = true
+			kind.Spec.MachineConfig.InstanceTypes = []string{"m6i.xlarge", "m8i.xlarge", "m7i.metal-24xl"}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).NotTo(HaveOccurred())
+
+			kind.Spec.MachineConfig.InstanceTypes = []string{"m6i.xlarge"}
+			_, err = validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("supports nested virtualization")))
+		})
+
+		It("rejects nested virtualization on GPU instances", func() {
+			kind := newKind("placed", 4, oneGPU)
+			kind.Spec.MachineConfig.NestedVirtualizationEnabled = true
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("supports nested virtualization")))
+		})
+
+		It("rejects availability zones outside the regions", func() {
+			kind := newKind("placed", 4, nil)
+			kind.Spec.CloudConfig.Regions = []string{"us-east-1"}
+			kind.Spec.CloudConfig.AvailabilityZones = []string{"eu-west-1a"}
+			_, err := validator.ValidateUpdate(ctx, newKind("placed", 4, nil), kind)
+			Expect(err).To(MatchError(ContainSubstring("spec.cloudConfig.availabilityZones")))
+		})
+
+		Context("with the operator credentials in eu-west-1", func() {
+			BeforeEach(func() {
+				existing = []client.Object{&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: clusters.CloudCredentialsSecretName, Namespace: clusters.CloudCredentialsSecretNamespace},
+					Data:       map[string][]byte{"region": []byte("eu-west-1")},
+				}}
+			})
+
+			It("admits constraints including the region", func() {
+				kind := newKind("placed", 4, nil)
+				kind.Spec.CloudConfig.Regions = []string{"us-east-1", "eu-west-1"}
+				kind.Spec.CloudConfig.AvailabilityZones = []string{"eu-west-1a"}
+				_, err := validator.ValidateCreate(ctx, kind)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("rejects regions excluding the region of the credentials", func() {
+				kind := newKind("placed", 4, nil)
+				kind.Spec.CloudConfig.Regions = []string{"us-east-1"}
+				_, err := validator.ValidateCreate(ctx, kind)
+				Expect(err).To(MatchError(ContainSubstring("clusters are provisioned in eu-west-1")))
+			})
+
+			It("rejects availability zones outside the region of the credentials", func() {
+				kind := newKind("placed", 4, nil)
+				kind.Spec.CloudConfig.AvailabilityZones = []string{"us-east-1a"}
+				_, err := validator.ValidateCreate(ctx, kind)
+				Expect(err).To(MatchError(ContainSubstring("us-east-1a is not in eu-west-1")))
+			})
+
+			It("admits availability zones a fallback strategy lifts", func() {
+				kind := newKind("placed", 4, nil)
+				kind.Spec.CloudConfig.AvailabilityZones = []string{"us-east-1a"}
+				kind.Spec.MachineConfig.Fallback = []maptv1alpha1.FallbackStrategy{
+					{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopePreferred},
+					{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopeAnyZone},
+				}
+				_, err := validator.ValidateCreate(ctx, kind)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("When capping the price of a Kind", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		It("admits a price ceiling with a fallback chain", func() {
+			kind := newKind("capped", 4, nil)
+			kind.Spec.MachineConfig.MaxHourlyPrice = "0.25"
+			kind.Spec.MachineConfig.Fallback = []maptv1alpha1.FallbackStrategy{
+				{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopePreferred},
+				{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopeAnyZone},
+				{Market: maptv1alpha1.InstanceMarketOnDemand, Placement: maptv1alpha1.PlacementScopePreferred},
+			}
+			warnings, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf(ContainSubstring("placement AnyZone only lifts cloudConfig.availabilityZones")))
+		})
+
+		It("does not warn about AnyZone lifting availability zones", func() {
+			kind := newKind("capped", 4, nil)
+			kind.Spec.CloudConfig.AvailabilityZones = []string{"us-east-1a"}
+			kind.Spec.MachineConfig.Fallback = []maptv1alpha1.FallbackStrategy{
+				{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopePreferred},
+				{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopeAnyZone},
+			}
+			warnings, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("rejects a zero price ceiling", func() {
+			kind := newKind("capped", 4, nil)
+			kind.Spec.MachineConfig.MaxHourlyPrice = "0.0"
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("maxHourlyPrice")))
+		})
+
+		It("rejects a strategy listed twice", func() {
+			kind := newKind("capped", 4, nil)
+			kind.Spec.MachineConfig.Fallback = []maptv1alpha1.FallbackStrategy{
+				{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopeAnyZone},
+				{Market: maptv1alpha1.InstanceMarketSpot, Placement: maptv1alpha1.PlacementScopeAnyZone},
+			}
+			_, err := validator.ValidateUpdate(ctx, newKind("capped", 4, nil), kind)
+			Expect(err).To(MatchError(ContainSubstring("spec.machineConfig.fallback")))
+		})
+	})
+
+	Context("When sizing the root disk of a Kind", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		withDisk := func(disk maptv1alpha1.DiskConfig) *maptv1alpha1.Kind {
+			kind := newKind("disk", 4, nil)
+			kind.Spec.MachineConfig.Disk = &disk
+			return kind
+		}
+
+		It("admits a gp3 volume with extra throughput", func() {
+			_, err := validator.ValidateCreate(ctx, withDisk(maptv1alpha1.DiskConfig{
+				SizeGiB: 200, Type: maptv1alpha1.VolumeTypeGP3, IOPS: ptr.To[int32](6000), Throughput: ptr.To[int32](500),
+			}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an io2 volume without IOPS", func() {
+			_, err := validator.ValidateCreate(ctx, withDisk(maptv1alpha1.DiskConfig{SizeGiB: 200, Type: maptv1alpha1.VolumeTypeIO2}))
+			Expect(err).To(MatchError(ContainSubstring("iops is required")))
+		})
+
+		It("rejects more IOPS than the size of a gp3 volume allows", func() {
+			_, err := validator.ValidateUpdate(ctx, newKind("disk", 4, nil), withDisk(maptv1alpha1.DiskConfig{
+				SizeGiB: 10, Type: maptv1alpha1.VolumeTypeGP3, IOPS: ptr.To[int32](8000),
+			}))
+			Expect(err).To(MatchError(ContainSubstring("spec.machineConfig.disk")))
+		})
+	})
+
+	Context("When restricting the network of a Kind", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		withNetwork := func(network maptv1alpha1.NetworkConfig) *maptv1alpha1.Kind {
+			kind := newKind("network", 4, nil)
+			kind.Spec.Network = &network
+			return kind
+		}
+
+		It("admits IPv4 and IPv6 ranges", func() {
+			_, err := validator.ValidateCreate(ctx, withNetwork(maptv1alpha1.NetworkConfig{
+				AllowedCIDRs: []string{"10.0.0.0/8", "2001:db8::/32"}, DisableSSH: true,
+			}))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects an address that is not a range", func() {
+			_, err := validator.ValidateCreate(ctx, withNetwork(maptv1alpha1.NetworkConfig{AllowedCIDRs: []string{"203.0.113.7"}}))
+			Expect(err).To(MatchError(ContainSubstring("is not a CIDR range")))
+		})
+
+		It("rejects a range with host bits set on update", func() {
+			_, err := validator.ValidateUpdate(ctx, newKind("network", 4, nil), withNetwork(maptv1alpha1.NetworkConfig{
+				AllowedCIDRs: []string{"10.1.2.3/16"},
+			}))
+			Expect(err).To(MatchError(ContainSubstring("the range is 10.1.0.0/16")))
+		})
+	})
+
+	Context("When installing the GPU stack on a Kind", func() {
+		BeforeEach(func() {
+			existing = nil
+		})
+
+		It("admits time-slicing on a GPU cluster", func() {
+			kind := newKind("gpu", 4, oneGPU)
+			kind.Spec.GPUStack = &maptv1alpha1.GPUStack{TimeSlicing: &maptv1alpha1.GPUTimeSlicing{Replicas: 4}}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a cluster without GPUs", func() {
+			kind := newKind("gpu", 4, nil)
+			kind.Spec.GPUStack = &maptv1alpha1.GPUStack{}
+			_, err := validator.ValidateCreate(ctx, kind)
+			Expect(err).To(MatchError(ContainSubstring("requires spec.machineConfig.gpu")))
+		})
+
+		It("rejects MIG on update", func() {
+			kind := newKind("gpu", 4, oneGPU)
+			kind.Spec.GPUStack = &maptv1alpha1.GPUStack{MIG: &maptv1alpha1.GPUMIG{Profile: "all-1g.10gb"}}
+			_, err := validator.ValidateUpdate(ctx, newKind("gpu", 4, oneGPU), kind)
+			Expect(err).To(MatchError(ContainSubstring("only supported on Openshift")))
+		})
+	})
+
+	Context("When extending the TTL of a Kind", func() {
+		withTTL := func(annotation string) *maptv1alpha1.Kind {
+			kind := newKind("ttl", 4, nil)
+			kind.Spec.TerminationPolicy = &maptv1alpha1.TerminationPolicy{DeleteAfterSeconds: ptr.To[int64](3600)}
+			if annotation != "" {
+				kind.Annotations = map[string]string{metadata.ExtendTTLAnnotation: annotation}
+			}
+			return kind
+		}
+
+		It("admits a positive duration", func() {
+			_, err := validator.ValidateUpdate(ctx, withTTL(""), withTTL("2h"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("rejects a value that is not a positive duration", func() {
+			_, err := validator.ValidateUpdate(ctx, withTTL(""), withTTL("tomorrow"))
+			Expect(err).To(MatchError(ContainSubstring("expected a positive duration")))
+			_, err = validator.ValidateUpdate(ctx, withTTL(""), withTTL("-1h"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects extending a cluster without a TTL", func() {
+			kind := withTTL("2h")
+			kind.Spec.TerminationPolicy = nil
+			_, err := validator.ValidateUpdate(ctx, newKind("ttl", 4, nil), kind)
+			Expect(err).To(MatchError(ContainSubstring("requires terminationPolicy.deleteAfterSeconds")))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/gpustack"
+	"github.com/mapt-oss/mapt-operator/pkg/quota"
+)
+
+// openshiftlog is for logging in this package.
+var openshiftlog = logf.Log.WithName("openshift-resource")
+
+// SetupOpenshiftWebhookWithManager registers the webhook for Openshift in the manager.
+func SetupOpenshiftWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&maptv1alpha1.Openshift{}).
+		WithValidator(&OpenshiftCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-mapt-redhat-com-v1alpha1-openshift,mutating=false,failurePolicy=fail,sideEffects=None,groups=mapt.redhat.com,resources=openshifts,verbs=create;update,versions=v1alpha1,name=vopenshift-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// OpenshiftCustomValidator validates Openshift resources when they are created or updated.
+// Creation, and updates requesting a larger machine, are rejected when they would exceed a
+// MaptQuota of the namespace, and both creation and updates changing the spec are rejected when
+// spec.schedule cannot be evaluated, a spot cluster is hibernated or the extend-ttl annotation is
+// invalid. Updates of a cluster being deleted are always admitted. Fallback strategies with an
+// AnyZone placement that has no zones to lift are admitted with a warning.
+type OpenshiftCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &OpenshiftCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Openshift.
+func (v *OpenshiftCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	openshift, ok := obj.(*maptv1alpha1.Openshift)
+	if !ok {
+		return nil, fmt.Errorf("expected an Openshift object but got %T", obj)
+	}
+	openshiftlog.Info("Validation for Openshift upon creation", "name", openshift.GetName())
+
+	if err := validateMachineConfig(openshift.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateNetwork(openshift.Spec.Network); err != nil {
+		return nil, err
+	}
+	if err := validateGPUStack(gpustack.Openshift, openshift.Spec.GPUStack, openshift.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(openshift.Spec.Schedule); err != nil {
+		return nil, err
+	}
+	if err := validateHibernation(openshift.Spec.Hibernate, openshift.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateTTLExtension(ctx, nil, openshift, &openshift.Spec.TerminationPolicy); err != nil {
+		return nil, err
+	}
+	return fallbackWarnings(openshift.Spec.MachineConfig.Fallback, nil), quota.Check(ctx, v.Client, openshift, openshift.Spec.MachineConfig, quota.AllClusters)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Openshift.
+func (v *OpenshiftCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	openshift, ok := newObj.(*maptv1alpha1.Openshift)
+	if !ok {
+		return nil, fmt.Errorf("expected an Openshift object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*maptv1alpha1.Openshift)
+	if !ok {
+		return nil, fmt.Errorf("expected an Openshift object for the oldObj but got %T", oldObj)
+	}
+	// Updates of a cluster being deleted, or leaving its spec alone such as the controller removing
+	// its finalizer, are not validated again: a spec that no longer validates, e.g. after the
+	// operator was upgraded, must not keep the cluster from being finalized.
+	if openshift.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+	if equality.Semantic.DeepEqual(old.Spec, openshift.Spec) {
+		return nil, validateTTLExtension(ctx, oldObj, openshift, &openshift.Spec.TerminationPolicy)
+	}
+	if err := validateMachineConfig(openshift.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateNetwork(openshift.Spec.Network); err != nil {
+		return nil, err
+	}
+	if err := validateGPUStack(gpustack.Openshift, openshift.Spec.GPUStack, openshift.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateSchedule(openshift.Spec.Schedule); err != nil {
+		return nil, err
+	}
+	if err := validateHibernation(openshift.Spec.Hibernate, openshift.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if err := validateTTLExtension(ctx, oldObj, openshift, &openshift.Spec.TerminationPolicy); err != nil {
+		return nil, err
+	}
+	warnings := fallbackWarnings(openshift.Spec.MachineConfig.Fallback, nil)
+	// A cluster requesting more resources must fit in the quotas as on creation, while shrinking
+	// it is always admitted, even when the namespace is over a quota lowered since.
+	if quota.UsageFor(openshift.Spec.MachineConfig).Within(quota.UsageFor(old.Spec.MachineConfig)) {
+		return warnings, nil
+	}
+	return warnings, quota.Check(ctx, v.Client, openshift, openshift.Spec.MachineConfig, quota.AllClusters)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Openshift.
+func (v *OpenshiftCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	maptv1alpha1 "github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/internal/metadata"
+	"github.com/mapt-oss/mapt-operator/pkg/addons"
+	"github.com/mapt-oss/mapt-operator/pkg/clusters"
+	"github.com/mapt-oss/mapt-operator/pkg/gpustack"
+	"github.com/mapt-oss/mapt-operator/pkg/kindconfig"
+	"github.com/mapt-oss/mapt-operator/pkg/schedule"
+)
+
+// auditlog records who requested changes to the lifetime of clusters.
+var auditlog = logf.Log.WithName("audit")
+
+// validateSchedule rejects schedules whose cron expressions or time zone cannot be evaluated.
+func validateSchedule(s *maptv1alpha1.Schedule) error {
+	if s == nil {
+		return nil
+	}
+	if _, err := schedule.NewWindow(s.Start, s.Stop, s.TimeZone); err != nil {
+		return fmt.Errorf("invalid spec.schedule: %w", err)
+	}
+	return nil
+}
+
+// validateHibernation rejects hibernating spot clusters: their instance cannot be stopped.
+func validateHibernation(hibernate bool, machine maptv1alpha1.MachineConfig) error {
+	if hibernate && machine.UseSpotInstances {
+		return fmt.Errorf("invalid spec.hibernate: spot instances cannot be stopped; set spec.machineConfig.useSpotInstances to false to hibernate the cluster")
+	}
+	return nil
+}
+
+// validateKindClusterConfig rejects Kind cluster configurations that cannot be rendered into a
+// kind configuration, e.g. a malformed raw kindConfig or invalid subnets.
+func validateKindClusterConfig(cfg maptv1alpha1.KindClusterConfig) error {
+	if err := kindconfig.Validate(cfg); err != nil {
+		return fmt.Errorf("invalid spec.kindClusterConfig: %w", err)
+	}
+	return nil
+}
+
+// validateAddons rejects add-ons that are neither well-known nor given a source.
+func validateAddons(specs []maptv1alpha1.KindAddon) error {
+	for i, addon := range specs {
+		if err := addons.Validate(addon); err != nil {
+			return fmt.Errorf("invalid spec.addons[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// validateMachineConfig rejects machines no instance type can be provisioned for, e.g. GPU
+// requirements no GPU instance type meets or an allow-list emptied by excludedInstanceTypes, as
+// well as a zero maxHourlyPrice, fallback strategies listed twice and root volumes EBS does not
+// accept.
+func validateMachineConfig(machine maptv1alpha1.MachineConfig) error {
+	if machine.GPU && machine.Architecture == "arm64" {
+		return fmt.Errorf("invalid spec.machineConfig.gpu: GPU instances are only available for the x86_64 architecture")
+	}
+	if _, err := clusters.CandidateInstanceTypes(machine); err != nil {
+		return fmt.Errorf("invalid spec.machineConfig: %w", err)
+	}
+	if _, err := clusters.MaxHourlyPrice(machine); err != nil {
+		return fmt.Errorf("invalid spec.machineConfig: %w", err)
+	}
+	for i, strategy := range machine.Fallback {
+		if slices.Contains(machine.Fallback[:i], strategy) {
+			return fmt.Errorf("invalid spec.machineConfig.fallback: strategy %s/%s is listed twice", strategy.Market, strategy.Placement)
+		}
+	}
+	if machine.Disk != nil {
+		if err := clusters.ValidateDisk(*machine.Disk); err != nil {
+			return fmt.Errorf("invalid spec.machineConfig.disk: %w", err)
+		}
+	}
+	return nil
+}
+
+// fallbackWarnings warns about AnyZone strategies of fallback that cannot change where the cluster
+// is provisioned: AnyZone only lifts the availability zones of the cluster, and clusters always run
+// in the region of the operator credentials.
+func fallbackWarnings(fallback []maptv1alpha1.FallbackStrategy, zones []string) admission.Warnings {
+	if len(zones) > 0 || !slices.ContainsFunc(fallback, func(s maptv1alpha1.FallbackStrategy) bool {
+		return s.Placement == maptv1alpha1.PlacementScopeAnyZone
+	}) {
+		return nil
+	}
+	return admission.Warnings{"spec.machineConfig.fallback: placement AnyZone only lifts cloudConfig.availabilityZones and " +
+		"never moves the cluster to another region than the one of the operator credentials; without availability zones it tries the same placement as Preferred"}
+}
+
+// validateCloudConfig rejects availability zones outside the allowed regions. Regions and zones
+// only constrain where the cluster may run: clusters are provisioned in the region of the operator
+// credentials, so regions excluding it are rejected too, and so are zones outside it unless a
+// fallback strategy lifts them. They are not checked against the credentials while their Secret
+// does not exist.
+func validateCloudConfig(ctx context.Context, c client.Reader, cfg maptv1alpha1.CloudConfig, fallback []maptv1alpha1.FallbackStrategy) error {
+	if len(cfg.Regions) > 0 {
+		for _, zone := range cfg.AvailabilityZones {
+			if !slices.ContainsFunc(cfg.Regions, func(region string) bool { return strings.HasPrefix(zone, region) }) {
+				return fmt.Errorf("invalid spec.cloudConfig.availabilityZones: %s is not in any of the regions %v", zone, cfg.Regions)
+			}
+		}
+	}
+	zones := cfg.AvailabilityZones
+	if slices.ContainsFunc(fallback, func(s maptv1alpha1.FallbackStrategy) bool { return s.Placement == maptv1alpha1.PlacementScopeAnyZone }) {
+		zones = nil
+	}
+	if len(cfg.Regions) == 0 && len(zones) == 0 {
+		return nil
+	}
+
+	region, err := clusters.CredentialsRegion(ctx, c)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to validate spec.cloudConfig: %w", err)
+	}
+	if region == "" {
+		return nil
+	}
+	if len(cfg.Regions) > 0 && !slices.Contains(cfg.Regions, region) {
+		return fmt.Errorf("invalid spec.cloudConfig.regions: clusters are provisioned in %s, the region of the operator credentials, which is not one of %v",
+			region, cfg.Regions)
+	}
+	for _, zone := range zones {
+		if !strings.HasPrefix(zone, region) {
+			return fmt.Errorf("invalid spec.cloudConfig.availabilityZones: %s is not in %s, the region of the operator credentials", zone, region)
+		}
+	}
+	return nil
+}
+
+// validateNetwork rejects allowed CIDRs that are not IP ranges.
+func validateNetwork(network *maptv1alpha1.NetworkConfig) error {
+	if network == nil {
+		return nil
+	}
+	if err := clusters.ValidateNetwork(*network); err != nil {
+		return fmt.Errorf("invalid spec.network: %w", err)
+	}
+	return nil
+}
+
+// validateGPUStack rejects GPU stacks on clusters without GPUs, and configurations the platform of
+// the cluster does not support.
+func validateGPUStack(platform gpustack.Platform, stack *maptv1alpha1.GPUStack, machine maptv1alpha1.MachineConfig) error {
+	if stack == nil {
+		return nil
+	}
+	if !machine.GPU {
+		return fmt.Errorf("invalid spec.gpuStack: it requires spec.machineConfig.gpu")
+	}
+	if err := gpustack.Validate(platform, *stack); err != nil {
+		return fmt.Errorf("invalid spec.gpuStack: %w", err)
+	}
+	return nil
+}
+
+// validateTTLExtension rejects extend-ttl annotations that are not a positive duration or extend a
+// cluster without a TTL. oldObj is nil on creation. Accepted extensions are logged along with the
+// user requesting them.
+func validateTTLExtension(ctx context.Context, oldObj runtime.Object, obj client.Object, policy *maptv1alpha1.TerminationPolicy) error {
+	value, ok := obj.GetAnnotations()[metadata.ExtendTTLAnnotation]
+	if !ok {
+		return nil
+	}
+	if old, isObject := oldObj.(client.Object); isObject && old.GetAnnotations()[metadata.ExtendTTLAnnotation] == value {
+		return nil
+	}
+	if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+		return fmt.Errorf("invalid %s annotation %q: expected a positive duration such as \"2h\"", metadata.ExtendTTLAnnotation, value)
+	}
+	if policy == nil || policy.DeleteAfterSeconds == nil {
+		return fmt.Errorf("%s annotation requires terminationPolicy.deleteAfterSeconds", metadata.ExtendTTLAnnotation)
+	}
+
+	user := "unknown"
+	if req, err := admission.RequestFromContext(ctx); err == nil {
+		user = req.UserInfo.Username
+	}
+	auditlog.Info("TTL extension requested", "namespace", obj.GetNamespace(), "name", obj.GetName(),
+		"extendBy", value, "user", user)
+	return nil
+}
@@ -0,0 +1,341 @@
+// Package addons installs add-ons on provisioned clusters. An add-on is a set of manifests, taken
+// from the upstream release of a well-known add-on, a URL or a ConfigMap, which are server-side
+// applied to the cluster. An add-on is ready once every workload it defines is available.
+package addons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// FieldOwner is the field manager the resources of add-ons are applied with.
+	FieldOwner = "mapt-operator"
+
+	// maxManifestSize bounds the size of a manifest downloaded from a URL.
+	maxManifestSize = 16 << 20
+	// fetchTimeout bounds how long downloading a manifest may take.
+	fetchTimeout = time.Minute
+)
+
+// wellKnown describes an add-on the operator knows how to install from its upstream release.
+type wellKnown struct {
+	// defaultVersion is installed when no version is requested.
+	defaultVersion string
+	// url is the manifest of a release, formatted with its version.
+	url string
+	// adjust tailors the upstream resources to Kind clusters; it may be nil.
+	adjust func(obj *unstructured.Unstructured) error
+}
+
+// catalog holds the well-known add-ons by name.
+var catalog = map[string]wellKnown{
+	"ingress-nginx": {
+		defaultVersion: "v1.13.0",
+		url:            "https://raw.githubusercontent.com/kubernetes/ingress-nginx/controller-%s/deploy/static/provider/kind/deploy.yaml",
+	},
+	"cert-manager": {
+		defaultVersion: "v1.18.2",
+		url:            "https://github.com/cert-manager/cert-manager/releases/download/%s/cert-manager.yaml",
+	},
+	"metrics-server": {
+		defaultVersion: "v0.8.0",
+		url:            "https://github.com/kubernetes-sigs/metrics-server/releases/download/%s/components.yaml",
+		adjust:         insecureKubeletTLS,
+	},
+}
+
+// WellKnown returns the names of the well-known add-ons, sorted.
+func WellKnown() []string {
+	names := make([]string, 0, len(catalog))
+	for name := range catalog {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Validate rejects add-ons without a source, and versions of add-ons that are not taken from an
+// upstream release.
+func Validate(addon v1alpha1.KindAddon) error {
+	_, known := catalog[addon.Name]
+	custom := addon.ManifestURL != "" || addon.ConfigMapRef != nil
+	switch {
+	case !known && !custom:
+		return fmt.Errorf("add-on %q is not well-known (%s): set manifestURL or configMapRef",
+			addon.Name, strings.Join(WellKnown(), ", "))
+	case custom && addon.Version != "":
+		return fmt.Errorf("add-on %q: version only applies to the upstream release of well-known add-ons", addon.Name)
+	case addon.ManifestURL != "" && addon.ConfigMapRef != nil:
+		return fmt.Errorf("add-on %q: manifestURL and configMapRef are mutually exclusive", addon.Name)
+	case addon.ConfigMapRef != nil && addon.ConfigMapRef.Name == "":
+		return fmt.Errorf("add-on %q: configMapRef requires a name", addon.Name)
+	}
+	return nil
+}
+
+// Source returns where the resources of addon come from, and the release installed for
+// well-known add-ons taken from upstream.
+func Source(addon v1alpha1.KindAddon) (source, version string) {
+	switch {
+	case addon.ConfigMapRef != nil:
+		return "configmap/" + addon.ConfigMapRef.Name, ""
+	case addon.ManifestURL != "":
+		return addon.ManifestURL, ""
+	}
+	known := catalog[addon.Name]
+	version = addon.Version
+	if version == "" {
+		version = known.defaultVersion
+	}
+	return fmt.Sprintf(known.url, version), version
+}
+
+// Load returns the resources of addon. ConfigMaps are read from namespace with c.
+func Load(ctx context.Context, c client.Reader, namespace string, addon v1alpha1.KindAddon) ([]*unstructured.Unstructured, error) {
+	if err := Validate(addon); err != nil {
+		return nil, err
+	}
+
+	var manifests [][]byte
+	if ref := addon.ConfigMapRef; ref != nil {
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.Name}, cm); err != nil {
+			return nil, fmt.Errorf("failed to get ConfigMap %s: %w", ref.Name, err)
+		}
+		keys := make([]string, 0, len(cm.Data))
+		for key := range cm.Data {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			manifests = append(manifests, []byte(cm.Data[key]))
+		}
+	} else {
+		url, _ := Source(addon)
+		data, err := fetch(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, data)
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, manifest := range manifests {
+		parsed, err := Parse(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("add-on %q: %w", addon.Name, err)
+		}
+		objs = append(objs, parsed...)
+	}
+	if known, ok := catalog[addon.Name]; ok && known.adjust != nil && addon.ManifestURL == "" && addon.ConfigMapRef == nil {
+		for _, obj := range objs {
+			if err := known.adjust(obj); err != nil {
+				return nil, fmt.Errorf("add-on %q: %w", addon.Name, err)
+			}
+		}
+	}
+	return objs, nil
+}
+
+// Parse decodes the resources of a YAML or JSON manifest holding any number of documents. Empty
+// documents and List objects are flattened.
+func Parse(manifest []byte) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), 4096)
+	var objs []*unstructured.Unstructured
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		if len(obj.Object) == 0 {
+			continue
+		}
+		if obj.IsList() {
+			if err := obj.EachListItem(func(item runtime.Object) error {
+				objs = append(objs, item.(*unstructured.Unstructured))
+				return nil
+			}); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("invalid manifest: every resource requires apiVersion, kind and metadata.name")
+		}
+		objs = append(objs, obj)
+	}
+}
+
+// Apply server-side applies objs to the cluster of c. CustomResourceDefinitions and Namespaces are
+// applied first, so that the resources depending on them can be applied in the same pass.
+// Namespaced resources without a namespace are set to, and applied to, the default namespace.
+func Apply(ctx context.Context, c client.Client, objs []*unstructured.Unstructured) error {
+	ordered := slices.Clone(objs)
+	slices.SortStableFunc(ordered, func(a, b *unstructured.Unstructured) int {
+		return applyOrder(a) - applyOrder(b)
+	})
+	for _, obj := range ordered {
+		if obj.GetNamespace() == "" {
+			namespaced, err := c.IsObjectNamespaced(obj)
+			if err != nil {
+				return fmt.Errorf("failed to resolve %s %s: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			if namespaced {
+				obj.SetNamespace(corev1.NamespaceDefault)
+			}
+		}
+		// The response of the apply overwrites the object it is given.
+		obj = obj.DeepCopy()
+		if err := c.Patch(ctx, obj, client.Apply, client.FieldOwner(FieldOwner), client.ForceOwnership); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+	}
+	return nil
+}
+
+func applyOrder(obj *unstructured.Unstructured) int {
+	switch obj.GetKind() {
+	case "CustomResourceDefinition":
+		return 0
+	case "Namespace":
+		return 1
+	}
+	return 2
+}
+
+// Ready reports whether the Deployments, StatefulSets and DaemonSets among objs are available on
+// the cluster of c. When they are not, it returns a message naming the first one that is not.
+func Ready(ctx context.Context, c client.Reader, objs []*unstructured.Unstructured) (bool, string, error) {
+	for _, obj := range objs {
+		if obj.GroupVersionKind().Group != "apps" {
+			continue
+		}
+		switch obj.GetKind() {
+		case "Deployment", "StatefulSet", "DaemonSet":
+		default:
+			continue
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+			return false, "", fmt.Errorf("failed to get %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+		if !available(live) {
+			return false, fmt.Sprintf("%s %s is not available yet.", obj.GetKind(), client.ObjectKeyFromObject(obj)), nil
+		}
+	}
+	return true, "", nil
+}
+
+// available reports whether the controller of a workload observed its current generation and all
+// its replicas are available.
+func available(obj *unstructured.Unstructured) bool {
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if observed < obj.GetGeneration() {
+		return false
+	}
+	switch obj.GetKind() {
+	case "DaemonSet":
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberAvailable")
+		return ready >= desired
+	case "StatefulSet":
+		return replicasReady(obj, "readyReplicas")
+	default:
+		return replicasReady(obj, "availableReplicas")
+	}
+}
+
+func replicasReady(obj *unstructured.Unstructured, field string) bool {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		replicas = 1
+	}
+	ready, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+	return ready >= replicas
+}
+
+// NewClient returns a client for the cluster kubeconfig points at.
+func NewClient(kubeconfig []byte) (client.Client, error) {
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for cluster: %w", err)
+	}
+	return c, nil
+}
+
+func fetch(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest URL %s: %w", url, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if len(data) > maxManifestSize {
+		return nil, fmt.Errorf("manifest %s exceeds %d bytes", url, maxManifestSize)
+	}
+	return data, nil
+}
+
+// insecureKubeletTLS lets metrics-server scrape the kubelets of Kind nodes, whose serving
+// certificates are self-signed.
+func insecureKubeletTLS(obj *unstructured.Unstructured) error {
+	if obj.GetKind() != "Deployment" || obj.GetName() != "metrics-server" {
+		return nil
+	}
+	containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	if err != nil {
+		return fmt.Errorf("invalid metrics-server Deployment: %w", err)
+	}
+	for i, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok || container["name"] != "metrics-server" {
+			continue
+		}
+		args, _, _ := unstructured.NestedStringSlice(container, "args")
+		if !slices.Contains(args, "--kubelet-insecure-tls") {
+			args = append(args, "--kubelet-insecure-tls")
+		}
+		if err := unstructured.SetNestedStringSlice(container, args, "args"); err != nil {
+			return err
+		}
+		containers[i] = container
+	}
+	return unstructured.SetNestedSlice(obj.Object, containers, "spec", "template", "spec", "containers")
+}
@@ -0,0 +1,167 @@
+package addons
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const manifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: demo
+---
+# an empty document
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: settings
+    namespace: demo
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: demo
+    namespace: demo
+  spec:
+    replicas: 2
+`
+
+var _ = Describe("Parse", func() {
+	It("flattens documents and lists", func() {
+		objs, err := Parse([]byte(manifest))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(3))
+		Expect(objs[0].GetKind()).To(Equal("Namespace"))
+		Expect(objs[1].GetKind()).To(Equal("ConfigMap"))
+		Expect(objs[2].GetKind()).To(Equal("Deployment"))
+	})
+
+	It("rejects resources without a name", func() {
+		_, err := Parse([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+		Expect(err).To(MatchError(ContainSubstring("metadata.name")))
+	})
+})
+
+var _ = Describe("Validate", func() {
+	DescribeTable("checks the source of add-ons",
+		func(addon v1alpha1.KindAddon, message string) {
+			err := Validate(addon)
+			if message == "" {
+				Expect(err).NotTo(HaveOccurred())
+				return
+			}
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("well-known add-on", v1alpha1.KindAddon{Name: "cert-manager", Version: "v1.17.0"}, ""),
+		Entry("well-known add-on from a mirror", v1alpha1.KindAddon{Name: "cert-manager", ManifestURL: "https://mirror/cert-manager.yaml"}, ""),
+		Entry("custom add-on", v1alpha1.KindAddon{Name: "demo", ConfigMapRef: &corev1.LocalObjectReference{Name: "demo"}}, ""),
+		Entry("unknown add-on without source", v1alpha1.KindAddon{Name: "demo"}, "is not well-known"),
+		Entry("version of a custom add-on", v1alpha1.KindAddon{Name: "demo", ManifestURL: "https://example.com/demo.yaml", Version: "v1"}, "version only applies"),
+		Entry("both sources", v1alpha1.KindAddon{Name: "demo", ManifestURL: "https://example.com/demo.yaml",
+			ConfigMapRef: &corev1.LocalObjectReference{Name: "demo"}}, "mutually exclusive"),
+	)
+})
+
+var _ = Describe("Source", func() {
+	It("resolves the release of well-known add-ons", func() {
+		source, version := Source(v1alpha1.KindAddon{Name: "metrics-server"})
+		Expect(version).To(Equal(catalog["metrics-server"].defaultVersion))
+		Expect(source).To(ContainSubstring("/download/" + version + "/components.yaml"))
+
+		source, version = Source(v1alpha1.KindAddon{Name: "ingress-nginx", Version: "v1.12.1"})
+		Expect(version).To(Equal("v1.12.1"))
+		Expect(source).To(ContainSubstring("controller-v1.12.1/deploy/static/provider/kind/"))
+	})
+
+	It("reports custom sources", func() {
+		source, version := Source(v1alpha1.KindAddon{Name: "demo", ConfigMapRef: &corev1.LocalObjectReference{Name: "demo-manifests"}})
+		Expect(source).To(Equal("configmap/demo-manifests"))
+		Expect(version).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Load", func() {
+	It("reads the values of a ConfigMap in key order", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo-manifests", Namespace: "team"},
+			Data: map[string]string{
+				"2-config.yaml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: second\n",
+				"1-namespace.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: first\n",
+			},
+		}).Build()
+
+		objs, err := Load(context.Background(), c, "team", v1alpha1.KindAddon{
+			Name:         "demo",
+			ConfigMapRef: &corev1.LocalObjectReference{Name: "demo-manifests"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objs).To(HaveLen(2))
+		Expect(objs[0].GetName()).To(Equal("first"))
+		Expect(objs[1].GetName()).To(Equal("second"))
+	})
+})
+
+var _ = Describe("Ready", func() {
+	It("waits for the workloads of the add-on", func() {
+		objs, err := Parse([]byte(manifest))
+		Expect(err).NotTo(HaveOccurred())
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "demo"},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2)},
+			Status:     appsv1.DeploymentStatus{AvailableReplicas: 1},
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(deployment).Build()
+
+		ready, message, err := Ready(context.Background(), c, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(message).To(ContainSubstring("Deployment demo/demo"))
+
+		deployment.Status.AvailableReplicas = 2
+		Expect(c.Status().Update(context.Background(), deployment)).To(Succeed())
+		ready, _, err = Ready(context.Background(), c, objs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeTrue())
+	})
+})
+
+var _ = Describe("insecureKubeletTLS", func() {
+	It("lets metrics-server scrape kubelets with self-signed certificates", func() {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": "metrics-server", "namespace": "kube-system"},
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name": "metrics-server",
+					"args": []interface{}{"--secure-port=10250"},
+				}},
+			}}},
+		}}
+		Expect(insecureKubeletTLS(obj)).To(Succeed())
+		Expect(insecureKubeletTLS(obj)).To(Succeed())
+
+		containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		args, _, _ := unstructured.NestedStringSlice(containers[0].(map[string]interface{}), "args")
+		Expect(args).To(Equal([]string{"--secure-port=10250", "--kubelet-insecure-tls"}))
+	})
+})
+
+func TestAddons(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Addons Suite")
+}
@@ -0,0 +1,70 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// The provisioning tool runs its stacks to completion and cannot be interrupted. runCancellable
+// lets callers stop waiting for it instead: the tool call keeps running in the background, and its
+// cluster stays busy until the call returns so that a destroy never races with it.
+var (
+	runsMu  sync.Mutex
+	running = map[string]chan struct{}{}
+)
+
+// runCancellable runs fn, returning as soon as either fn completes or ctx is done. fn must own
+// every resource it uses, e.g. its workspace, since it may outlive the call.
+func runCancellable[T any](ctx context.Context, clusterType ClusterType, provisionID string, fn func() (T, error)) (T, error) {
+	key := stateKey(clusterType, provisionID)
+	var zero T
+
+	runsMu.Lock()
+	if _, busy := running[key]; busy {
+		runsMu.Unlock()
+		return zero, fmt.Errorf("an abandoned operation on %s %s is still running", clusterType, provisionID)
+	}
+	done := make(chan struct{})
+	running[key] = done
+	runsMu.Unlock()
+
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		defer func() {
+			runsMu.Lock()
+			delete(running, key)
+			runsMu.Unlock()
+			close(done)
+		}()
+		value, err := fn()
+		results <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-results:
+		return r.value, r.err
+	case <-ctx.Done():
+		return zero, fmt.Errorf("stopped waiting for %s %s: %w", clusterType, provisionID, context.Cause(ctx))
+	}
+}
+
+// waitIdle blocks until no operation runs on the cluster, or ctx is done.
+func waitIdle(ctx context.Context, clusterType ClusterType, provisionID string) error {
+	runsMu.Lock()
+	done, busy := running[stateKey(clusterType, provisionID)]
+	runsMu.Unlock()
+	if !busy {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for the abandoned operation on %s %s: %w", clusterType, provisionID, context.Cause(ctx))
+	}
+}
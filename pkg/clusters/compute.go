@@ -0,0 +1,296 @@
+package clusters
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	instancetypes "github.com/redhat-developer/mapt/pkg/provider/api/compute-request"
+)
+
+// Placement is where the instance of a cluster runs.
+type Placement struct {
+	Region           string `json:"region,omitempty"`
+	AvailabilityZone string `json:"availabilityZone,omitempty"`
+	InstanceType     string `json:"instanceType,omitempty"`
+}
+
+// GPUInstance describes an EC2 instance type fitted with NVIDIA GPUs.
+type GPUInstance struct {
+	InstanceType string
+	Model        v1alpha1.GPUModel
+	// GPUs is the number of GPUs of the instance.
+	GPUs int32
+	// GPUMemoryGiB is the memory of each GPU.
+	GPUMemoryGiB int32
+	CPUs         int32
+	MemoryGiB    int32
+}
+
+// GPUCatalog lists the GPU instance types clusters can be provisioned on.
+var GPUCatalog = []GPUInstance{
+	// G6 (L4)
+	{"g6.xlarge", v1alpha1.GPUModelL4, 1, 24, 4, 16},
+	{"g6.2xlarge", v1alpha1.GPUModelL4, 1, 24, 8, 32},
+	{"g6.4xlarge", v1alpha1.GPUModelL4, 1, 24, 16, 64},
+	{"g6.8xlarge", v1alpha1.GPUModelL4, 1, 24, 32, 128},
+	{"g6.16xlarge", v1alpha1.GPUModelL4, 1, 24, 64, 256},
+	{"g6.12xlarge", v1alpha1.GPUModelL4, 4, 24, 48, 192},
+	{"g6.24xlarge", v1alpha1.GPUModelL4, 4, 24, 96, 384},
+	{"g6.48xlarge", v1alpha1.GPUModelL4, 8, 24, 192, 768},
+
+	// G6e (L40S)
+	{"g6e.xlarge", v1alpha1.GPUModelL40S, 1, 48, 4, 32},
+	{"g6e.2xlarge", v1alpha1.GPUModelL40S, 1, 48, 8, 64},
+	{"g6e.4xlarge", v1alpha1.GPUModelL40S, 1, 48, 16, 128},
+	{"g6e.8xlarge", v1alpha1.GPUModelL40S, 1, 48, 32, 256},
+	{"g6e.16xlarge", v1alpha1.GPUModelL40S, 1, 48, 64, 512},
+	{"g6e.12xlarge", v1alpha1.GPUModelL40S, 4, 48, 48, 384},
+	{"g6e.24xlarge", v1alpha1.GPUModelL40S, 4, 48, 96, 768},
+	{"g6e.48xlarge", v1alpha1.GPUModelL40S, 8, 48, 192, 1536},
+
+	// G5 (A10G)
+	{"g5.xlarge", v1alpha1.GPUModelA10G, 1, 24, 4, 16},
+	{"g5.2xlarge", v1alpha1.GPUModelA10G, 1, 24, 8, 32},
+	{"g5.4xlarge", v1alpha1.GPUModelA10G, 1, 24, 16, 64},
+	{"g5.8xlarge", v1alpha1.GPUModelA10G, 1, 24, 32, 128},
+	{"g5.16xlarge", v1alpha1.GPUModelA10G, 1, 24, 64, 256},
+	{"g5.12xlarge", v1alpha1.GPUModelA10G, 4, 24, 48, 192},
+	{"g5.24xlarge", v1alpha1.GPUModelA10G, 4, 24, 96, 384},
+	{"g5.48xlarge", v1alpha1.GPUModelA10G, 8, 24, 192, 768},
+
+	// P4 (A100)
+	{"p4d.24xlarge", v1alpha1.GPUModelA100, 8, 40, 96, 1152},
+	{"p4de.24xlarge", v1alpha1.GPUModelA100, 8, 80, 96, 1152},
+
+	// P5 (H100)
+	{"p5.48xlarge", v1alpha1.GPUModelH100, 8, 80, 192, 2048},
+}
+
+// GPUInstanceTypes returns the instance types of GPUCatalog meeting the GPU requirements of
+// machine, as well as its cpus and memoryGiB. Only the instance types with the fewest GPUs are
+// returned, so that a request for one GPU is never served by an eight GPU instance. Returns an
+// error if no instance type meets the requirements.
+func GPUInstanceTypes(machine v1alpha1.MachineConfig) ([]string, error) {
+	matches, err := gpuInstances(machine)
+	if err != nil {
+		return nil, err
+	}
+	var types []string
+	for _, instance := range matches {
+		if instance.GPUs != matches[0].GPUs {
+			break
+		}
+		types = append(types, instance.InstanceType)
+	}
+	return types, nil
+}
+
+// CandidateInstanceTypes returns the instance types machine may be provisioned on, in order of
+// preference, without its excluded instance types and, when it needs nested virtualization, the
+// ones not supporting it, or nil when they are derived from its cpus and memoryGiB. Returns an
+// error if no instance type is left.
+func CandidateInstanceTypes(machine v1alpha1.MachineConfig) ([]string, error) {
+	var candidates []string
+	switch {
+	case machine.GPU && len(machine.InstanceTypes) > 0:
+		matches, err := gpuInstances(machine)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range machine.InstanceTypes {
+			if slices.ContainsFunc(matches, func(instance GPUInstance) bool { return instance.InstanceType == t }) {
+				candidates = append(candidates, t)
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("none of the instance types %v meets the GPU requirements", machine.InstanceTypes)
+		}
+	case machine.GPU:
+		types, err := GPUInstanceTypes(machine)
+		if err != nil {
+			return nil, err
+		}
+		candidates = types
+	case len(machine.InstanceTypes) > 0:
+		candidates = slices.Clone(machine.InstanceTypes)
+	default:
+		return nil, nil
+	}
+
+	candidates = withoutExcluded(candidates, machine.ExcludedInstanceTypes)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("every candidate instance type is listed in excludedInstanceTypes")
+	}
+	if machine.NestedVirtualizationEnabled {
+		virt := withNestedVirtualization(slices.Clone(candidates))
+		if len(virt) == 0 {
+			return nil, fmt.Errorf("none of the instance types %v supports nested virtualization", candidates)
+		}
+		candidates = virt
+	}
+	return candidates, nil
+}
+
+// SpotOffer is the best current spot price of an instance type.
+type SpotOffer struct {
+	InstanceType     string
+	AvailabilityZone string
+	// HourlyPrice is in USD.
+	HourlyPrice float64
+}
+
+// MaxHourlyPrice returns the spot price ceiling of machine, or 0 when it has none.
+func MaxHourlyPrice(machine v1alpha1.MachineConfig) (float64, error) {
+	if machine.MaxHourlyPrice == "" {
+		return 0, nil
+	}
+	price, err := strconv.ParseFloat(machine.MaxHourlyPrice, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("maxHourlyPrice %q is not a positive price", machine.MaxHourlyPrice)
+	}
+	return price, nil
+}
+
+// computeRequest returns the instance requirements handed to mapt for machine when provisioned
+// with strategy, restricted to the availability zones of cloud unless the strategy lifts them.
+// mapt selects the cheapest spot offer among the instance types of the request and takes no
+// region, zones or price ceiling; the allow-list, exclusions, zones and price ceiling are applied
+// beforehand. The allow-list is in order of preference, so only its first instance type meeting
+// them is handed to mapt. An *OfferError is returned when no spot offer meets them.
+func computeRequest(ctx context.Context, machines *ec2Machines, machine v1alpha1.MachineConfig, cloud v1alpha1.CloudConfig, strategy v1alpha1.FallbackStrategy) (*instancetypes.ComputeRequestArgs, error) {
+	if strategy.Placement == v1alpha1.PlacementScopeAnyZone {
+		cloud.AvailabilityZones = nil
+	}
+	if len(cloud.Regions) > 0 && !slices.Contains(cloud.Regions, machines.creds.Region) {
+		return nil, fmt.Errorf("clusters are provisioned in %s, the region of the operator credentials, which is not one of cloudConfig.regions %v",
+			machines.creds.Region, cloud.Regions)
+	}
+	maxPrice, err := MaxHourlyPrice(machine)
+	if err != nil {
+		return nil, err
+	}
+	spot := strategy.Market != v1alpha1.InstanceMarketOnDemand
+	checkOffers := spot && (len(machine.InstanceTypes) > 0 || len(cloud.AvailabilityZones) > 0 || maxPrice > 0)
+
+	candidates, err := CandidateInstanceTypes(machine)
+	if err != nil {
+		return nil, err
+	}
+	if candidates == nil {
+		if len(machine.ExcludedInstanceTypes) == 0 && !checkOffers {
+			return &instancetypes.ComputeRequestArgs{
+				CPUs:       machine.CPUs,
+				MemoryGib:  machine.MemoryGiB,
+				Arch:       computeArch(machine.Architecture),
+				NestedVirt: machine.NestedVirtualizationEnabled,
+			}, nil
+		}
+		types, err := machines.InstanceTypes(ctx, machine.Architecture, machine.CPUs, machine.MemoryGiB)
+		if err != nil {
+			return nil, err
+		}
+		candidates = withoutExcluded(types, machine.ExcludedInstanceTypes)
+		if machine.NestedVirtualizationEnabled {
+			candidates = withNestedVirtualization(candidates)
+		}
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no instance type with %d vCPUs and %d GiB of memory meets the machine requirements", machine.CPUs, machine.MemoryGiB)
+		}
+	}
+
+	if checkOffers {
+		offers, err := machines.SpotOffers(ctx, candidates, cloud.AvailabilityZones)
+		if err != nil {
+			return nil, err
+		}
+		if len(offers) == 0 {
+			return nil, &OfferError{
+				Reason:  ReasonInsufficientCapacity,
+				Message: fmt.Sprintf("none of the instance types %v has spot capacity in %s", candidates, zonesName(machines.creds.Region, cloud.AvailabilityZones)),
+			}
+		}
+		if maxPrice > 0 {
+			best := slices.MinFunc(offers, func(a, b SpotOffer) int { return cmp.Compare(a.HourlyPrice, b.HourlyPrice) })
+			offers = slices.DeleteFunc(offers, func(offer SpotOffer) bool { return offer.HourlyPrice > maxPrice })
+			if len(offers) == 0 {
+				return nil, &OfferError{
+					Reason: ReasonPriceAboveMaximum,
+					Message: fmt.Sprintf("the best spot offer, %s in %s at $%.4f/h, is above maxHourlyPrice $%s",
+						best.InstanceType, best.AvailabilityZone, best.HourlyPrice, machine.MaxHourlyPrice),
+				}
+			}
+		}
+		candidates = offeredInstanceTypes(candidates, offers)
+	}
+	if len(machine.InstanceTypes) > 0 && len(candidates) > 1 {
+		candidates = candidates[:1]
+	}
+	return &instancetypes.ComputeRequestArgs{
+		ComputeSizes: candidates,
+		Arch:         computeArch(machine.Architecture),
+	}, nil
+}
+
+// offeredInstanceTypes returns the instance types of candidates with one of offers, in order.
+func offeredInstanceTypes(candidates []string, offers []SpotOffer) []string {
+	return slices.DeleteFunc(slices.Clone(candidates), func(t string) bool {
+		return !slices.ContainsFunc(offers, func(offer SpotOffer) bool { return offer.InstanceType == t })
+	})
+}
+
+// gpuInstances returns the instances of GPUCatalog meeting the GPU requirements of machine, as well
+// as its cpus and memoryGiB, ordered by number of GPUs.
+func gpuInstances(machine v1alpha1.MachineConfig) ([]GPUInstance, error) {
+	req := machine.RequestedGPUs()
+	if req == nil {
+		return nil, fmt.Errorf("no GPU requested")
+	}
+	count := max(req.Count, 1)
+
+	var matches []GPUInstance
+	for _, instance := range GPUCatalog {
+		if (req.Model == "" || instance.Model == req.Model) &&
+			instance.GPUs >= count &&
+			instance.GPUMemoryGiB >= req.MinMemoryGiB &&
+			instance.CPUs >= machine.CPUs &&
+			instance.MemoryGiB >= machine.MemoryGiB {
+			matches = append(matches, instance)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no GPU instance type offers %d %s GPU(s) with %d GiB of memory each, %d vCPUs and %d GiB of memory",
+			count, gpuModelName(req.Model), req.MinMemoryGiB, machine.CPUs, machine.MemoryGiB)
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].GPUs < matches[j].GPUs })
+	return matches, nil
+}
+
+func withoutExcluded(types, excluded []string) []string {
+	return slices.DeleteFunc(types, func(t string) bool { return slices.Contains(excluded, t) })
+}
+
+func computeArch(architecture string) instancetypes.Arch {
+	if architecture == "arm64" {
+		return instancetypes.Arm64
+	}
+	return instancetypes.Amd64
+}
+
+func zonesName(region string, zones []string) string {
+	if len(zones) == 0 {
+		return region
+	}
+	return fmt.Sprintf("%v", zones)
+}
+
+func gpuModelName(model v1alpha1.GPUModel) string {
+	if model == "" {
+		return "NVIDIA"
+	}
+	return string(model)
+}
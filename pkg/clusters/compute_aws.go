@@ -0,0 +1,116 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// spotProductDescription is the platform of the spot prices the instance types are checked with.
+const spotProductDescription = "Linux/UNIX"
+
+// Placement returns where the instance of a provisioned cluster runs.
+func (m *ec2Machines) Placement(ctx context.Context, provisionID string) (Placement, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return Placement{}, err
+	}
+	instance, err := findInstance(ctx, client, provisionID, "")
+	if err != nil {
+		return Placement{}, err
+	}
+	placement := Placement{Region: m.creds.Region, InstanceType: string(instance.InstanceType)}
+	if instance.Placement != nil {
+		placement.AvailabilityZone = aws.ToString(instance.Placement.AvailabilityZone)
+	}
+	return placement, nil
+}
+
+// InstanceTypes returns the instance types of the region with at least cpus vCPUs and memoryGiB
+// of memory, for the given architecture.
+func (m *ec2Machines) InstanceTypes(ctx context.Context, arch string, cpus, memoryGiB int32) ([]string, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input := &ec2.GetInstanceTypesFromInstanceRequirementsInput{
+		ArchitectureTypes:   []ec2types.ArchitectureType{ec2types.ArchitectureType(arch)},
+		VirtualizationTypes: []ec2types.VirtualizationType{ec2types.VirtualizationTypeHvm},
+		InstanceRequirements: &ec2types.InstanceRequirementsRequest{
+			VCpuCount: &ec2types.VCpuCountRangeRequest{Min: aws.Int32(cpus)},
+			MemoryMiB: &ec2types.MemoryMiBRequest{Min: aws.Int32(memoryGiB * 1024)},
+		},
+	}
+
+	var types []string
+	for {
+		out, err := client.GetInstanceTypesFromInstanceRequirements(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list instance types with %d vCPUs and %d GiB of memory: %w", cpus, memoryGiB, err)
+		}
+		for _, t := range out.InstanceTypes {
+			types = append(types, aws.ToString(t.InstanceType))
+		}
+		if aws.ToString(out.NextToken) == "" {
+			return types, nil
+		}
+		input.NextToken = out.NextToken
+	}
+}
+
+// SpotOffers returns the best current spot offer of each instance type of instanceTypes in one of
+// zones, or in any zone of the region when zones is empty, keeping their order. Instance types
+// without a spot offer are left out.
+func (m *ec2Machines) SpotOffers(ctx context.Context, instanceTypes, zones []string) ([]SpotOffer, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input := &ec2.DescribeSpotPriceHistoryInput{
+		ProductDescriptions: []string{spotProductDescription},
+		StartTime:           aws.Time(time.Now()),
+	}
+	for _, t := range instanceTypes {
+		input.InstanceTypes = append(input.InstanceTypes, ec2types.InstanceType(t))
+	}
+
+	best := map[string]SpotOffer{}
+	for {
+		out, err := client.DescribeSpotPriceHistory(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe spot prices: %w", err)
+		}
+		for _, price := range out.SpotPriceHistory {
+			zone := aws.ToString(price.AvailabilityZone)
+			if len(zones) > 0 && !slices.Contains(zones, zone) {
+				continue
+			}
+			hourly, err := strconv.ParseFloat(aws.ToString(price.SpotPrice), 64)
+			if err != nil {
+				continue
+			}
+			t := string(price.InstanceType)
+			if offer, ok := best[t]; !ok || hourly < offer.HourlyPrice {
+				best[t] = SpotOffer{InstanceType: t, AvailabilityZone: zone, HourlyPrice: hourly}
+			}
+		}
+		if aws.ToString(out.NextToken) == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+
+	var offers []SpotOffer
+	for _, t := range instanceTypes {
+		if offer, ok := best[t]; ok {
+			offers = append(offers, offer)
+		}
+	}
+	return offers, nil
+}
@@ -0,0 +1,23 @@
+package clusters
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("compute", func() {
+	DescribeTable("keeps the offered instance types in order of preference",
+		func(candidates []string, offers []SpotOffer, expected []string) {
+			Expect(offeredInstanceTypes(candidates, offers)).To(Equal(expected))
+		},
+		Entry("although a later one is cheaper",
+			[]string{"m7i.2xlarge", "m6i.2xlarge"},
+			[]SpotOffer{{InstanceType: "m6i.2xlarge", HourlyPrice: 0.1}, {InstanceType: "m7i.2xlarge", HourlyPrice: 0.2}},
+			[]string{"m7i.2xlarge", "m6i.2xlarge"}),
+		Entry("without the ones lacking an offer",
+			[]string{"m7i.2xlarge", "m6i.2xlarge", "m5.2xlarge"},
+			[]SpotOffer{{InstanceType: "m5.2xlarge"}, {InstanceType: "m6i.2xlarge"}, {InstanceType: "m5.2xlarge", AvailabilityZone: "us-east-1b"}},
+			[]string{"m6i.2xlarge", "m5.2xlarge"}),
+		Entry("without any offer", []string{"m7i.2xlarge"}, nil, []string{}),
+	)
+})
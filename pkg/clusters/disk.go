@@ -0,0 +1,125 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"k8s.io/utils/ptr"
+)
+
+// hoursPerMonth converts the monthly EBS prices to hourly ones.
+const hoursPerMonth = 730
+
+// EBS prices of us-east-1, in USD per month. gp3 volumes include 3000 IOPS and 125 MiB/s.
+const (
+	gp3GiBMonthPrice        = 0.08
+	gp3IOPSMonthPrice       = 0.005
+	gp3ThroughputMonthPrice = 0.04
+	gp3IncludedIOPS         = 3000
+	gp3IncludedThroughput   = 125
+	io2GiBMonthPrice        = 0.125
+)
+
+// io2IOPSTiers are the monthly prices of the provisioned IOPS of io2 volumes, by tier.
+var io2IOPSTiers = []struct {
+	upTo  int32
+	price float64
+}{
+	{32000, 0.065},
+	{64000, 0.0455},
+	{256000, 0.032},
+}
+
+// growRootScript grows the partition and the filesystem of the root volume of a host to the size
+// of the volume. On Openshift the root filesystem is mounted on /sysroot.
+const growRootScript = `set -eu
+mount=/
+if findmnt -n /sysroot >/dev/null 2>&1; then
+  mount=/sysroot
+  sudo mount -o remount,rw /sysroot
+fi
+source=$(findmnt -n -o SOURCE "$mount" | sed 's/\[.*\]$//')
+fstype=$(findmnt -n -o FSTYPE "$mount")
+disk=/dev/$(lsblk -n -o PKNAME "$source" | head -n 1)
+part=$(cat "/sys/class/block/$(basename "$source")/partition")
+# growpart exits with 1 when the partition already fills the disk.
+sudo growpart "$disk" "$part" >&2 || [ $? -eq 1 ]
+case "$fstype" in
+xfs) sudo xfs_growfs "$mount" >&2 ;;
+ext4) sudo resize2fs "$source" >&2 ;;
+btrfs) sudo btrfs filesystem resize max "$mount" >&2 ;;
+*) echo "unsupported root filesystem $fstype" >&2; exit 1 ;;
+esac
+`
+
+// ValidateDisk rejects root volume configurations EBS does not accept.
+func ValidateDisk(disk v1alpha1.DiskConfig) error {
+	iops := ptr.Deref(disk.IOPS, 0)
+	switch disk.Type {
+	case v1alpha1.VolumeTypeIO2:
+		switch {
+		case disk.IOPS == nil:
+			return fmt.Errorf("iops is required for io2 volumes")
+		case disk.Throughput != nil:
+			return fmt.Errorf("throughput only applies to gp3 volumes")
+		case iops > disk.SizeGiB*1000:
+			return fmt.Errorf("io2 volumes have at most 1000 IOPS per GiB, %d IOPS need at least %d GiB", iops, (iops+999)/1000)
+		}
+	default:
+		if disk.IOPS == nil {
+			iops = gp3IncludedIOPS
+		}
+		switch {
+		case iops < gp3IncludedIOPS || iops > 16000:
+			return fmt.Errorf("gp3 volumes have from 3000 to 16000 IOPS, not %d", iops)
+		case disk.IOPS != nil && iops > disk.SizeGiB*500:
+			return fmt.Errorf("gp3 volumes have at most 500 IOPS per GiB, %d IOPS need at least %d GiB", iops, (iops+499)/500)
+		case ptr.Deref(disk.Throughput, gp3IncludedThroughput)*4 > iops:
+			return fmt.Errorf("the throughput of gp3 volumes is at most a quarter of their IOPS, %d MiB/s need at least %d IOPS",
+				*disk.Throughput, *disk.Throughput*4)
+		}
+	}
+	return nil
+}
+
+// DiskHourlyPrice returns the hourly price, in USD, of the root volume configured by disk, or 0
+// when it is not configured.
+func DiskHourlyPrice(disk *v1alpha1.DiskConfig) float64 {
+	if disk == nil {
+		return 0
+	}
+	var monthly float64
+	if disk.Type == v1alpha1.VolumeTypeIO2 {
+		monthly = float64(disk.SizeGiB) * io2GiBMonthPrice
+		iops, from := ptr.Deref(disk.IOPS, 0), int32(0)
+		for _, tier := range io2IOPSTiers {
+			if iops <= from {
+				break
+			}
+			monthly += float64(min(iops, tier.upTo)-from) * tier.price
+			from = tier.upTo
+		}
+	} else {
+		monthly = float64(disk.SizeGiB)*gp3GiBMonthPrice +
+			float64(max(ptr.Deref(disk.IOPS, 0)-gp3IncludedIOPS, 0))*gp3IOPSMonthPrice +
+			float64(max(ptr.Deref(disk.Throughput, 0)-gp3IncludedThroughput, 0))*gp3ThroughputMonthPrice
+	}
+	return monthly / hoursPerMonth
+}
+
+// configureRootDisk modifies the root volume of the instance of a cluster as configured by disk
+// and, when the volume was grown, grows its partition and filesystem over SSH.
+func configureRootDisk(ctx context.Context, machines *ec2Machines, provisionID string, login hostLogin, disk v1alpha1.DiskConfig) error {
+	grown, err := machines.ModifyRootVolume(ctx, provisionID, disk)
+	if err != nil {
+		return err
+	}
+	if !grown {
+		return nil
+	}
+	if _, err := runHostScript(ctx, login, growRootScript); err != nil {
+		return fmt.Errorf("failed to grow the root filesystem of host %s: %w", login.Host, err)
+	}
+	return nil
+}
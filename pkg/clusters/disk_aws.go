@@ -0,0 +1,110 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+// volumePollInterval is how often a volume modification is checked until the volume can be used.
+const volumePollInterval = 5 * time.Second
+
+// ModifyRootVolume modifies the size, type, IOPS and throughput of the root volume of the instance
+// of a cluster to the ones of disk, and waits until the new size can be used. It reports whether
+// the volume was grown; volumes are never shrunk.
+func (m *ec2Machines) ModifyRootVolume(ctx context.Context, provisionID string, disk v1alpha1.DiskConfig) (bool, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return false, err
+	}
+	instance, err := findInstance(ctx, client, provisionID, "")
+	if err != nil {
+		return false, err
+	}
+	volumeID := rootVolumeID(instance)
+	if volumeID == "" {
+		return false, fmt.Errorf("instance %s has no root EBS volume", aws.ToString(instance.InstanceId))
+	}
+	out, err := client.DescribeVolumes(ctx, &ec2.DescribeVolumesInput{VolumeIds: []string{volumeID}})
+	if err != nil {
+		return false, fmt.Errorf("failed to describe root volume %s: %w", volumeID, err)
+	}
+	if len(out.Volumes) == 0 {
+		return false, fmt.Errorf("root volume %s not found", volumeID)
+	}
+	volume := out.Volumes[0]
+
+	input := &ec2.ModifyVolumeInput{VolumeId: aws.String(volumeID)}
+	grown := disk.SizeGiB > aws.ToInt32(volume.Size)
+	if grown {
+		input.Size = aws.Int32(disk.SizeGiB)
+	}
+	volumeType := ec2types.VolumeType(disk.Type)
+	if volumeType == "" {
+		volumeType = ec2types.VolumeTypeGp3
+	}
+	changed := grown
+	if volume.VolumeType != volumeType {
+		input.VolumeType = volumeType
+		changed = true
+	}
+	if disk.IOPS != nil && *disk.IOPS != aws.ToInt32(volume.Iops) {
+		input.Iops = disk.IOPS
+		changed = true
+	}
+	if disk.Throughput != nil && *disk.Throughput != aws.ToInt32(volume.Throughput) {
+		input.Throughput = disk.Throughput
+		changed = true
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if _, err := client.ModifyVolume(ctx, input); err != nil {
+		return false, fmt.Errorf("failed to modify root volume %s: %w", volumeID, err)
+	}
+	if err := waitForVolumeModification(ctx, client, volumeID); err != nil {
+		return false, err
+	}
+	return grown, nil
+}
+
+// rootVolumeID returns the ID of the EBS volume the instance boots from.
+func rootVolumeID(instance *ec2types.Instance) string {
+	for _, mapping := range instance.BlockDeviceMappings {
+		if aws.ToString(mapping.DeviceName) == aws.ToString(instance.RootDeviceName) && mapping.Ebs != nil {
+			return aws.ToString(mapping.Ebs.VolumeId)
+		}
+	}
+	return ""
+}
+
+// waitForVolumeModification blocks until the modification of a volume is optimizing, from when
+// the new size can be used, or completed.
+func waitForVolumeModification(ctx context.Context, client *ec2.Client, volumeID string) error {
+	ctx, cancel := context.WithTimeout(ctx, machineStateTimeout)
+	defer cancel()
+
+	for {
+		out, err := client.DescribeVolumesModifications(ctx, &ec2.DescribeVolumesModificationsInput{VolumeIds: []string{volumeID}})
+		if err != nil {
+			return fmt.Errorf("failed to describe the modification of volume %s: %w", volumeID, err)
+		}
+		for _, modification := range out.VolumesModifications {
+			switch modification.ModificationState {
+			case ec2types.VolumeModificationStateOptimizing, ec2types.VolumeModificationStateCompleted:
+				return nil
+			case ec2types.VolumeModificationStateFailed:
+				return fmt.Errorf("modification of volume %s failed: %s", volumeID, aws.ToString(modification.StatusMessage))
+			}
+		}
+		if err := sleep(ctx, volumePollInterval); err != nil {
+			return fmt.Errorf("modification of volume %s did not complete: %w", volumeID, err)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Reasons a provisioning attempt failed, recorded in status.provisioningAttempts.
+const (
+	// ReasonInsufficientCapacity reports that AWS had no capacity for the instance.
+	ReasonInsufficientCapacity = "InsufficientCapacity"
+	// ReasonPriceAboveMaximum reports that the best spot offer was above maxHourlyPrice.
+	ReasonPriceAboveMaximum = "PriceAboveMaximum"
+	// ReasonProvisioningFailed reports any other failure; no further strategy is tried.
+	ReasonProvisioningFailed = "ProvisioningFailed"
+)
+
+// capacityErrorCodes are the EC2 error codes reporting that AWS cannot fulfil an instance request
+// for lack of capacity, or not at the bid spot price.
+var capacityErrorCodes = []string{
+	"InsufficientInstanceCapacity",
+	"InsufficientHostCapacity",
+	"InsufficientReservedInstanceCapacity",
+	"MaxSpotInstanceCountExceeded",
+	"SpotMaxPriceTooLow",
+	"UnfulfillableCapacity",
+	"capacity-not-available",
+	"capacity-oversubscribed",
+}
+
+// OfferError is returned when no instance offer meets the requirements of a strategy. Nothing has
+// been provisioned then.
+type OfferError struct {
+	// Reason is ReasonInsufficientCapacity or ReasonPriceAboveMaximum.
+	Reason  string
+	Message string
+}
+
+func (e *OfferError) Error() string { return e.Message }
+
+// AttemptsError is returned when provisioning failed after trying one or more strategies. It wraps
+// the error of the last attempt.
+type AttemptsError struct {
+	Attempts []v1alpha1.ProvisioningAttempt
+	Err      error
+}
+
+func (e *AttemptsError) Error() string { return e.Err.Error() }
+
+func (e *AttemptsError) Unwrap() error { return e.Err }
+
+// FallbackChain returns the strategies tried to provision machine, in order.
+func FallbackChain(machine v1alpha1.MachineConfig) []v1alpha1.FallbackStrategy {
+	if len(machine.Fallback) > 0 {
+		return machine.Fallback
+	}
+	market := v1alpha1.InstanceMarketSpot
+	if !machine.UseSpotInstances {
+		market = v1alpha1.InstanceMarketOnDemand
+	}
+	return []v1alpha1.FallbackStrategy{{Market: market, Placement: v1alpha1.PlacementScopePreferred}}
+}
+
+// provisionWithFallback tries the strategies of chain in order until provision succeeds with one
+// of them. An attempt failing for lack of capacity or because of its price moves on to the next
+// strategy, once destroy removed what the attempt created; any other failure ends provisioning.
+// The attempts are returned with the result, or in an *AttemptsError.
+func provisionWithFallback[T any](ctx context.Context, chain []v1alpha1.FallbackStrategy,
+	provision func(strategy v1alpha1.FallbackStrategy) (T, error), destroy func() error) (T, []v1alpha1.ProvisioningAttempt, error) {
+	var zero T
+	var attempts []v1alpha1.ProvisioningAttempt
+	for i, strategy := range chain {
+		if strategy.Placement == "" {
+			strategy.Placement = v1alpha1.PlacementScopePreferred
+		}
+		attempt := v1alpha1.ProvisioningAttempt{Strategy: strategy, StartTime: metav1.Now()}
+		result, err := provision(strategy)
+		if err == nil {
+			attempt.Succeeded = true
+			return result, append(attempts, attempt), nil
+		}
+		attempt.Reason = failureReason(err)
+		attempt.Message = err.Error()
+		attempts = append(attempts, attempt)
+		if attempt.Reason == ReasonProvisioningFailed || i == len(chain)-1 || ctx.Err() != nil {
+			return zero, nil, &AttemptsError{Attempts: attempts, Err: err}
+		}
+
+		var offer *OfferError
+		if !errors.As(err, &offer) {
+			if err := destroy(); err != nil {
+				return zero, nil, &AttemptsError{Attempts: attempts,
+					Err: fmt.Errorf("failed to destroy the resources of the %s attempt: %w", strategy.Market, err)}
+			}
+		}
+		log.Log.WithName("fallback").Info("Provisioning attempt failed; trying the next fallback strategy.",
+			"market", strategy.Market, "placement", strategy.Placement, "reason", attempt.Reason, "next", chain[i+1])
+	}
+	return zero, nil, fmt.Errorf("no fallback strategy to provision the cluster with")
+}
+
+// failureReason classifies the error of a provisioning attempt.
+func failureReason(err error) string {
+	var offer *OfferError
+	if errors.As(err, &offer) {
+		return offer.Reason
+	}
+	for _, code := range capacityErrorCodes {
+		if strings.Contains(err.Error(), code) {
+			return ReasonInsufficientCapacity
+		}
+	}
+	return ReasonProvisioningFailed
+}
@@ -0,0 +1,96 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrHibernationNotSupported is returned when the provider of a cluster type cannot stop and
+// start the machine of its clusters.
+var ErrHibernationNotSupported = errors.New("hibernation is not supported")
+
+// ProvisionIDTag is the cloud resource tag holding the ProvisionId of the cluster the resource
+// belongs to. It lets the operator find the machine of a cluster to hibernate it.
+const ProvisionIDTag = "mapt-operator/provision-id"
+
+// Hibernator is implemented by provisioners able to stop the machine of a provisioned cluster and
+// start it again, keeping its disks.
+type Hibernator interface {
+	// Hibernate stops the machine of the cluster and returns its ID.
+	Hibernate(ctx context.Context, cluster *MaptCluster) (string, error)
+	// Resume starts the machine of the cluster and waits for its API server. It returns the address
+	// the cluster is reachable at, or an empty string when the address did not change.
+	Resume(ctx context.Context, cluster *MaptCluster) (string, error)
+}
+
+// TypedHibernator is implemented by the TypedProvider of cluster types supporting hibernation.
+type TypedHibernator[T client.Object] interface {
+	Hibernate(ctx context.Context, cluster T) (string, error)
+	Resume(ctx context.Context, cluster T) (string, error)
+}
+
+func (p *maptProvisioner) Hibernate(ctx context.Context, cluster *MaptCluster) (string, error) {
+	prov, ok := p.providers[cluster.Type]
+	if !ok {
+		return "", fmt.Errorf("unsupported cluster type: %s", cluster.Type)
+	}
+	return prov.Hibernate(ctx, cluster.Object)
+}
+
+func (p *maptProvisioner) Resume(ctx context.Context, cluster *MaptCluster) (string, error) {
+	prov, ok := p.providers[cluster.Type]
+	if !ok {
+		return "", fmt.Errorf("unsupported cluster type: %s", cluster.Type)
+	}
+	return prov.Resume(ctx, cluster.Object)
+}
+
+func (p *typedProvider[T, M]) Hibernate(ctx context.Context, obj client.Object) (string, error) {
+	h, cluster, err := p.hibernator(obj)
+	if err != nil {
+		return "", err
+	}
+	id, err := h.Hibernate(ctx, cluster)
+	if err != nil {
+		return "", fmt.Errorf("failed to hibernate %s cluster: %w", p.clusterType, err)
+	}
+	return id, nil
+}
+
+func (p *typedProvider[T, M]) Resume(ctx context.Context, obj client.Object) (string, error) {
+	h, cluster, err := p.hibernator(obj)
+	if err != nil {
+		return "", err
+	}
+	host, err := h.Resume(ctx, cluster)
+	if err != nil {
+		return "", fmt.Errorf("failed to resume %s cluster: %w", p.clusterType, err)
+	}
+	return host, nil
+}
+
+func (p *typedProvider[T, M]) hibernator(obj client.Object) (TypedHibernator[T], T, error) {
+	cluster, err := clusterObject[T](obj)
+	if err != nil {
+		return nil, cluster, err
+	}
+	h, ok := p.provider.(TypedHibernator[T])
+	if !ok {
+		return nil, cluster, fmt.Errorf("%w for %s clusters", ErrHibernationNotSupported, p.clusterType)
+	}
+	return h, cluster, nil
+}
+
+// provisionTags returns the tags of the cloud resources of a cluster: the tags requested in its
+// spec, and ProvisionIDTag.
+func provisionTags(tags map[string]string, provisionID string) map[string]string {
+	result := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		result[k] = v
+	}
+	result[ProvisionIDTag] = provisionID
+	return result
+}
@@ -0,0 +1,155 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	// machineStateTimeout bounds how long stopping or starting an instance, and the API server of
+	// a resumed cluster coming back, may take.
+	machineStateTimeout = 10 * time.Minute
+	// apiServerPollInterval is how often the API server of a resumed cluster is probed.
+	apiServerPollInterval = 5 * time.Second
+)
+
+// errInstanceNotFound is returned when a provision has no instance, e.g. while it is being created.
+var errInstanceNotFound = errors.New("no instance found")
+
+// ec2Machines stops and starts the EC2 instances of provisioned clusters. Instances are found by
+// the ID recorded when they were hibernated or, failing that, by ProvisionIDTag.
+type ec2Machines struct {
+	creds *ProvisionCloudCredentials
+}
+
+// Stop stops the instance of a cluster and returns its ID.
+func (m *ec2Machines) Stop(ctx context.Context, provisionID, instanceID string) (string, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	instance, err := findInstance(ctx, client, provisionID, instanceID)
+	if err != nil {
+		return "", err
+	}
+	id := aws.ToString(instance.InstanceId)
+	if instance.State != nil && instance.State.Name == ec2types.InstanceStateNameStopped {
+		return id, nil
+	}
+
+	if _, err := client.StopInstances(ctx, &ec2.StopInstancesInput{InstanceIds: []string{id}}); err != nil {
+		return "", fmt.Errorf("failed to stop instance %s: %w", id, err)
+	}
+	if err := ec2.NewInstanceStoppedWaiter(client).Wait(ctx,
+		&ec2.DescribeInstancesInput{InstanceIds: []string{id}}, machineStateTimeout); err != nil {
+		return "", fmt.Errorf("instance %s did not stop: %w", id, err)
+	}
+	return id, nil
+}
+
+// Start starts the instance of a cluster and waits for its API server. It returns the public
+// address of the instance.
+func (m *ec2Machines) Start(ctx context.Context, provisionID, instanceID string) (string, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return "", err
+	}
+	instance, err := findInstance(ctx, client, provisionID, instanceID)
+	if err != nil {
+		return "", err
+	}
+	id := aws.ToString(instance.InstanceId)
+
+	if _, err := client.StartInstances(ctx, &ec2.StartInstancesInput{InstanceIds: []string{id}}); err != nil {
+		return "", fmt.Errorf("failed to start instance %s: %w", id, err)
+	}
+	if err := ec2.NewInstanceRunningWaiter(client).Wait(ctx,
+		&ec2.DescribeInstancesInput{InstanceIds: []string{id}}, machineStateTimeout); err != nil {
+		return "", fmt.Errorf("instance %s did not start: %w", id, err)
+	}
+
+	// The public address of an instance usually changes across a stop and start.
+	instance, err = findInstance(ctx, client, provisionID, id)
+	if err != nil {
+		return "", err
+	}
+	host := aws.ToString(instance.PublicIpAddress)
+	if host == "" {
+		return "", fmt.Errorf("instance %s has no public address", id)
+	}
+	if err := waitForAPIServer(ctx, host); err != nil {
+		return "", err
+	}
+	return host, nil
+}
+
+func (m *ec2Machines) client(ctx context.Context) (*ec2.Client, error) {
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(m.creds.Region)}
+	if m.creds.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(m.creds.AccessKeyID, m.creds.SecretAccessKey, ""),
+		))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return ec2.NewFromConfig(cfg), nil
+}
+
+// findInstance returns the instance with the given ID or, when instanceID is empty, the instance
+// tagged with the ProvisionId of the cluster.
+func findInstance(ctx context.Context, client *ec2.Client, provisionID, instanceID string) (*ec2types.Instance, error) {
+	input := &ec2.DescribeInstancesInput{}
+	if instanceID != "" {
+		input.InstanceIds = []string{instanceID}
+	} else {
+		input.Filters = []ec2types.Filter{
+			{Name: aws.String("tag:" + ProvisionIDTag), Values: []string{provisionID}},
+			{Name: aws.String("instance-state-name"), Values: []string{
+				string(ec2types.InstanceStateNamePending), string(ec2types.InstanceStateNameRunning),
+				string(ec2types.InstanceStateNameStopping), string(ec2types.InstanceStateNameStopped),
+			}},
+		}
+	}
+
+	out, err := client.DescribeInstances(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances of provision %s: %w", provisionID, err)
+	}
+	for _, reservation := range out.Reservations {
+		for i := range reservation.Instances {
+			return &reservation.Instances[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w for provision %s", errInstanceNotFound, provisionID)
+}
+
+// waitForAPIServer blocks until the API server at host accepts connections.
+func waitForAPIServer(ctx context.Context, host string) error {
+	ctx, cancel := context.WithTimeout(ctx, machineStateTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(host, strconv.Itoa(int(apiServerPort)))
+	dialer := &net.Dialer{Timeout: apiServerPollInterval}
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		if err := sleep(ctx, apiServerPollInterval); err != nil {
+			return fmt.Errorf("API server at %s did not come back: %w", addr, err)
+		}
+	}
+}
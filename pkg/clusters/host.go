@@ -0,0 +1,151 @@
+package clusters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshDialTimeout bounds how long connecting to the host of a cluster may take.
+const sshDialTimeout = 30 * time.Second
+
+// Delimiters of the SSH host keys cloud-init prints on the console of an instance at boot.
+const (
+	hostKeysBegin = "-----BEGIN SSH HOST KEY KEYS-----"
+	hostKeysEnd   = "-----END SSH HOST KEY KEYS-----"
+)
+
+// hostLogin is how the host of a provisioned cluster is logged into over SSH.
+type hostLogin struct {
+	Host       string
+	Username   string
+	PrivateKey string
+	// HostKeys are the keys the host may present; connecting to a host presenting another key fails.
+	HostKeys []ssh.PublicKey
+}
+
+// pinHostKeys returns login with the host keys the instance of the cluster printed on its console,
+// which EC2 reports over its authenticated API rather than over the network being verified.
+func pinHostKeys(ctx context.Context, machines *ec2Machines, provisionID string, login hostLogin) (hostLogin, error) {
+	keys, err := machines.HostKeys(ctx, provisionID)
+	if err != nil {
+		return login, fmt.Errorf("failed to look up the SSH host keys of host %s: %w", login.Host, err)
+	}
+	login.HostKeys = keys
+	return login, nil
+}
+
+// verifyHostKey accepts key when it is one of the host keys of login.
+func (l hostLogin) verifyHostKey(_ string, _ net.Addr, key ssh.PublicKey) error {
+	for _, known := range l.HostKeys {
+		if bytes.Equal(known.Marshal(), key.Marshal()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %s presented an unknown %s host key %s", l.Host, key.Type(), ssh.FingerprintSHA256(key))
+}
+
+// consoleHostKeys returns the SSH host keys listed in the console output of an instance.
+func consoleHostKeys(output string) []ssh.PublicKey {
+	_, block, found := strings.Cut(output, hostKeysBegin)
+	if !found {
+		return nil
+	}
+	block, _, found = strings.Cut(block, hostKeysEnd)
+	if !found {
+		return nil
+	}
+	var keys []ssh.PublicKey
+	for _, line := range strings.Split(block, "\n") {
+		// Some images prefix the lines they print with a tag, which parses as key options.
+		if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(line))); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// configureHost applies the settings of machine the provisioning tool knows nothing about to the
+// host of a provisioned cluster, placed at placement: it configures the root disk and, when nested
+// virtualization is enabled, verifies that KVM is available.
+func configureHost(ctx context.Context, machines *ec2Machines, provisionID string, placement Placement, login hostLogin, machine v1alpha1.MachineConfig) error {
+	if machine.Disk == nil && !machine.NestedVirtualizationEnabled {
+		return nil
+	}
+	login, err := pinHostKeys(ctx, machines, provisionID, login)
+	if err != nil {
+		return err
+	}
+	if machine.Disk != nil {
+		if err := configureRootDisk(ctx, machines, provisionID, login, *machine.Disk); err != nil {
+			return fmt.Errorf("failed to configure the root disk: %w", err)
+		}
+	}
+	if machine.NestedVirtualizationEnabled {
+		return verifyKVM(ctx, login, placement.InstanceType)
+	}
+	return nil
+}
+
+// runHostScript runs script with sh on the host of login, passing it args, and returns what it
+// printed on its standard output. The error of a failed script ends with the last lines it printed
+// on its standard error.
+func runHostScript(ctx context.Context, login hostLogin, script string, args ...string) ([]byte, error) {
+	signer, err := ssh.ParsePrivateKey([]byte(login.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key of host %s: %w", login.Host, err)
+	}
+	addr := net.JoinHostPort(login.Host, "22")
+	dialer := net.Dialer{Timeout: sshDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to host %s: %w", login.Host, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            login.Username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: login.verifyHostKey,
+		Timeout:         sshDialTimeout,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open SSH connection to host %s: %w", login.Host, err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer stop()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SSH session to host %s: %w", login.Host, err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdin = strings.NewReader(script)
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run(strings.Join(append([]string{"sh -s --"}, args...), " ")); err != nil {
+		if ctx.Err() != nil {
+			return nil, context.Cause(ctx)
+		}
+		return nil, fmt.Errorf("%w: %s", err, lastLines(stderr.String(), 5))
+	}
+	return stdout.Bytes(), nil
+}
+
+// lastLines returns the last n lines of s, which is where commands report why they failed.
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,54 @@
+package clusters
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+var _ = Describe("host keys", func() {
+	// newHostKey returns a new host key and its authorized_keys line.
+	newHostKey := func() (ssh.PublicKey, string) {
+		public, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		key, err := ssh.NewPublicKey(public)
+		Expect(err).NotTo(HaveOccurred())
+		return key, string(ssh.MarshalAuthorizedKey(key))
+	}
+
+	It("reads the host keys printed on the console", func() {
+		key, line := newHostKey()
+		other, otherLine := newHostKey()
+		output := "[   12.3] cloud-init[812]: Cloud-init finished\r\n" +
+			"ec2: " + hostKeysBegin + "\r\n" +
+			"ec2: " + line + "\r\n" + otherLine + "\r\n" +
+			"ec2: " + hostKeysEnd + "\r\n"
+
+		keys := consoleHostKeys(output)
+		Expect(keys).To(HaveLen(2))
+		Expect(keys[0].Marshal()).To(Equal(key.Marshal()))
+		Expect(keys[1].Marshal()).To(Equal(other.Marshal()))
+	})
+
+	DescribeTable("reads no host key from an incomplete console",
+		func(output string) {
+			Expect(consoleHostKeys(output)).To(BeEmpty())
+		},
+		Entry("before cloud-init printed them", "[    0.0] Linux version 6.12\r\n"),
+		Entry("while cloud-init prints them", hostKeysBegin+"\r\n"),
+	)
+
+	It("accepts only the pinned host keys", func() {
+		key, _ := newHostKey()
+		other, _ := newHostKey()
+		login := hostLogin{Host: "198.51.100.7", HostKeys: []ssh.PublicKey{key}}
+
+		Expect(login.verifyHostKey("198.51.100.7:22", nil, key)).To(Succeed())
+		Expect(login.verifyHostKey("198.51.100.7:22", nil, other)).To(MatchError(
+			ContainSubstring("host 198.51.100.7 presented an unknown ssh-ed25519 host key")))
+		Expect(hostLogin{Host: "198.51.100.7"}.verifyHostKey("198.51.100.7:22", nil, key)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,53 @@
+package clusters
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// hostKeysTimeout bounds how long the console of an instance may take to list its host keys.
+	hostKeysTimeout = 5 * time.Minute
+	// hostKeysPollInterval is how often the console output of an instance is fetched until it
+	// lists its host keys.
+	hostKeysPollInterval = 10 * time.Second
+)
+
+// HostKeys returns the SSH host keys the instance of a cluster printed on its console at boot.
+// EC2 publishes the console output a few minutes after boot, so it is polled until it lists them.
+func (m *ec2Machines) HostKeys(ctx context.Context, provisionID string) ([]ssh.PublicKey, error) {
+	client, err := m.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := findInstance(ctx, client, provisionID, "")
+	if err != nil {
+		return nil, err
+	}
+	id := aws.ToString(instance.InstanceId)
+
+	ctx, cancel := context.WithTimeout(ctx, hostKeysTimeout)
+	defer cancel()
+	for {
+		out, err := client.GetConsoleOutput(ctx, &ec2.GetConsoleOutputInput{InstanceId: aws.String(id)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get console output of instance %s: %w", id, err)
+		}
+		output, err := base64.StdEncoding.DecodeString(aws.ToString(out.Output))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode console output of instance %s: %w", id, err)
+		}
+		if keys := consoleHostKeys(string(output)); len(keys) > 0 {
+			return keys, nil
+		}
+		if err := sleep(ctx, hostKeysPollInterval); err != nil {
+			return nil, fmt.Errorf("instance %s did not list its SSH host keys on its console: %w", id, err)
+		}
+	}
+}
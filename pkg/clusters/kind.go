@@ -1,26 +1,35 @@
 package clusters
 
 import (
+	gocontext "context"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/kindconfig"
 	"github.com/redhat-developer/mapt/pkg/manager/context"
-	instancetypes "github.com/redhat-developer/mapt/pkg/provider/api/compute-request"
 	"github.com/redhat-developer/mapt/pkg/provider/aws/action/kind"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-type KindProvisioner interface {
-	Provision(cluster *v1alpha1.Kind) (*KindMetadata, error)
-	Deprovision(cluster *v1alpha1.Kind) error
+func init() {
+	RegisterProvider(KindClusterType, "kind",
+		func(cfg ProviderConfig) (TypedProvider[*v1alpha1.Kind, *KindMetadata], error) {
+			return &kindClusterProvisioner{
+				CloudCredentials: cfg.CloudCredentials,
+				Workspaces:       cfg.Workspaces,
+				Machines:         &ec2Machines{creds: cfg.CloudCredentials},
+			}, nil
+		})
 }
 
 type kindClusterProvisioner struct {
 	CloudCredentials *ProvisionCloudCredentials
+	Workspaces       *WorkspaceManager
+	Machines         *ec2Machines
 }
 
-func (p *kindClusterProvisioner) Provision(cluster *v1alpha1.Kind) (*KindMetadata, error) {
+func (p *kindClusterProvisioner) Provision(ctx gocontext.Context, cluster *v1alpha1.Kind) (*KindMetadata, error) {
 	if cluster.Status.ProvisionId == nil || *cluster.Status.ProvisionId == "" {
 		return nil, fmt.Errorf("missing or empty Status.ProvisionId")
 	}
@@ -36,66 +45,193 @@ func (p *kindClusterProvisioner) Provision(cluster *v1alpha1.Kind) (*KindMetadat
 			supported,
 		)
 	}
+	kindConfig := cluster.Spec.KindClusterConfig
+	if err := kindconfig.Validate(kindConfig); err != nil {
+		return nil, err
+	}
+	machine := cluster.Spec.MachineConfig
+	if _, err := MaxHourlyPrice(machine); err != nil {
+		return nil, err
+	}
+	if machine.Disk != nil {
+		if err := ValidateDisk(*machine.Disk); err != nil {
+			return nil, err
+		}
+	}
+	if cluster.Spec.Network != nil {
+		if err := ValidateNetwork(*cluster.Spec.Network); err != nil {
+			return nil, err
+		}
+	}
 
 	provisionID := *cluster.Status.ProvisionId
-	if err := os.Mkdir(filepath.Join(".", provisionID), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create provision directory: %w", err)
+	if err := waitIdle(ctx, KindClusterType, provisionID); err != nil {
+		return nil, err
+	}
+	ws, err := p.Workspaces.Acquire(KindClusterType, provisionID, WorkspaceProvision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare provision workspace: %w", err)
+	}
+
+	backedURL, err := p.buildBackendURL(cluster)
+	if err != nil {
+		ws.cleanup()
+		return nil, err
 	}
 
 	ctxArgs := &context.ContextArgs{
 		ProjectName:           cluster.Name,
-		BackedURL:             p.buildBackendURL(provisionID),
-		SpotPriceIncreaseRate: *cluster.Spec.MachineConfig.SpotPriceIncreasePercentage,
-		Tags:                  cluster.Spec.MachineConfig.Tags,
+		BackedURL:             backedURL,
+		ResultsOutput:         ws.Dir,
+		SpotPriceIncreaseRate: *machine.SpotPriceIncreasePercentage,
+		Tags:                  provisionTags(machine.Tags, *cluster.Status.ProvisionId),
 		ForceDestroy:          true,
 	}
 
-	kindArgs := &kind.KindArgs{
-		Prefix:         cluster.Name,
-		Arch:           cluster.Spec.MachineConfig.Architecture,
-		ComputeRequest: p.buildComputeRequest(cluster),
-		Version:        cluster.Spec.KindClusterConfig.KubernetesVersion,
-		Spot:           true,
+	create := func(strategy v1alpha1.FallbackStrategy) (*KindMetadata, error) {
+		compute, err := computeRequest(ctx, p.Machines, machine, cluster.Spec.CloudConfig, strategy)
+		if err != nil {
+			return nil, err
+		}
+		restricted := restrictIngressOnLaunch(ctx, p.Machines, provisionID, cluster.Spec.Network)
+		kindMetadataResults, err := kind.Create(ctxArgs, &kind.KindArgs{
+			Prefix:         cluster.Name,
+			Arch:           machine.Architecture,
+			ComputeRequest: compute,
+			Version:        cluster.Spec.KindClusterConfig.KubernetesVersion,
+			Spot:           strategy.Market == v1alpha1.InstanceMarketSpot,
+		})
+		if err != nil {
+			_ = restricted()
+			return nil, fmt.Errorf("failed to create kind cluster: %w", err)
+		}
+		if err := restricted(); err != nil {
+			return nil, err
+		}
+		return &KindMetadata{
+			Username:   kindMetadataResults.Username,
+			PrivateKey: kindMetadataResults.PrivateKey,
+			Host:       kindMetadataResults.Host,
+			Kubeconfig: kindMetadataResults.Kubeconfig,
+			// mapt reports no price for on-demand instances.
+			SpotPrice: ptr.Deref(kindMetadataResults.SpotPrice, 0),
+		}, nil
+	}
+
+	return runCancellable(ctx, KindClusterType, provisionID, func() (*KindMetadata, error) {
+		defer ws.cleanup()
+		meta, attempts, err := provisionWithFallback(ctx, FallbackChain(machine), create, func() error {
+			return kind.Destroy(ctxArgs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		meta.Attempts = attempts
+		meta.Placement = p.placement(ctx, provisionID)
+		if err := configureHost(ctx, p.Machines, provisionID, meta.Placement, meta.login(), machine); err != nil {
+			return nil, err
+		}
+		// mapt only creates single node clusters with its default configuration and no GPU
+		// support; any other cluster is applied by recreating the cluster on its host.
+		gpu := cluster.Spec.GPUStack != nil
+		if kindconfig.Customized(kindConfig) || gpu {
+			config, err := kindconfig.Render(kindConfig, meta.Host, apiServerPort, gpu)
+			if err != nil {
+				return nil, err
+			}
+			if err := recreateKindCluster(ctx, p.Machines, provisionID, meta, config, gpu); err != nil {
+				return nil, fmt.Errorf("failed to apply kind cluster configuration: %w", err)
+			}
+		}
+		if err := configureNetwork(ctx, p.Machines, provisionID, cluster.Spec.Network, meta.Host); err != nil {
+			return nil, err
+		}
+		return meta, nil
+	})
+}
+
+func (p *kindClusterProvisioner) Deprovision(ctx gocontext.Context, cluster *v1alpha1.Kind) error {
+	provisionID := *cluster.Status.ProvisionId
+	// A provisioning run abandoned after a timeout still owns the stack; destroying it concurrently
+	// would corrupt its state.
+	if err := waitIdle(ctx, KindClusterType, provisionID); err != nil {
+		return err
+	}
+	ws, err := p.Workspaces.Acquire(KindClusterType, provisionID, WorkspaceDeprovision)
+	if err != nil {
+		return fmt.Errorf("failed to prepare deprovision workspace: %w", err)
 	}
 
-	kindMetadataResults, err := kind.Create(ctxArgs, kindArgs)
+	backend, err := p.stateBackend(cluster)
+	if err != nil {
+		ws.cleanup()
+		return err
+	}
+	backedURL, err := backend.URL(KindClusterType, provisionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create kind cluster: %w", err)
+		ws.cleanup()
+		return err
 	}
 
-	return &KindMetadata{
-		Username:   kindMetadataResults.Username,
-		PrivateKey: kindMetadataResults.PrivateKey,
-		Host:       kindMetadataResults.Host,
-		Kubeconfig: kindMetadataResults.Kubeconfig,
-		SpotPrice:  *kindMetadataResults.SpotPrice,
-	}, nil
-}
+	_, err = runCancellable(ctx, KindClusterType, provisionID, func() (struct{}, error) {
+		defer ws.cleanup()
+		if err := kind.Destroy(&context.ContextArgs{
+			ProjectName:           cluster.Name,
+			BackedURL:             backedURL,
+			ResultsOutput:         ws.Dir,
+			SpotPriceIncreaseRate: *cluster.Spec.MachineConfig.SpotPriceIncreasePercentage,
+			ForceDestroy:          true,
+		}); err != nil {
+			return struct{}{}, err
+		}
 
-func (p *kindClusterProvisioner) Deprovision(cluster *v1alpha1.Kind) error {
-	return kind.Destroy(&context.ContextArgs{
-		ProjectName:           cluster.Name,
-		BackedURL:             p.buildBackendURL(*cluster.Status.ProvisionId),
-		SpotPriceIncreaseRate: *cluster.Spec.MachineConfig.SpotPriceIncreasePercentage,
-		ForceDestroy:          true,
+		// The cluster is gone at this point; leftover state only wastes storage, so do not fail on it.
+		if err := backend.Cleanup(gocontext.WithoutCancel(ctx), KindClusterType, provisionID); err != nil {
+			log.Log.WithName("kind").Error(err, "Failed to clean up cluster state", "url", backedURL)
+		}
+		return struct{}{}, nil
 	})
+	return err
 }
 
-func (p *kindClusterProvisioner) buildBackendURL(provisionID string) string {
-	return fmt.Sprintf("s3://%s/mapt/kind/%s", p.CloudCredentials.S3BucketName, provisionID)
+// Hibernate stops the instance of the cluster, keeping its disks.
+func (p *kindClusterProvisioner) Hibernate(ctx gocontext.Context, cluster *v1alpha1.Kind) (string, error) {
+	provisionID := *cluster.Status.ProvisionId
+	if err := waitIdle(ctx, KindClusterType, provisionID); err != nil {
+		return "", err
+	}
+	return p.Machines.Stop(ctx, provisionID, ptr.Deref(cluster.Status.AWSInstanceID, ""))
 }
 
-func (p *kindClusterProvisioner) buildComputeRequest(cluster *v1alpha1.Kind) *instancetypes.ComputeRequestArgs {
-	if cluster.Spec.MachineConfig.GPU {
-		return &instancetypes.ComputeRequestArgs{
-			ComputeSizes: SupportedAwsGPUsInstances,
-			Arch:         instancetypes.Amd64,
-		}
+// Resume starts the instance of a hibernated cluster and waits for its API server.
+func (p *kindClusterProvisioner) Resume(ctx gocontext.Context, cluster *v1alpha1.Kind) (string, error) {
+	return p.Machines.Start(ctx, *cluster.Status.ProvisionId, ptr.Deref(cluster.Status.AWSInstanceID, ""))
+}
+
+// stateBackend returns the backend recorded when provisioning started, or the requested one
+// for clusters provisioned before backends were recorded.
+func (p *kindClusterProvisioner) stateBackend(cluster *v1alpha1.Kind) (StateBackend, error) {
+	cfg := cluster.Status.StateBackend
+	if cfg == nil {
+		cfg = ResolveStateBackend(cluster.Spec.StateBackend)
 	}
+	return NewStateBackend(cfg, p.CloudCredentials)
+}
 
-	return &instancetypes.ComputeRequestArgs{
-		CPUs:      cluster.Spec.MachineConfig.CPUs,
-		MemoryGib: cluster.Spec.MachineConfig.MemoryGiB,
-		Arch:      instancetypes.Amd64,
+func (p *kindClusterProvisioner) buildBackendURL(cluster *v1alpha1.Kind) (string, error) {
+	backend, err := p.stateBackend(cluster)
+	if err != nil {
+		return "", err
+	}
+	return backend.URL(KindClusterType, *cluster.Status.ProvisionId)
+}
+
+// placement returns where mapt placed the instance of the cluster. Failing to look it up does not
+// fail the provisioning; the placement is then not reported.
+func (p *kindClusterProvisioner) placement(ctx gocontext.Context, provisionID string) Placement {
+	placement, err := p.Machines.Placement(ctx, provisionID)
+	if err != nil {
+		log.Log.WithName("kind").Error(err, "Failed to look up the placement of the cluster", "provisionId", provisionID)
 	}
+	return placement
 }
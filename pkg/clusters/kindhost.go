@@ -0,0 +1,128 @@
+package clusters
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// recreateKindScript recreates the Kind cluster mapt created on its host with the configuration
+// passed, base64 encoded, as its first argument, keeping the node image mapt selected. It prints
+// the kubeconfig of the new cluster. When its second argument is gpu, the NVIDIA driver and
+// container toolkit are installed on the host first, and the nodes the configuration hands GPUs to
+// get the container toolkit configured as the default runtime of containerd. Repository keys and
+// definitions are downloaded to files and then installed, so nothing fetched runs as root.
+const recreateKindScript = `set -eu
+work=$(mktemp -d)
+trap 'rm -rf "$work"' EXIT
+echo "$1" | base64 -d > "$work/kind.yaml"
+gpu=${2:-}
+name=$(kind get clusters | head -n 1)
+image=$(docker inspect --format '{{.Config.Image}}' "$name-control-plane")
+
+toolkit=https://nvidia.github.io/libnvidia-container
+keyring=/usr/share/keyrings/nvidia-container-toolkit-keyring.gpg
+if [ "$gpu" = gpu ]; then
+  . /etc/os-release
+  arch=$(uname -m)
+  curl -fsSL -o "$work/toolkit.key" "$toolkit/gpgkey"
+  gpg --batch --yes --dearmor -o "$work/toolkit.gpg" "$work/toolkit.key"
+  curl -fsSL -o "$work/toolkit.list" "$toolkit/stable/deb/nvidia-container-toolkit.list"
+  sed -i "s#deb https://#deb [signed-by=$keyring] https://#" "$work/toolkit.list"
+  chmod 0644 "$work/toolkit.gpg" "$work/toolkit.list"
+  if [ "$ID" = ubuntu ] || [ "$ID" = debian ]; then
+    if ! nvidia-smi >/dev/null 2>&1; then
+      [ "$arch" = aarch64 ] && arch=sbsa
+      curl -fsSL -o "$work/cuda-keyring.deb" "https://developer.download.nvidia.com/compute/cuda/repos/$ID$(echo "$VERSION_ID" | tr -d .)/$arch/cuda-keyring_1.1-1_all.deb"
+      sudo dpkg -i "$work/cuda-keyring.deb" >&2
+      sudo apt-get update >&2
+      sudo apt-get install -y "linux-headers-$(uname -r)" nvidia-open >&2
+    fi
+    sudo install -m 0644 "$work/toolkit.gpg" "$keyring"
+    sudo install -m 0644 "$work/toolkit.list" /etc/apt/sources.list.d/nvidia-container-toolkit.list
+    sudo apt-get update >&2
+    sudo apt-get install -y nvidia-container-toolkit >&2
+  else
+    if ! nvidia-smi >/dev/null 2>&1; then
+      [ "$ID" = fedora ] || VERSION_ID=${VERSION_ID%%.*}
+      [ "$ID" = fedora ] || [ "$arch" = x86_64 ] || arch=sbsa
+      curl -fsSL -o "$work/cuda.repo" "https://developer.download.nvidia.com/compute/cuda/repos/$ID$VERSION_ID/$arch/cuda-$ID$VERSION_ID.repo"
+      sudo install -m 0644 "$work/cuda.repo" /etc/yum.repos.d/cuda.repo
+      sudo dnf install -y "kernel-devel-$(uname -r)" nvidia-open >&2
+    fi
+    curl -fsSL -o "$work/toolkit.repo" "$toolkit/stable/rpm/nvidia-container-toolkit.repo"
+    sudo install -m 0644 "$work/toolkit.repo" /etc/yum.repos.d/nvidia-container-toolkit.repo
+    sudo dnf install -y nvidia-container-toolkit >&2
+  fi
+  sudo modprobe nvidia
+  nvidia-smi >&2
+  sudo nvidia-ctk runtime configure --runtime=docker --set-as-default >&2
+  sudo nvidia-ctk config --set accept-nvidia-visible-devices-as-volume-mounts=true --in-place
+  sudo systemctl restart docker
+fi
+
+kind delete cluster --name "$name" >&2
+kind create cluster --name "$name" --image "$image" --config "$work/kind.yaml" --wait 5m >&2
+
+if [ "$gpu" = gpu ]; then
+  for node in $(kind get nodes --name "$name"); do
+    docker inspect --format '{{range .Mounts}}{{.Destination}} {{end}}' "$node" | grep -q nvidia-container-devices || continue
+    docker exec "$node" umount -R /proc/driver/nvidia || true
+    docker cp "$work/toolkit.gpg" "$node:$keyring"
+    docker cp "$work/toolkit.list" "$node:/etc/apt/sources.list.d/nvidia-container-toolkit.list"
+    docker exec "$node" sh -c "apt-get update && apt-get install -y nvidia-container-toolkit" >&2
+    docker exec "$node" nvidia-ctk runtime configure --runtime=containerd --set-as-default >&2
+    docker exec "$node" systemctl restart containerd
+  done
+fi
+kind get kubeconfig --name "$name"
+`
+
+// recreateKindCluster replaces the Kind cluster on the host of meta with one created from the
+// given kind configuration, and updates the kubeconfig of meta accordingly. With gpu, the host and
+// the nodes are set up to run GPU workloads first.
+//
+// mapt creates the Kind cluster itself, with its default configuration, and takes no kind
+// configuration to create it with, so any other cluster costs a second cluster creation.
+func recreateKindCluster(ctx context.Context, machines *ec2Machines, provisionID string, meta *KindMetadata, config []byte, gpu bool) error {
+	login, err := pinHostKeys(ctx, machines, provisionID, meta.login())
+	if err != nil {
+		return err
+	}
+	args := []string{base64.StdEncoding.EncodeToString(config)}
+	if gpu {
+		args = append(args, "gpu")
+	}
+	out, err := runHostScript(ctx, login, recreateKindScript, args...)
+	if err != nil {
+		return fmt.Errorf("failed to recreate kind cluster on host %s: %w", meta.Host, err)
+	}
+
+	kubeconfig, err := publishKubeconfig(out, meta.Host)
+	if err != nil {
+		return err
+	}
+	meta.Kubeconfig = kubeconfig
+	return nil
+}
+
+// publishKubeconfig points the kubeconfig kind reports on the host at the API server published
+// on the public address of the host.
+func publishKubeconfig(data []byte, host string) (string, error) {
+	cfg, err := clientcmd.Load(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse kubeconfig of kind cluster: %w", err)
+	}
+	for _, cluster := range cfg.Clusters {
+		cluster.Server = "https://" + net.JoinHostPort(host, strconv.Itoa(int(apiServerPort)))
+	}
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize kubeconfig of kind cluster: %w", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,107 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+// sshIngressPort is the port of the instance of a cluster, besides apiServerPort, whose ingress
+// spec.network restricts.
+const sshIngressPort int32 = 22
+
+// ValidateNetwork rejects allowed CIDRs that are not IPv4 or IPv6 ranges, have host bits set or
+// are listed twice.
+func ValidateNetwork(network v1alpha1.NetworkConfig) error {
+	for i, cidr := range network.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return fmt.Errorf("allowedCIDRs: %q is not a CIDR range", cidr)
+		}
+		if prefix.Masked() != prefix {
+			return fmt.Errorf("allowedCIDRs: %s has host bits set, the range is %s", cidr, prefix.Masked())
+		}
+		if slices.Contains(network.AllowedCIDRs[:i], cidr) {
+			return fmt.Errorf("allowedCIDRs: %s is listed twice", cidr)
+		}
+	}
+	return nil
+}
+
+// launchPollInterval is how often the instance of a cluster being provisioned is looked up until
+// it is launched.
+const launchPollInterval = 5 * time.Second
+
+// restrictIngressOnLaunch opens the API server and SSH of the instance of a cluster being
+// provisioned to the allowed CIDRs of network only, as soon as the instance is launched and long
+// before either is up, so that they are never open to any address. SSH is only closed by
+// configureNetwork, once nothing needs to log into the host anymore. The returned function stops
+// waiting for the instance and reports whether restricting its ingress failed; it must be called
+// once provisioning returns.
+func restrictIngressOnLaunch(ctx context.Context, machines *ec2Machines, provisionID string, network *v1alpha1.NetworkConfig) func() error {
+	if network == nil || len(network.AllowedCIDRs) == 0 {
+		return func() error { return nil }
+	}
+	rules := ingressRules(v1alpha1.NetworkConfig{AllowedCIDRs: network.AllowedCIDRs})
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			err := machines.RestrictIngress(ctx, provisionID, rules)
+			if !errors.Is(err, errInstanceNotFound) {
+				done <- err
+				return
+			}
+			// Provisioning returned before launching an instance; there is nothing to restrict.
+			if sleep(ctx, launchPollInterval) != nil {
+				done <- nil
+				return
+			}
+		}
+	}()
+	return func() error {
+		cancel()
+		if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+			return fmt.Errorf("failed to restrict the ingress of the cluster once launched: %w", err)
+		}
+		return nil
+	}
+}
+
+// configureNetwork restricts how the instance of a provisioned cluster can be reached as network
+// requires, once nothing else needs to log into its host; restrictIngressOnLaunch usually applied
+// the allowed CIDRs already. It fails when the API server cannot be reached anymore, since the
+// operator would not be able to manage the cluster.
+func configureNetwork(ctx context.Context, machines *ec2Machines, provisionID string, network *v1alpha1.NetworkConfig, host string) error {
+	if network == nil {
+		return nil
+	}
+	if rules := ingressRules(*network); len(rules) > 0 {
+		if err := machines.RestrictIngress(ctx, provisionID, rules); err != nil {
+			return fmt.Errorf("failed to restrict the ingress of the cluster: %w", err)
+		}
+	}
+	if err := waitForAPIServer(ctx, host); err != nil {
+		return fmt.Errorf("the API server of the cluster is not reachable from the operator once its network is restricted: %w", err)
+	}
+	return nil
+}
+
+// ingressRules returns the CIDRs each port restricted by network is opened to; a port opened to
+// no CIDR is closed.
+func ingressRules(network v1alpha1.NetworkConfig) map[int32][]string {
+	rules := map[int32][]string{}
+	if len(network.AllowedCIDRs) > 0 {
+		rules[apiServerPort] = network.AllowedCIDRs
+		rules[sshIngressPort] = network.AllowedCIDRs
+	}
+	if network.DisableSSH {
+		rules[sshIngressPort] = nil
+	}
+	return rules
+}
@@ -0,0 +1,153 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/netip"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// ingressRuleDescription marks the ingress rules added by the operator.
+const ingressRuleDescription = "mapt-operator spec.network"
+
+// RestrictIngress replaces the rules of the security groups of the instance of a cluster opening
+// the ports of rules to IP ranges with rules opening each port to its CIDRs only. Rules opening
+// the ports to other security groups are kept. The new rules are authorized before the others are
+// revoked, so the allowed ranges keep their access while the rules are replaced. It fails when a
+// rule opening a range of ports, or all traffic, to IP ranges includes one of the ports, since it
+// cannot be narrowed without closing the other ports.
+func (m *ec2Machines) RestrictIngress(ctx context.Context, provisionID string, rules map[int32][]string) error {
+	client, err := m.client(ctx)
+	if err != nil {
+		return err
+	}
+	instance, err := findInstance(ctx, client, provisionID, "")
+	if err != nil {
+		return err
+	}
+	var groupIDs []string
+	for _, group := range instance.SecurityGroups {
+		groupIDs = append(groupIDs, aws.ToString(group.GroupId))
+	}
+	if len(groupIDs) == 0 {
+		return fmt.Errorf("instance %s has no security group", aws.ToString(instance.InstanceId))
+	}
+	out, err := client.DescribeSecurityGroups(ctx, &ec2.DescribeSecurityGroupsInput{GroupIds: groupIDs})
+	if err != nil {
+		return fmt.Errorf("failed to describe the security groups of instance %s: %w", aws.ToString(instance.InstanceId), err)
+	}
+
+	// The ports are opened to their CIDRs in the first security group, keeping the rules it
+	// already has for them.
+	ports := slices.Sorted(maps.Keys(rules))
+	opened := map[int32][]string{}
+	revoke := map[string][]ec2types.IpPermission{}
+	for _, group := range out.SecurityGroups {
+		groupID := aws.ToString(group.GroupId)
+		for _, permission := range group.IpPermissions {
+			if len(permission.IpRanges) == 0 && len(permission.Ipv6Ranges) == 0 && len(permission.PrefixListIds) == 0 {
+				continue
+			}
+			for _, port := range ports {
+				if !opensPort(permission, port) {
+					continue
+				}
+				if aws.ToInt32(permission.FromPort) != port || aws.ToInt32(permission.ToPort) != port {
+					return fmt.Errorf("security group %s opens port %d through a rule that cannot be narrowed: %s",
+						groupID, port, describePermission(permission))
+				}
+				keep := func(cidr string) bool {
+					if groupID != groupIDs[0] || !slices.Contains(rules[port], cidr) {
+						return false
+					}
+					opened[port] = append(opened[port], cidr)
+					return true
+				}
+				stale := ec2types.IpPermission{
+					IpProtocol:    permission.IpProtocol,
+					FromPort:      permission.FromPort,
+					ToPort:        permission.ToPort,
+					PrefixListIds: permission.PrefixListIds,
+				}
+				for _, r := range permission.IpRanges {
+					if !keep(aws.ToString(r.CidrIp)) {
+						stale.IpRanges = append(stale.IpRanges, r)
+					}
+				}
+				for _, r := range permission.Ipv6Ranges {
+					if !keep(aws.ToString(r.CidrIpv6)) {
+						stale.Ipv6Ranges = append(stale.Ipv6Ranges, r)
+					}
+				}
+				if len(stale.IpRanges) > 0 || len(stale.Ipv6Ranges) > 0 || len(stale.PrefixListIds) > 0 {
+					revoke[groupID] = append(revoke[groupID], stale)
+				}
+			}
+		}
+	}
+
+	var authorize []ec2types.IpPermission
+	for _, port := range ports {
+		permission := ec2types.IpPermission{IpProtocol: aws.String("tcp"), FromPort: aws.Int32(port), ToPort: aws.Int32(port)}
+		for _, cidr := range rules[port] {
+			if slices.Contains(opened[port], cidr) {
+				continue
+			}
+			if netip.MustParsePrefix(cidr).Addr().Is4() {
+				permission.IpRanges = append(permission.IpRanges,
+					ec2types.IpRange{CidrIp: aws.String(cidr), Description: aws.String(ingressRuleDescription)})
+			} else {
+				permission.Ipv6Ranges = append(permission.Ipv6Ranges,
+					ec2types.Ipv6Range{CidrIpv6: aws.String(cidr), Description: aws.String(ingressRuleDescription)})
+			}
+		}
+		if len(permission.IpRanges) > 0 || len(permission.Ipv6Ranges) > 0 {
+			authorize = append(authorize, permission)
+		}
+	}
+	if len(authorize) > 0 {
+		if _, err := client.AuthorizeSecurityGroupIngress(ctx, &ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupIDs[0]),
+			IpPermissions: authorize,
+		}); err != nil {
+			return fmt.Errorf("failed to authorize the ingress rules of security group %s: %w", groupIDs[0], err)
+		}
+	}
+
+	for _, groupID := range groupIDs {
+		if len(revoke[groupID]) == 0 {
+			continue
+		}
+		if _, err := client.RevokeSecurityGroupIngress(ctx, &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: revoke[groupID],
+		}); err != nil {
+			return fmt.Errorf("failed to revoke the ingress rules of security group %s: %w", groupID, err)
+		}
+	}
+	return nil
+}
+
+// opensPort reports whether permission opens the TCP port.
+func opensPort(permission ec2types.IpPermission, port int32) bool {
+	switch aws.ToString(permission.IpProtocol) {
+	case "-1":
+		return true
+	case "tcp", "6":
+		return aws.ToInt32(permission.FromPort) <= port && port <= aws.ToInt32(permission.ToPort)
+	}
+	return false
+}
+
+// describePermission formats the protocol and ports of permission for error messages.
+func describePermission(permission ec2types.IpPermission) string {
+	if aws.ToString(permission.IpProtocol) == "-1" {
+		return "all traffic"
+	}
+	return fmt.Sprintf("%s ports %d-%d", aws.ToString(permission.IpProtocol), aws.ToInt32(permission.FromPort), aws.ToInt32(permission.ToPort))
+}
@@ -0,0 +1,31 @@
+package clusters
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+var _ = Describe("network", func() {
+	allowed := []string{"203.0.113.0/24", "2001:db8::/32"}
+
+	DescribeTable("derives the ingress rules of a cluster",
+		func(network v1alpha1.NetworkConfig, expected map[int32][]string) {
+			Expect(ingressRules(network)).To(Equal(expected))
+		},
+		Entry("leaving every port open without restriction", v1alpha1.NetworkConfig{}, map[int32][]string{}),
+		Entry("opening the API server and SSH to the allowed CIDRs",
+			v1alpha1.NetworkConfig{AllowedCIDRs: allowed}, map[int32][]string{apiServerPort: allowed, sshIngressPort: allowed}),
+		Entry("closing SSH", v1alpha1.NetworkConfig{AllowedCIDRs: allowed, DisableSSH: true},
+			map[int32][]string{apiServerPort: allowed, sshIngressPort: nil}),
+		Entry("closing SSH only", v1alpha1.NetworkConfig{DisableSSH: true}, map[int32][]string{sshIngressPort: nil}),
+	)
+
+	It("does not wait for the instance of a cluster without allowed CIDRs", func() {
+		restricted := restrictIngressOnLaunch(context.Background(), nil, "kind-1", &v1alpha1.NetworkConfig{DisableSSH: true})
+		Expect(restricted()).To(Succeed())
+	})
+})
@@ -1,6 +1,7 @@
 package clusters
 
 import (
+	gocontext "context"
 	"fmt"
 	"os"
 
@@ -8,22 +9,32 @@ import (
 	"github.com/redhat-developer/mapt/pkg/manager/context"
 	instancetypes "github.com/redhat-developer/mapt/pkg/provider/api/compute-request"
 	openshiftsnc "github.com/redhat-developer/mapt/pkg/provider/aws/action/openshift-snc"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
 var OpenshiftSNCSupportedVersions = []string{
 	"4.19.0",
 }
 
-type OpenshiftProvisioner interface {
-	Provision(cluster *v1alpha1.Openshift) (*OpenshiftMetadata, error)
-	Deprovision(cluster *v1alpha1.Openshift) error
+func init() {
+	RegisterProvider(OpenshiftClusterType, "openshift-snc",
+		func(cfg ProviderConfig) (TypedProvider[*v1alpha1.Openshift, *OpenshiftMetadata], error) {
+			return &openshiftSncProvisioner{
+				CloudCredentials: cfg.CloudCredentials,
+				Workspaces:       cfg.Workspaces,
+				Machines:         &ec2Machines{creds: cfg.CloudCredentials},
+			}, nil
+		})
 }
 
 type openshiftSncProvisioner struct {
 	CloudCredentials *ProvisionCloudCredentials
+	Workspaces       *WorkspaceManager
+	Machines         *ec2Machines
 }
 
-func (p *openshiftSncProvisioner) Provision(cluster *v1alpha1.Openshift) (*OpenshiftMetadata, error) {
+func (p *openshiftSncProvisioner) Provision(ctx gocontext.Context, cluster *v1alpha1.Openshift) (*OpenshiftMetadata, error) {
 	if !isSupportedOpenshiftVersion(cluster.Spec.OpenshiftClusterConfig.OpenshiftVersion) {
 		return nil, fmt.Errorf("unsupported OpenShift version: %s (supported: %v)", cluster.Spec.OpenshiftClusterConfig.OpenshiftVersion, OpenshiftSNCSupportedVersions)
 	}
@@ -31,76 +42,194 @@ func (p *openshiftSncProvisioner) Provision(cluster *v1alpha1.Openshift) (*Opens
 	if err := validateProvisionInput(cluster); err != nil {
 		return nil, err
 	}
+	if _, err := MaxHourlyPrice(cluster.Spec.MachineConfig); err != nil {
+		return nil, err
+	}
+	if disk := cluster.Spec.MachineConfig.Disk; disk != nil {
+		if err := ValidateDisk(*disk); err != nil {
+			return nil, err
+		}
+	}
+	if network := cluster.Spec.Network; network != nil {
+		if err := ValidateNetwork(*network); err != nil {
+			return nil, err
+		}
+	}
 
 	pullSecretFile, err := getValidatedPullSecretFile()
 	if err != nil {
 		return nil, err
 	}
 
-	ctxArgs := p.buildContextArgs(cluster)
-	sncArgs := p.buildSNCArgs(cluster, pullSecretFile)
+	provisionID := *cluster.Status.ProvisionId
+	if err := waitIdle(ctx, OpenshiftClusterType, provisionID); err != nil {
+		return nil, err
+	}
+	ws, err := p.Workspaces.Acquire(OpenshiftClusterType, provisionID, WorkspaceProvision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare provision workspace: %w", err)
+	}
 
-	metadata, err := openshiftsnc.Create(ctxArgs, sncArgs)
+	backend, err := p.stateBackend(cluster)
+	if err != nil {
+		ws.cleanup()
+		return nil, err
+	}
+	backedURL, err := backend.URL(OpenshiftClusterType, provisionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create openshift snc cluster: %w", err)
+		ws.cleanup()
+		return nil, err
 	}
-	if metadata == nil {
-		return nil, fmt.Errorf("received nil metadata from OpenShift SNC creation")
+
+	ctxArgs := p.buildContextArgs(cluster, backedURL, ws)
+	create := func(strategy v1alpha1.FallbackStrategy) (*OpenshiftMetadata, error) {
+		compute, err := computeRequest(ctx, p.Machines, cluster.Spec.MachineConfig, v1alpha1.CloudConfig{}, strategy)
+		if err != nil {
+			return nil, err
+		}
+		restricted := restrictIngressOnLaunch(ctx, p.Machines, provisionID, cluster.Spec.Network)
+		metadata, err := openshiftsnc.Create(ctxArgs, p.buildSNCArgs(cluster, pullSecretFile, compute, strategy))
+		if err != nil {
+			_ = restricted()
+			return nil, fmt.Errorf("failed to create openshift snc cluster: %w", err)
+		}
+		if err := restricted(); err != nil {
+			return nil, err
+		}
+		if metadata == nil {
+			return nil, fmt.Errorf("received nil metadata from OpenShift SNC creation")
+		}
+
+		return &OpenshiftMetadata{
+			Username:          metadata.Username,
+			PrivateKey:        metadata.PrivateKey,
+			Host:              metadata.Host,
+			Kubeconfig:        metadata.Kubeconfig,
+			KubeadminPassword: metadata.KubeadminPass,
+			// mapt reports no price for on-demand instances.
+			SpotPrice:  ptr.Deref(metadata.SpotPrice, 0),
+			ConsoleURL: metadata.ConsoleUrl,
+		}, nil
 	}
 
-	return &OpenshiftMetadata{
-		Username:          metadata.Username,
-		PrivateKey:        metadata.PrivateKey,
-		Host:              metadata.Host,
-		Kubeconfig:        metadata.Kubeconfig,
-		KubeadminPassword: metadata.KubeadminPass,
-		SpotPrice:         *metadata.SpotPrice,
-		ConsoleURL:        metadata.ConsoleUrl,
-	}, nil
+	return runCancellable(ctx, OpenshiftClusterType, provisionID, func() (*OpenshiftMetadata, error) {
+		defer ws.cleanup()
+		meta, attempts, err := provisionWithFallback(ctx, FallbackChain(cluster.Spec.MachineConfig), create, func() error {
+			return openshiftsnc.Destroy(ctxArgs)
+		})
+		if err != nil {
+			return nil, err
+		}
+		meta.Attempts = attempts
+		meta.Placement = p.placement(ctx, provisionID)
+		if err := configureHost(ctx, p.Machines, provisionID, meta.Placement, meta.login(), cluster.Spec.MachineConfig); err != nil {
+			return nil, err
+		}
+		if err := configureNetwork(ctx, p.Machines, provisionID, cluster.Spec.Network, meta.Host); err != nil {
+			return nil, err
+		}
+		return meta, nil
+	})
 }
 
-func (p *openshiftSncProvisioner) Deprovision(cluster *v1alpha1.Openshift) error {
-	return openshiftsnc.Destroy(&context.ContextArgs{
-		ProjectName:           cluster.Name,
-		BackedURL:             fmt.Sprintf("s3://%s/mapt/openshift-snc/%s", p.CloudCredentials.S3BucketName, *cluster.Status.ProvisionId),
-		SpotPriceIncreaseRate: *cluster.Spec.MachineConfig.SpotPriceIncreasePercentage,
-		ForceDestroy:          true,
+func (p *openshiftSncProvisioner) Deprovision(ctx gocontext.Context, cluster *v1alpha1.Openshift) error {
+	provisionID := *cluster.Status.ProvisionId
+	// A provisioning run abandoned after a timeout still owns the stack; destroying it concurrently
+	// would corrupt its state.
+	if err := waitIdle(ctx, OpenshiftClusterType, provisionID); err != nil {
+		return err
+	}
+	ws, err := p.Workspaces.Acquire(OpenshiftClusterType, provisionID, WorkspaceDeprovision)
+	if err != nil {
+		return fmt.Errorf("failed to prepare deprovision workspace: %w", err)
+	}
+
+	backend, err := p.stateBackend(cluster)
+	if err != nil {
+		ws.cleanup()
+		return err
+	}
+	backedURL, err := backend.URL(OpenshiftClusterType, provisionID)
+	if err != nil {
+		ws.cleanup()
+		return err
+	}
+
+	_, err = runCancellable(ctx, OpenshiftClusterType, provisionID, func() (struct{}, error) {
+		defer ws.cleanup()
+		if err := openshiftsnc.Destroy(&context.ContextArgs{
+			ProjectName:           cluster.Name,
+			BackedURL:             backedURL,
+			ResultsOutput:         ws.Dir,
+			SpotPriceIncreaseRate: *cluster.Spec.MachineConfig.SpotPriceIncreasePercentage,
+			ForceDestroy:          true,
+		}); err != nil {
+			return struct{}{}, err
+		}
+
+		// The cluster is gone at this point; leftover state only wastes storage, so do not fail on it.
+		if err := backend.Cleanup(gocontext.WithoutCancel(ctx), OpenshiftClusterType, provisionID); err != nil {
+			log.Log.WithName("openshift").Error(err, "Failed to clean up cluster state", "url", backedURL)
+		}
+		return struct{}{}, nil
 	})
+	return err
 }
 
-func (p *openshiftSncProvisioner) buildContextArgs(cluster *v1alpha1.Openshift) *context.ContextArgs {
+// Hibernate stops the instance of the cluster, keeping its disks.
+func (p *openshiftSncProvisioner) Hibernate(ctx gocontext.Context, cluster *v1alpha1.Openshift) (string, error) {
+	provisionID := *cluster.Status.ProvisionId
+	if err := waitIdle(ctx, OpenshiftClusterType, provisionID); err != nil {
+		return "", err
+	}
+	return p.Machines.Stop(ctx, provisionID, ptr.Deref(cluster.Status.AWSInstanceID, ""))
+}
+
+// Resume starts the instance of a hibernated cluster and waits for its API server.
+func (p *openshiftSncProvisioner) Resume(ctx gocontext.Context, cluster *v1alpha1.Openshift) (string, error) {
+	return p.Machines.Start(ctx, *cluster.Status.ProvisionId, ptr.Deref(cluster.Status.AWSInstanceID, ""))
+}
+
+// stateBackend returns the backend recorded when provisioning started, or the requested one
+// for clusters provisioned before backends were recorded.
+func (p *openshiftSncProvisioner) stateBackend(cluster *v1alpha1.Openshift) (StateBackend, error) {
+	cfg := cluster.Status.StateBackend
+	if cfg == nil {
+		cfg = ResolveStateBackend(cluster.Spec.StateBackend)
+	}
+	return NewStateBackend(cfg, p.CloudCredentials)
+}
+
+func (p *openshiftSncProvisioner) buildContextArgs(cluster *v1alpha1.Openshift, backedURL string, ws *Workspace) *context.ContextArgs {
 	return &context.ContextArgs{
 		ProjectName:           cluster.Name,
-		BackedURL:             fmt.Sprintf("s3://%s/mapt/openshift-snc/%s", p.CloudCredentials.S3BucketName, *cluster.Status.ProvisionId),
+		BackedURL:             backedURL,
+		ResultsOutput:         ws.Dir,
 		SpotPriceIncreaseRate: *cluster.Spec.MachineConfig.SpotPriceIncreasePercentage,
-		Tags:                  cluster.Spec.MachineConfig.Tags,
+		Tags:                  provisionTags(cluster.Spec.MachineConfig.Tags, *cluster.Status.ProvisionId),
 		ForceDestroy:          true,
 	}
 }
 
-func (p *openshiftSncProvisioner) buildSNCArgs(cluster *v1alpha1.Openshift, pullSecretFile string) *openshiftsnc.OpenshiftSNCArgs {
+func (p *openshiftSncProvisioner) buildSNCArgs(cluster *v1alpha1.Openshift, pullSecretFile string, compute *instancetypes.ComputeRequestArgs, strategy v1alpha1.FallbackStrategy) *openshiftsnc.OpenshiftSNCArgs {
 	return &openshiftsnc.OpenshiftSNCArgs{
 		Prefix:         cluster.Name,
 		Version:        "4.19.0",
-		ComputeRequest: p.buildComputeRequest(cluster),
+		ComputeRequest: compute,
 		Arch:           cluster.Spec.MachineConfig.Architecture,
 		PullSecretFile: pullSecretFile,
-		Spot:           true,
+		Spot:           strategy.Market == v1alpha1.InstanceMarketSpot,
 	}
 }
 
-func (p *openshiftSncProvisioner) buildComputeRequest(cluster *v1alpha1.Openshift) *instancetypes.ComputeRequestArgs {
-	if cluster.Spec.MachineConfig.GPU {
-		return &instancetypes.ComputeRequestArgs{
-			ComputeSizes: SupportedAwsGPUsInstances,
-			Arch:         instancetypes.Amd64,
-		}
-	}
-	return &instancetypes.ComputeRequestArgs{
-		CPUs:      cluster.Spec.MachineConfig.CPUs,
-		MemoryGib: cluster.Spec.MachineConfig.MemoryGiB,
-		Arch:      instancetypes.Amd64,
+// placement returns where mapt placed the instance of the cluster. Failing to look it up does not
+// fail the provisioning; the placement is then not reported.
+func (p *openshiftSncProvisioner) placement(ctx gocontext.Context, provisionID string) Placement {
+	placement, err := p.Machines.Placement(ctx, provisionID)
+	if err != nil {
+		log.Log.WithName("openshift").Error(err, "Failed to look up the placement of the cluster", "provisionId", provisionID)
 	}
+	return placement
 }
 
 func getFromEnvOrError(key string) (string, error) {
@@ -4,8 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
-	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -15,94 +15,85 @@ type ProvisionerMetadata struct {
 	Provisioner string
 }
 
+// GenericMaptProvisioner provisions and deprovisions clusters of every supported type. Both calls
+// return once ctx is done, even if the provisioning tool is still running.
 type GenericMaptProvisioner interface {
-	Provision(cluster *MaptCluster) (*ClusterProvisionerMetadata, error)
-	Deprovision(cluster *MaptCluster) error
+	Provision(ctx context.Context, cluster *MaptCluster) (ClusterProvisionerMetadata, error)
+	Deprovision(ctx context.Context, cluster *MaptCluster) error
 }
 
+// maptProvisioner dispatches each cluster to the provider registered for its type.
 type maptProvisioner struct {
-	openshiftProv OpenshiftProvisioner
-	kindProv      KindProvisioner
+	providers map[ClusterType]provider
+}
+
+var (
+	defaultProvisionerMu sync.Mutex
+	defaultProvisioner   GenericMaptProvisioner
+)
+
+// SetDefaultProvisioner makes NewGenericMaptProvisioner return p instead of provisioning through
+// mapt, e.g. to run the operator with a SimulatedProvisioner. It must be called before the
+// controllers start.
+func SetDefaultProvisioner(p GenericMaptProvisioner) {
+	defaultProvisionerMu.Lock()
+	defer defaultProvisionerMu.Unlock()
+	defaultProvisioner = p
 }
 
 func NewGenericMaptProvisioner(ctx context.Context, c client.Client) (GenericMaptProvisioner, error) {
+	defaultProvisionerMu.Lock()
+	override := defaultProvisioner
+	defaultProvisionerMu.Unlock()
+	if override != nil {
+		return override, nil
+	}
+
 	creds, err := loadCloudCredentials(ctx, c)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load cloud credentials: %w", err)
 	}
+	workspaces, err := DefaultWorkspaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize workspaces: %w", err)
+	}
 
-	return &maptProvisioner{
-		openshiftProv: &openshiftSncProvisioner{
-			CloudCredentials: creds,
-		},
-		kindProv: &kindClusterProvisioner{
-			CloudCredentials: creds,
-		},
-	}, nil
+	providers, err := newProviders(ProviderConfig{
+		Client:           c,
+		CloudCredentials: creds,
+		Workspaces:       workspaces,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &maptProvisioner{providers: providers}, nil
 }
 
-func (p *maptProvisioner) Provision(cluster *MaptCluster) (*ClusterProvisionerMetadata, error) {
-	switch cluster.Type {
-	case OpenshiftClusterType:
-		ocp, err := getOpenshift(cluster.Object)
-		if err != nil {
-			return nil, err
-		}
-		metadata, err := p.openshiftProv.Provision(ocp)
-		if err != nil {
-			return nil, fmt.Errorf("failed to provision OpenShift cluster: %w", err)
-		}
-		return &ClusterProvisionerMetadata{
-			Type:              OpenshiftClusterType,
-			OpenshiftMetadata: metadata,
-		}, nil
-
-	case KindClusterType:
-		kind, err := getKind(cluster.Object)
-		if err != nil {
-			return nil, err
-		}
-		kindMetadata, err := p.kindProv.Provision(kind)
-		if err != nil {
-			return nil, fmt.Errorf("failed to provision Kind cluster: %w", err)
-		}
-		return &ClusterProvisionerMetadata{
-			Type:         KindClusterType,
-			KindMetadata: kindMetadata,
-		}, nil
-
-	default:
+func (p *maptProvisioner) Provision(ctx context.Context, cluster *MaptCluster) (ClusterProvisionerMetadata, error) {
+	prov, ok := p.providers[cluster.Type]
+	if !ok {
 		return nil, fmt.Errorf("unsupported cluster type: %s", cluster.Type)
 	}
+	return prov.Provision(ctx, cluster.Object)
 }
 
-func (p *maptProvisioner) Deprovision(cluster *MaptCluster) error {
-	switch cluster.Type {
-	case OpenshiftClusterType:
-		ocp, err := getOpenshift(cluster.Object)
-		if err != nil {
-			return err
-		}
-		return p.openshiftProv.Deprovision(ocp)
-
-	case KindClusterType:
-		kind, err := getKind(cluster.Object)
-		if err != nil {
-			return err
-		}
-		return p.kindProv.Deprovision(kind)
-
-	default:
+func (p *maptProvisioner) Deprovision(ctx context.Context, cluster *MaptCluster) error {
+	prov, ok := p.providers[cluster.Type]
+	if !ok {
 		return fmt.Errorf("unsupported cluster type: %s", cluster.Type)
 	}
+	return prov.Deprovision(ctx, cluster.Object)
+}
+
+// CloudCredentialsSecretKey returns the key of the Secret holding the cloud credentials of the
+// operator, which every cluster is provisioned with.
+func CloudCredentialsSecretKey() client.ObjectKey {
+	return client.ObjectKey{Name: CloudCredentialsSecretName, Namespace: CloudCredentialsSecretNamespace}
 }
 
 func loadCloudCredentials(ctx context.Context, c client.Client) (*ProvisionCloudCredentials, error) {
 	secret := &corev1.Secret{}
-	secretKey := client.ObjectKey{
-		Name:      CloudCredentialsSecretName,
-		Namespace: CloudCredentialsSecretNamespace,
-	}
+	secretKey := CloudCredentialsSecretKey()
 	if err := c.Get(ctx, secretKey, secret); err != nil {
 		return nil, fmt.Errorf("failed to get secret '%s' in namespace '%s': %w", secretKey.Name, secretKey.Namespace, err)
 	}
@@ -120,6 +111,17 @@ func loadCloudCredentials(ctx context.Context, c client.Client) (*ProvisionCloud
 	return creds, nil
 }
 
+// CredentialsRegion returns the region of the cloud credentials of the operator, which clusters
+// are provisioned in.
+func CredentialsRegion(ctx context.Context, c client.Reader) (string, error) {
+	secret := &corev1.Secret{}
+	secretKey := CloudCredentialsSecretKey()
+	if err := c.Get(ctx, secretKey, secret); err != nil {
+		return "", fmt.Errorf("failed to get secret '%s' in namespace '%s': %w", secretKey.Name, secretKey.Namespace, err)
+	}
+	return string(secret.Data["region"]), nil
+}
+
 func (c *ProvisionCloudCredentials) Validate() error {
 	if c.AccessKeyID == "" {
 		return errors.New("missing cloud credential: access-key")
@@ -130,24 +132,5 @@ func (c *ProvisionCloudCredentials) Validate() error {
 	if c.Region == "" {
 		return errors.New("missing cloud credential: region")
 	}
-	if c.S3BucketName == "" {
-		return errors.New("missing cloud credential: bucket")
-	}
 	return nil
 }
-
-func getOpenshift(obj client.Object) (*v1alpha1.Openshift, error) {
-	ocp, ok := obj.(*v1alpha1.Openshift)
-	if !ok {
-		return nil, errors.New("object is not of type *v1alpha1.Openshift")
-	}
-	return ocp, nil
-}
-
-func getKind(obj client.Object) (*v1alpha1.Kind, error) {
-	kind, ok := obj.(*v1alpha1.Kind)
-	if !ok {
-		return nil, errors.New("object is not of type *v1alpha1.Kind")
-	}
-	return kind, nil
-}
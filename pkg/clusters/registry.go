@@ -0,0 +1,149 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// TypedProvider provisions clusters of one type, described by the API object T, and reports the
+// access details of a provisioned cluster as M.
+type TypedProvider[T client.Object, M ClusterProvisionerMetadata] interface {
+	Provision(ctx context.Context, cluster T) (M, error)
+	Deprovision(ctx context.Context, cluster T) error
+}
+
+// ProviderConfig is the operator configuration providers are built from.
+type ProviderConfig struct {
+	// Client reads the cluster the operator runs in.
+	Client client.Client
+	// CloudCredentials are the operator-wide cloud credentials.
+	CloudCredentials *ProvisionCloudCredentials
+	// Workspaces hands out the scratch directories of provisioning operations.
+	Workspaces *WorkspaceManager
+}
+
+// ProviderFactory builds the provider of a cluster type from the operator configuration.
+type ProviderFactory[T client.Object, M ClusterProvisionerMetadata] func(cfg ProviderConfig) (TypedProvider[T, M], error)
+
+// provider is a TypedProvider with its types erased, so providers of every cluster type can be
+// kept together.
+type provider interface {
+	Provision(ctx context.Context, obj client.Object) (ClusterProvisionerMetadata, error)
+	Deprovision(ctx context.Context, obj client.Object) error
+	Hibernate(ctx context.Context, obj client.Object) (string, error)
+	Resume(ctx context.Context, obj client.Object) (string, error)
+}
+
+type registration struct {
+	backendPath string
+	newProvider func(cfg ProviderConfig) (provider, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[ClusterType]registration{}
+)
+
+// RegisterProvider makes a cluster type available to NewGenericMaptProvisioner. backendPath is the
+// directory, below "mapt/" in the state backend, holding the state of clusters of that type.
+// Providers living outside this repository register from their own main package, before the
+// controllers are set up. Registering the same cluster type twice panics.
+func RegisterProvider[T client.Object, M ClusterProvisionerMetadata](
+	clusterType ClusterType, backendPath string, factory ProviderFactory[T, M],
+) {
+	if clusterType == "" || backendPath == "" || factory == nil {
+		panic("clusters: RegisterProvider requires a cluster type, a backend path and a factory")
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[clusterType]; dup {
+		panic(fmt.Sprintf("clusters: provider for cluster type %q registered twice", clusterType))
+	}
+	registry[clusterType] = registration{
+		backendPath: backendPath,
+		newProvider: func(cfg ProviderConfig) (provider, error) {
+			p, err := factory(cfg)
+			if err != nil {
+				return nil, err
+			}
+			return &typedProvider[T, M]{clusterType: clusterType, provider: p}, nil
+		},
+	}
+}
+
+// RegisteredClusterTypes returns the cluster types with a registered provider, sorted.
+func RegisteredClusterTypes() []ClusterType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	types := make([]ClusterType, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// newProviders builds the provider of every registered cluster type.
+func newProviders(cfg ProviderConfig) (map[ClusterType]provider, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	providers := make(map[ClusterType]provider, len(registry))
+	for t, reg := range registry {
+		p, err := reg.newProvider(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s provider: %w", t, err)
+		}
+		providers[t] = p
+	}
+	return providers, nil
+}
+
+// backendPath returns the state backend directory of a cluster type, falling back to the type name
+// for unregistered types.
+func backendPath(clusterType ClusterType) string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if reg, ok := registry[clusterType]; ok {
+		return reg.backendPath
+	}
+	return string(clusterType)
+}
+
+type typedProvider[T client.Object, M ClusterProvisionerMetadata] struct {
+	clusterType ClusterType
+	provider    TypedProvider[T, M]
+}
+
+func (p *typedProvider[T, M]) Provision(ctx context.Context, obj client.Object) (ClusterProvisionerMetadata, error) {
+	cluster, err := clusterObject[T](obj)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := p.provider.Provision(ctx, cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to provision %s cluster: %w", p.clusterType, err)
+	}
+	return metadata, nil
+}
+
+func (p *typedProvider[T, M]) Deprovision(ctx context.Context, obj client.Object) error {
+	cluster, err := clusterObject[T](obj)
+	if err != nil {
+		return err
+	}
+	return p.provider.Deprovision(ctx, cluster)
+}
+
+func clusterObject[T client.Object](obj client.Object) (T, error) {
+	cluster, ok := obj.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("object is not of type %T", zero)
+	}
+	return cluster, nil
+}
@@ -0,0 +1,297 @@
+package clusters
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SimulateFailureAnnotation makes the SimulatedProvisioner fail the provisioning of a cluster
+// with the reason given as value.
+const SimulateFailureAnnotation = "mapt.redhat.com/simulate-failure"
+
+// SimulatedKubeconfigMode selects how the SimulatedProvisioner builds kubeconfigs.
+type SimulatedKubeconfigMode string
+
+const (
+	// SimulatedKubeconfigEnvtest starts a local API server per cluster, so the returned kubeconfig
+	// is fully usable. It requires an operator built with the simulated_envtest build tag, which
+	// links envtest, and the envtest binaries (KUBEBUILDER_ASSETS).
+	SimulatedKubeconfigEnvtest SimulatedKubeconfigMode = "envtest"
+	// SimulatedKubeconfigStatic returns a well-formed kubeconfig pointing to an unreachable server.
+	SimulatedKubeconfigStatic SimulatedKubeconfigMode = "static"
+)
+
+// SimulatedOptions configures the SimulatedProvisioner.
+type SimulatedOptions struct {
+	// Latency is how long provisioning a cluster takes.
+	Latency time.Duration
+	// DeprovisionLatency is how long deprovisioning a cluster takes.
+	DeprovisionLatency time.Duration
+	// FailureRates maps failure reasons to the probability, between 0 and 1, of provisioning
+	// failing with that reason.
+	FailureRates map[string]float64
+	// SpotPriceMin and SpotPriceMax bound the fake hourly spot price of the clusters.
+	SpotPriceMin, SpotPriceMax float64
+	// KubeconfigMode selects how kubeconfigs are built.
+	KubeconfigMode SimulatedKubeconfigMode
+}
+
+// SimulatedFailureError is returned by the SimulatedProvisioner when a failure is injected.
+type SimulatedFailureError struct {
+	Reason string
+}
+
+func (e *SimulatedFailureError) Error() string {
+	return fmt.Sprintf("simulated provisioning failure: %s", e.Reason)
+}
+
+// SimulatedProvisioner implements GenericMaptProvisioner without any cloud access. It is meant for
+// e2e tests and demos: it reproduces the latency, failures and spot prices of real provisioning and
+// hands out working kubeconfigs backed by local API servers.
+type SimulatedProvisioner struct {
+	opts SimulatedOptions
+
+	mu      sync.Mutex
+	servers map[string]simulatedAPIServer
+}
+
+// simulatedAPIServer is a local API server backing the kubeconfig of a simulated cluster.
+type simulatedAPIServer interface {
+	// Kubeconfig returns an admin kubeconfig of the API server and its address.
+	Kubeconfig() (string, string, error)
+	Stop() error
+}
+
+// NewSimulatedProvisioner returns a SimulatedProvisioner configured with opts.
+func NewSimulatedProvisioner(opts SimulatedOptions) *SimulatedProvisioner {
+	if opts.KubeconfigMode == "" {
+		opts.KubeconfigMode = DefaultSimulatedKubeconfigMode
+	}
+	if opts.SpotPriceMax < opts.SpotPriceMin {
+		opts.SpotPriceMax = opts.SpotPriceMin
+	}
+	return &SimulatedProvisioner{
+		opts:    opts,
+		servers: map[string]simulatedAPIServer{},
+	}
+}
+
+func (p *SimulatedProvisioner) Provision(ctx context.Context, cluster *MaptCluster) (ClusterProvisionerMetadata, error) {
+	provisionID, err := simulatedProvisionID(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sleep(ctx, p.opts.Latency); err != nil {
+		return nil, err
+	}
+
+	if err := p.injectedFailure(cluster.Object); err != nil {
+		return nil, err
+	}
+
+	kubeconfig, host, err := p.kubeconfig(cluster.Object.GetName(), provisionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build simulated kubeconfig: %w", err)
+	}
+	spotPrice := p.opts.SpotPriceMin + mathrand.Float64()*(p.opts.SpotPriceMax-p.opts.SpotPriceMin)
+
+	switch cluster.Type {
+	case KindClusterType:
+		return &KindMetadata{
+			Username:   "simulated",
+			Host:       host,
+			Kubeconfig: kubeconfig,
+			SpotPrice:  spotPrice,
+		}, nil
+	case OpenshiftClusterType:
+		return &OpenshiftMetadata{
+			Username:          "simulated",
+			Host:              host,
+			Kubeconfig:        kubeconfig,
+			KubeadminPassword: randomToken(),
+			SpotPrice:         spotPrice,
+			ConsoleURL:        fmt.Sprintf("https://console-openshift-console.apps.%s.simulated.invalid", cluster.Object.GetName()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cluster type: %s", cluster.Type)
+	}
+}
+
+func (p *SimulatedProvisioner) Deprovision(ctx context.Context, cluster *MaptCluster) error {
+	provisionID, err := simulatedProvisionID(cluster)
+	if err != nil {
+		return err
+	}
+
+	if err := sleep(ctx, p.opts.DeprovisionLatency); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	server, ok := p.servers[provisionID]
+	delete(p.servers, provisionID)
+	p.mu.Unlock()
+	if ok {
+		if err := server.Stop(); err != nil {
+			return fmt.Errorf("failed to stop simulated API server: %w", err)
+		}
+	}
+	return nil
+}
+
+// Hibernate pretends to stop the instance of a cluster. The API server of the cluster keeps running,
+// so that its kubeconfig still works once resumed.
+func (p *SimulatedProvisioner) Hibernate(ctx context.Context, cluster *MaptCluster) (string, error) {
+	provisionID, err := simulatedProvisionID(cluster)
+	if err != nil {
+		return "", err
+	}
+	if err := sleep(ctx, p.opts.DeprovisionLatency); err != nil {
+		return "", err
+	}
+	return "simulated-" + provisionID, nil
+}
+
+// Resume pretends to start the instance of a hibernated cluster; its address does not change.
+func (p *SimulatedProvisioner) Resume(ctx context.Context, cluster *MaptCluster) (string, error) {
+	if _, err := simulatedProvisionID(cluster); err != nil {
+		return "", err
+	}
+	return "", sleep(ctx, p.opts.Latency)
+}
+
+// Stop shuts down the API servers of every simulated cluster.
+func (p *SimulatedProvisioner) Stop() error {
+	p.mu.Lock()
+	servers := p.servers
+	p.servers = map[string]simulatedAPIServer{}
+	p.mu.Unlock()
+
+	var errs []error
+	for _, server := range servers {
+		errs = append(errs, server.Stop())
+	}
+	return errors.Join(errs...)
+}
+
+// injectedFailure returns the failure requested through SimulateFailureAnnotation or rolled from
+// the configured failure rates, if any.
+func (p *SimulatedProvisioner) injectedFailure(obj client.Object) error {
+	if reason := obj.GetAnnotations()[SimulateFailureAnnotation]; reason != "" {
+		return &SimulatedFailureError{Reason: reason}
+	}
+
+	reasons := make([]string, 0, len(p.opts.FailureRates))
+	for reason := range p.opts.FailureRates {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		if mathrand.Float64() < p.opts.FailureRates[reason] {
+			return &SimulatedFailureError{Reason: reason}
+		}
+	}
+	return nil
+}
+
+// kubeconfig returns the kubeconfig and API server host of a simulated cluster. In envtest mode
+// the API server of a retried ProvisionId is reused.
+func (p *SimulatedProvisioner) kubeconfig(name, provisionID string) (string, string, error) {
+	if p.opts.KubeconfigMode == SimulatedKubeconfigStatic {
+		host := fmt.Sprintf("https://api.%s.simulated.invalid:6443", name)
+		kubeconfig, err := staticKubeconfig(name, host)
+		return kubeconfig, host, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	server, ok := p.servers[provisionID]
+	if !ok {
+		var err error
+		if server, err = startSimulatedAPIServer(); err != nil {
+			return "", "", fmt.Errorf("failed to start simulated API server: %w", err)
+		}
+		p.servers[provisionID] = server
+	}
+	return server.Kubeconfig()
+}
+
+func staticKubeconfig(name, host string) (string, error) {
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters[name] = &clientcmdapi.Cluster{Server: host, InsecureSkipTLSVerify: true}
+	cfg.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: randomToken()}
+	cfg.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	cfg.CurrentContext = name
+	data, err := clientcmd.Write(*cfg)
+	return string(data), err
+}
+
+func simulatedProvisionID(cluster *MaptCluster) (string, error) {
+	var provisionID *string
+	switch obj := cluster.Object.(type) {
+	case *v1alpha1.Kind:
+		provisionID = obj.Status.ProvisionId
+	case *v1alpha1.Openshift:
+		provisionID = obj.Status.ProvisionId
+	default:
+		return "", fmt.Errorf("unsupported cluster type: %s", cluster.Type)
+	}
+	if provisionID == nil || *provisionID == "" {
+		return "", fmt.Errorf("missing or empty Status.ProvisionId")
+	}
+	return *provisionID, nil
+}
+
+// ParseFailureRates parses failure rates written as comma-separated reason=probability pairs,
+// e.g. "SpotCapacityUnavailable=0.1,QuotaExceeded=0.05".
+func ParseFailureRates(s string) (map[string]float64, error) {
+	rates := map[string]float64{}
+	if strings.TrimSpace(s) == "" {
+		return rates, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		reason, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || reason == "" {
+			return nil, fmt.Errorf("invalid failure rate %q, expected reason=probability", pair)
+		}
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid probability %q for failure reason %s, expected a value between 0 and 1", value, reason)
+		}
+		rates[reason] = rate
+	}
+	return rates, nil
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
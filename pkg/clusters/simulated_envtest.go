@@ -0,0 +1,38 @@
+//go:build simulated_envtest
+
+package clusters
+
+import "sigs.k8s.io/controller-runtime/pkg/envtest"
+
+// DefaultSimulatedKubeconfigMode is the kubeconfig mode of the SimulatedProvisioner when none is
+// configured.
+const DefaultSimulatedKubeconfigMode = SimulatedKubeconfigEnvtest
+
+// envtestAPIServer is an API server started with envtest.
+type envtestAPIServer struct {
+	env *envtest.Environment
+}
+
+func startSimulatedAPIServer() (simulatedAPIServer, error) {
+	env := &envtest.Environment{}
+	if _, err := env.Start(); err != nil {
+		return nil, err
+	}
+	return &envtestAPIServer{env: env}, nil
+}
+
+func (s *envtestAPIServer) Kubeconfig() (string, string, error) {
+	user, err := s.env.AddUser(envtest.User{Name: "simulated-admin", Groups: []string{"system:masters"}}, nil)
+	if err != nil {
+		return "", "", err
+	}
+	kubeconfig, err := user.KubeConfig()
+	if err != nil {
+		return "", "", err
+	}
+	return string(kubeconfig), user.Config().Host, nil
+}
+
+func (s *envtestAPIServer) Stop() error {
+	return s.env.Stop()
+}
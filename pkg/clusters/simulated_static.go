@@ -0,0 +1,15 @@
+//go:build !simulated_envtest
+
+package clusters
+
+import "errors"
+
+// DefaultSimulatedKubeconfigMode is the kubeconfig mode of the SimulatedProvisioner when none is
+// configured.
+const DefaultSimulatedKubeconfigMode = SimulatedKubeconfigStatic
+
+// startSimulatedAPIServer fails: envtest is only linked into operators built with the
+// simulated_envtest build tag, so that production builds do not carry it.
+func startSimulatedAPIServer() (simulatedAPIServer, error) {
+	return nil, errors.New("the envtest kubeconfig mode requires an operator built with -tags simulated_envtest")
+}
@@ -0,0 +1,154 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+// StateBackend stores the state the provisioning tool keeps for each cluster.
+type StateBackend interface {
+	// Validate checks that the backend is fully configured.
+	Validate() error
+
+	// URL renders the backend URL holding the state of a cluster, preparing the location if needed.
+	URL(clusterType ClusterType, provisionID string) (string, error)
+
+	// Cleanup removes the state of a cluster. It is called once the cluster has been destroyed.
+	Cleanup(ctx context.Context, clusterType ClusterType, provisionID string) error
+}
+
+// StateBackendOptions is the operator-wide state backend configuration.
+type StateBackendOptions struct {
+	// Type is the backend used by clusters that do not select one.
+	Type v1alpha1.StateBackendType
+	// Bucket is the default S3 bucket or Azure Blob container. For S3 it falls back to the bucket
+	// of the cloud credentials.
+	Bucket string
+	// FileDir is the directory holding the state of the File backend.
+	FileDir string
+}
+
+var (
+	stateBackendsMu      sync.Mutex
+	stateBackendDefaults = StateBackendOptions{Type: v1alpha1.StateBackendS3}
+)
+
+// ConfigureStateBackends sets the operator-wide state backend configuration.
+// It must be called before the controllers start.
+func ConfigureStateBackends(opts StateBackendOptions) error {
+	switch opts.Type {
+	case v1alpha1.StateBackendS3:
+	case v1alpha1.StateBackendFile:
+		if !filepath.IsAbs(opts.FileDir) {
+			return fmt.Errorf("state backend directory must be an absolute path, got %q", opts.FileDir)
+		}
+	case v1alpha1.StateBackendAzureBlob:
+		if opts.Bucket == "" {
+			return fmt.Errorf("state backend %s requires a container", opts.Type)
+		}
+	default:
+		return fmt.Errorf("unsupported state backend: %q", opts.Type)
+	}
+
+	stateBackendsMu.Lock()
+	defer stateBackendsMu.Unlock()
+	stateBackendDefaults = opts
+	return nil
+}
+
+// ResolveStateBackend returns the backend a cluster should use, filling the operator defaults in
+// for anything the cluster did not request. The result is meant to be recorded in the cluster
+// status so that later operations keep using the same backend.
+func ResolveStateBackend(requested *v1alpha1.StateBackend) *v1alpha1.StateBackend {
+	stateBackendsMu.Lock()
+	defaults := stateBackendDefaults
+	stateBackendsMu.Unlock()
+
+	resolved := &v1alpha1.StateBackend{Type: defaults.Type}
+	if requested != nil {
+		resolved.Type = requested.Type
+		resolved.Bucket = requested.Bucket
+	}
+	if resolved.Bucket == "" && resolved.Type == defaults.Type && resolved.Type != v1alpha1.StateBackendFile {
+		resolved.Bucket = defaults.Bucket
+	}
+	return resolved
+}
+
+// NewStateBackend builds the backend described by cfg. A nil cfg selects the operator default.
+func NewStateBackend(cfg *v1alpha1.StateBackend, creds *ProvisionCloudCredentials) (StateBackend, error) {
+	if cfg == nil {
+		cfg = ResolveStateBackend(nil)
+	}
+
+	var backend StateBackend
+	switch cfg.Type {
+	case v1alpha1.StateBackendS3:
+		b := &s3StateBackend{bucket: cfg.Bucket}
+		if creds != nil {
+			if b.bucket == "" {
+				b.bucket = creds.S3BucketName
+			}
+			b.region = creds.Region
+			b.accessKeyID = creds.AccessKeyID
+			b.secretAccessKey = creds.SecretAccessKey
+		}
+		backend = b
+	case v1alpha1.StateBackendFile:
+		stateBackendsMu.Lock()
+		dir := stateBackendDefaults.FileDir
+		stateBackendsMu.Unlock()
+		backend = &fileStateBackend{dir: dir}
+	case v1alpha1.StateBackendAzureBlob:
+		backend = newAzureBlobStateBackend(cfg.Bucket, os.Getenv("AZURE_STORAGE_ACCOUNT"), os.Getenv("AZURE_STORAGE_KEY"))
+	default:
+		return nil, fmt.Errorf("unsupported state backend: %q", cfg.Type)
+	}
+
+	if err := backend.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s state backend: %w", cfg.Type, err)
+	}
+	return backend, nil
+}
+
+// stateKey returns the path of the state of a cluster within a backend.
+func stateKey(clusterType ClusterType, provisionID string) string {
+	return path.Join("mapt", backendPath(clusterType), provisionID)
+}
+
+// fileStateBackend stores the state in a local directory, typically a PVC mount.
+type fileStateBackend struct {
+	dir string
+}
+
+func (b *fileStateBackend) Validate() error {
+	if b.dir == "" {
+		return fmt.Errorf("no state directory configured")
+	}
+	if !filepath.IsAbs(b.dir) {
+		return fmt.Errorf("state directory must be an absolute path, got %q", b.dir)
+	}
+	return nil
+}
+
+func (b *fileStateBackend) URL(clusterType ClusterType, provisionID string) (string, error) {
+	dir := filepath.Join(b.dir, filepath.FromSlash(stateKey(clusterType, provisionID)))
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+	return "file://" + filepath.ToSlash(dir), nil
+}
+
+func (b *fileStateBackend) Cleanup(_ context.Context, clusterType ClusterType, provisionID string) error {
+	dir := filepath.Join(b.dir, filepath.FromSlash(stateKey(clusterType, provisionID)))
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove state directory %s: %w", dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package clusters
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// azureStorageAPIVersion is the Blob service REST API version used for cleanup requests.
+const azureStorageAPIVersion = "2021-08-06"
+
+// azureBlobStateBackend stores the state in an Azure Blob Storage container. The provisioning tool
+// reads the storage account from the AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY environment
+// variables, so the same variables are used to clean the state up.
+type azureBlobStateBackend struct {
+	container  string
+	account    string
+	accountKey string
+	endpoint   string
+	httpClient *http.Client
+}
+
+func newAzureBlobStateBackend(container, account, accountKey string) *azureBlobStateBackend {
+	return &azureBlobStateBackend{
+		container:  container,
+		account:    account,
+		accountKey: accountKey,
+		endpoint:   fmt.Sprintf("https://%s.blob.core.windows.net", account),
+		httpClient: &http.Client{Timeout: time.Minute},
+	}
+}
+
+func (b *azureBlobStateBackend) Validate() error {
+	if b.container == "" {
+		return errors.New("no container configured")
+	}
+	if b.account == "" || b.accountKey == "" {
+		return errors.New("AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY must be set")
+	}
+	if _, err := base64.StdEncoding.DecodeString(b.accountKey); err != nil {
+		return fmt.Errorf("AZURE_STORAGE_KEY is not valid base64: %w", err)
+	}
+	return nil
+}
+
+func (b *azureBlobStateBackend) URL(clusterType ClusterType, provisionID string) (string, error) {
+	return fmt.Sprintf("azblob://%s/%s", b.container, stateKey(clusterType, provisionID)), nil
+}
+
+func (b *azureBlobStateBackend) Cleanup(ctx context.Context, clusterType ClusterType, provisionID string) error {
+	prefix := stateKey(clusterType, provisionID) + "/"
+	marker := ""
+	for {
+		blobs, next, err := b.listBlobs(ctx, prefix, marker)
+		if err != nil {
+			return err
+		}
+		for _, name := range blobs {
+			if err := b.deleteBlob(ctx, name); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		marker = next
+	}
+}
+
+type azureBlobList struct {
+	Blobs []struct {
+		Name string `xml:"Name"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func (b *azureBlobStateBackend) listBlobs(ctx context.Context, prefix, marker string) ([]string, string, error) {
+	query := url.Values{}
+	query.Set("restype", "container")
+	query.Set("comp", "list")
+	query.Set("prefix", prefix)
+	if marker != "" {
+		query.Set("marker", marker)
+	}
+
+	resp, err := b.do(ctx, http.MethodGet, "/"+url.PathEscape(b.container), query)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list state blobs under %s/%s: %w", b.container, prefix, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var list azureBlobList
+	if err := xml.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, "", fmt.Errorf("failed to decode blob list: %w", err)
+	}
+	names := make([]string, 0, len(list.Blobs))
+	for _, blob := range list.Blobs {
+		names = append(names, blob.Name)
+	}
+	return names, list.NextMarker, nil
+}
+
+func (b *azureBlobStateBackend) deleteBlob(ctx context.Context, name string) error {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	resp, err := b.do(ctx, http.MethodDelete, "/"+url.PathEscape(b.container)+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		var status *azureStatusError
+		if errors.As(err, &status) && status.code == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete state blob %s/%s: %w", b.container, name, err)
+	}
+	return resp.Body.Close()
+}
+
+type azureStatusError struct {
+	code int
+	body string
+}
+
+func (e *azureStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.code, e.body)
+}
+
+// do sends a request authorized with the storage account Shared Key.
+func (b *azureBlobStateBackend) do(ctx context.Context, method, escapedPath string, query url.Values) (*http.Response, error) {
+	u := b.endpoint + escapedPath
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-date", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("x-ms-version", azureStorageAPIVersion)
+
+	signature, err := b.sign(req, escapedPath, query)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", b.account, signature))
+
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, &azureStatusError{code: resp.StatusCode, body: string(body)}
+	}
+	return resp, nil
+}
+
+// sign computes the Shared Key signature of a request without body, as described in
+// https://learn.microsoft.com/rest/api/storageservices/authorize-with-shared-key.
+func (b *azureBlobStateBackend) sign(req *http.Request, escapedPath string, query url.Values) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(b.accountKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid storage account key: %w", err)
+	}
+
+	var headers []string
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-ms-") {
+			headers = append(headers, lower+":"+strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
+	sort.Strings(headers)
+
+	resource := "/" + b.account + escapedPath
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	for _, name := range params {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		resource += "\n" + strings.ToLower(name) + ":" + strings.Join(values, ",")
+	}
+
+	// Verb, then Content-Encoding, Content-Language, Content-Length, Content-MD5, Content-Type,
+	// Date, If-Modified-Since, If-Match, If-None-Match, If-Unmodified-Since and Range, all empty.
+	stringToSign := req.Method + strings.Repeat("\n", 12) + strings.Join(headers, "\n") + "\n" + resource
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,72 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3StateBackend stores the state in an S3 bucket, using the operator cloud credentials.
+type s3StateBackend struct {
+	bucket          string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+func (b *s3StateBackend) Validate() error {
+	if b.bucket == "" {
+		return errors.New("missing cloud credential: bucket")
+	}
+	return nil
+}
+
+func (b *s3StateBackend) URL(clusterType ClusterType, provisionID string) (string, error) {
+	return fmt.Sprintf("s3://%s/%s", b.bucket, stateKey(clusterType, provisionID)), nil
+}
+
+func (b *s3StateBackend) Cleanup(ctx context.Context, clusterType ClusterType, provisionID string) error {
+	opts := []func(*awsconfig.LoadOptions) error{awsconfig.WithRegion(b.region)}
+	if b.accessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(b.accessKeyID, b.secretAccessKey, ""),
+		))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	client := s3.NewFromConfig(cfg)
+
+	prefix := stateKey(clusterType, provisionID) + "/"
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list state objects under s3://%s/%s: %w", b.bucket, prefix, err)
+		}
+		if len(page.Contents) == 0 {
+			continue
+		}
+		objects := make([]s3types.ObjectIdentifier, 0, len(page.Contents))
+		for _, obj := range page.Contents {
+			objects = append(objects, s3types.ObjectIdentifier{Key: obj.Key})
+		}
+		if _, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(b.bucket),
+			Delete: &s3types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		}); err != nil {
+			return fmt.Errorf("failed to delete state objects under s3://%s/%s: %w", b.bucket, prefix, err)
+		}
+	}
+	return nil
+}
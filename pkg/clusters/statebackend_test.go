@@ -0,0 +1,142 @@
+package clusters
+
+import (
+	"context"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+// azureTestKey is a base64 encoded storage account key.
+const azureTestKey = "c2VjcmV0LWtleQ=="
+
+var _ = Describe("StateBackend", func() {
+	DescribeTable("builds the state URL of a cluster",
+		func(backend StateBackend, clusterType ClusterType, expected string) {
+			Expect(backend.Validate()).To(Succeed())
+			Expect(backend.URL(clusterType, "abc123")).To(Equal(expected))
+		},
+		Entry("S3 for a Kind cluster", &s3StateBackend{bucket: "states"}, KindClusterType, "s3://states/mapt/kind/abc123"),
+		Entry("S3 for an Openshift cluster", &s3StateBackend{bucket: "states"}, OpenshiftClusterType, "s3://states/mapt/openshift-snc/abc123"),
+		Entry("Azure Blob for a Kind cluster",
+			newAzureBlobStateBackend("states", "account", azureTestKey), KindClusterType, "azblob://states/mapt/kind/abc123"),
+		Entry("Azure Blob for an Openshift cluster",
+			newAzureBlobStateBackend("states", "account", azureTestKey), OpenshiftClusterType, "azblob://states/mapt/openshift-snc/abc123"),
+	)
+
+	It("keeps the File state in a directory per cluster", func() {
+		dir := GinkgoT().TempDir()
+		backend := &fileStateBackend{dir: dir}
+		Expect(backend.Validate()).To(Succeed())
+
+		url, err := backend.URL(KindClusterType, "abc123")
+		Expect(err).NotTo(HaveOccurred())
+		stateDir := filepath.Join(dir, "mapt", "kind", "abc123")
+		Expect(url).To(Equal("file://" + filepath.ToSlash(stateDir)))
+		Expect(stateDir).To(BeADirectory())
+
+		Expect(backend.Cleanup(context.Background(), KindClusterType, "abc123")).To(Succeed())
+		Expect(stateDir).NotTo(BeADirectory())
+	})
+
+	DescribeTable("rejects incomplete backends",
+		func(backend StateBackend, expected string) {
+			Expect(backend.Validate()).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("S3 without bucket", &s3StateBackend{}, "missing cloud credential: bucket"),
+		Entry("File without directory", &fileStateBackend{}, "no state directory configured"),
+		Entry("File with a relative directory", &fileStateBackend{dir: "states"}, `must be an absolute path, got "states"`),
+		Entry("Azure Blob without container", newAzureBlobStateBackend("", "account", azureTestKey), "no container configured"),
+		Entry("Azure Blob without account", newAzureBlobStateBackend("states", "", azureTestKey), "AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY must be set"),
+		Entry("Azure Blob without account key", newAzureBlobStateBackend("states", "account", ""), "AZURE_STORAGE_ACCOUNT and AZURE_STORAGE_KEY must be set"),
+		Entry("Azure Blob with an account key that is not base64", newAzureBlobStateBackend("states", "account", "not base64!"), "AZURE_STORAGE_KEY is not valid base64"),
+	)
+
+	DescribeTable("rejects invalid operator defaults",
+		func(opts StateBackendOptions, expected string) {
+			Expect(ConfigureStateBackends(opts)).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("File with a relative directory",
+			StateBackendOptions{Type: v1alpha1.StateBackendFile, FileDir: "states"}, `must be an absolute path, got "states"`),
+		Entry("Azure Blob without container", StateBackendOptions{Type: v1alpha1.StateBackendAzureBlob}, "requires a container"),
+		Entry("an unknown backend", StateBackendOptions{Type: "GCS"}, `unsupported state backend: "GCS"`),
+	)
+
+	Context("with the operator cloud credentials", func() {
+		BeforeEach(func() {
+			previous := stateBackendDefaults
+			DeferCleanup(func() {
+				stateBackendsMu.Lock()
+				defer stateBackendsMu.Unlock()
+				stateBackendDefaults = previous
+			})
+			GinkgoT().Setenv("AZURE_STORAGE_ACCOUNT", "account")
+			GinkgoT().Setenv("AZURE_STORAGE_KEY", azureTestKey)
+		})
+
+		// credentials loads the operator cloud credentials from a Secret holding bucket.
+		credentials := func(bucket string) *ProvisionCloudCredentials {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: CloudCredentialsSecretName, Namespace: CloudCredentialsSecretNamespace},
+				Data: map[string][]byte{
+					"access-key": []byte("AKIA"),
+					"secret-key": []byte("secret"),
+					"region":     []byte("us-east-1"),
+					"bucket":     []byte(bucket),
+				},
+			}
+			c := fake.NewClientBuilder().WithObjects(secret).Build()
+			creds, err := loadCloudCredentials(context.Background(), c)
+			Expect(err).NotTo(HaveOccurred())
+			return creds
+		}
+
+		DescribeTable("resolves the bucket of a cluster",
+			func(defaults StateBackendOptions, requested *v1alpha1.StateBackend, credentialsBucket, expected string) {
+				Expect(ConfigureStateBackends(defaults)).To(Succeed())
+				backend, err := NewStateBackend(ResolveStateBackend(requested), credentials(credentialsBucket))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(backend.URL(KindClusterType, "abc123")).To(Equal(expected))
+			},
+			Entry("falling back to the bucket of the credentials",
+				StateBackendOptions{Type: v1alpha1.StateBackendS3}, nil, "creds-bucket",
+				"s3://creds-bucket/mapt/kind/abc123"),
+			Entry("falling back to the bucket of the credentials when S3 is requested without bucket",
+				StateBackendOptions{Type: v1alpha1.StateBackendS3}, &v1alpha1.StateBackend{Type: v1alpha1.StateBackendS3}, "creds-bucket",
+				"s3://creds-bucket/mapt/kind/abc123"),
+			Entry("preferring the operator default bucket",
+				StateBackendOptions{Type: v1alpha1.StateBackendS3, Bucket: "operator-bucket"}, nil, "creds-bucket",
+				"s3://operator-bucket/mapt/kind/abc123"),
+			Entry("preferring the bucket of the cluster",
+				StateBackendOptions{Type: v1alpha1.StateBackendS3, Bucket: "operator-bucket"},
+				&v1alpha1.StateBackend{Type: v1alpha1.StateBackendS3, Bucket: "cluster-bucket"}, "creds-bucket",
+				"s3://cluster-bucket/mapt/kind/abc123"),
+			Entry("falling back to the bucket of the credentials when S3 is not the operator default",
+				StateBackendOptions{Type: v1alpha1.StateBackendAzureBlob, Bucket: "operator-container"},
+				&v1alpha1.StateBackend{Type: v1alpha1.StateBackendS3}, "creds-bucket",
+				"s3://creds-bucket/mapt/kind/abc123"),
+			Entry("ignoring the bucket of the credentials for Azure Blob",
+				StateBackendOptions{Type: v1alpha1.StateBackendAzureBlob, Bucket: "operator-container"}, nil, "creds-bucket",
+				"azblob://operator-container/mapt/kind/abc123"),
+		)
+
+		It("rejects S3 when neither the cluster, the operator nor the credentials set a bucket", func() {
+			Expect(ConfigureStateBackends(StateBackendOptions{Type: v1alpha1.StateBackendS3})).To(Succeed())
+			_, err := NewStateBackend(ResolveStateBackend(nil), credentials(""))
+			Expect(err).To(MatchError("invalid S3 state backend: missing cloud credential: bucket"))
+		})
+
+		It("rejects Azure Blob without container instead of using the bucket of the credentials", func() {
+			Expect(ConfigureStateBackends(StateBackendOptions{Type: v1alpha1.StateBackendS3})).To(Succeed())
+			requested := &v1alpha1.StateBackend{Type: v1alpha1.StateBackendAzureBlob}
+			_, err := NewStateBackend(ResolveStateBackend(requested), credentials("creds-bucket"))
+			Expect(err).To(MatchError(ContainSubstring("no container configured")))
+		})
+	})
+})
@@ -1,6 +1,9 @@
 package clusters
 
-import "sigs.k8s.io/controller-runtime/pkg/client"
+import (
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
 
 type ClusterType string
 
@@ -11,52 +14,53 @@ const (
 	CloudCredentialsSecretNamespace string      = "mapt-operator-system"
 )
 
-var (
-	SupportedAwsGPUsInstances = []string{
-		// G6e
-		"g6e.12xlarge", "g6e.16xlarge", "g6e.24xlarge", "g6e.48xlarge",
-
-		// G6
-		"g6.12xlarge", "g6.16xlarge", "g6.24xlarge", "g6.48xlarge",
-
-		// G5
-		"g5.12xlarge", "g5.16xlarge", "g5.48xlarge",
-
-		// P4 (A100)
-		"p4d.24xlarge", "p4de.24xlarge",
-
-		// P5 (H100)
-		"p5.48xlarge", "p5e.48xlarge", "p5en.48xlarge",
-	}
-)
+// apiServerPort is the port the API server of Kind and single node Openshift clusters is published
+// on, on the public address of their host.
+const apiServerPort int32 = 6443
 
 type MaptCluster struct {
 	Type   ClusterType
 	Object client.Object
 }
 
-type ClusterProvisionerMetadata struct {
-	Type              ClusterType
-	OpenshiftMetadata *OpenshiftMetadata `json:"openshiftMetadata,omitempty"`
-	KindMetadata      *KindMetadata      `json:"kindMetadata,omitempty"`
+// ClusterProvisionerMetadata holds the access details of a provisioned cluster. Each cluster type
+// reports its own metadata type, e.g. *KindMetadata or *OpenshiftMetadata.
+type ClusterProvisionerMetadata interface {
+	ClusterType() ClusterType
 }
 
 type OpenshiftMetadata struct {
-	Username          string  `json:"username"`
-	PrivateKey        string  `json:"privateKey"`
-	Host              string  `json:"host"`
-	Kubeconfig        string  `json:"kubeconfig"`
-	KubeadminPassword string  `json:"kubeadminPassword"`
-	SpotPrice         float64 `json:"spotPrice"`
-	ConsoleURL        string  `json:"consoleURL"`
+	Username          string                         `json:"username"`
+	PrivateKey        string                         `json:"privateKey"`
+	Host              string                         `json:"host"`
+	Kubeconfig        string                         `json:"kubeconfig"`
+	KubeadminPassword string                         `json:"kubeadminPassword"`
+	SpotPrice         float64                        `json:"spotPrice"`
+	ConsoleURL        string                         `json:"consoleURL"`
+	Placement         Placement                      `json:"placement"`
+	Attempts          []v1alpha1.ProvisioningAttempt `json:"attempts"`
+}
+
+func (*OpenshiftMetadata) ClusterType() ClusterType { return OpenshiftClusterType }
+
+func (m *OpenshiftMetadata) login() hostLogin {
+	return hostLogin{Host: m.Host, Username: m.Username, PrivateKey: m.PrivateKey}
 }
 
 type KindMetadata struct {
-	Username   string  `json:"username"`
-	PrivateKey string  `json:"privateKey"`
-	Host       string  `json:"host"`
-	Kubeconfig string  `json:"kubeconfig"`
-	SpotPrice  float64 `json:"spotPrice"`
+	Username   string                         `json:"username"`
+	PrivateKey string                         `json:"privateKey"`
+	Host       string                         `json:"host"`
+	Kubeconfig string                         `json:"kubeconfig"`
+	SpotPrice  float64                        `json:"spotPrice"`
+	Placement  Placement                      `json:"placement"`
+	Attempts   []v1alpha1.ProvisioningAttempt `json:"attempts"`
+}
+
+func (*KindMetadata) ClusterType() ClusterType { return KindClusterType }
+
+func (m *KindMetadata) login() hostLogin {
+	return hostLogin{Host: m.Host, Username: m.Username, PrivateKey: m.PrivateKey}
 }
 
 type ProvisionCloudCredentials struct {
@@ -0,0 +1,49 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// nestedVirtFamilies are the instance families whose virtual instances expose the virtualization
+// extensions of their CPUs. Bare metal instances of every family do.
+var nestedVirtFamilies = []string{"c8i", "m8i", "r8i"}
+
+// verifyKVMScript loads the KVM module of the CPU of a host when /dev/kvm is missing, and fails
+// when it is still missing.
+const verifyKVMScript = `set -eu
+if [ ! -c /dev/kvm ]; then
+  sudo modprobe kvm_intel 2>/dev/null || sudo modprobe kvm_amd 2>/dev/null || true
+fi
+if [ ! -c /dev/kvm ]; then
+  echo "/dev/kvm is missing; the CPUs of the host do not expose virtualization extensions" >&2
+  exit 1
+fi
+`
+
+// SupportsNestedVirtualization reports whether virtual machines can run on instances of
+// instanceType, i.e. whether it is a bare metal instance type or one of nestedVirtFamilies.
+func SupportsNestedVirtualization(instanceType string) bool {
+	family, size, _ := strings.Cut(instanceType, ".")
+	return strings.HasPrefix(size, "metal") || slices.Contains(nestedVirtFamilies, family)
+}
+
+// withNestedVirtualization returns the instance types of types supporting nested virtualization.
+func withNestedVirtualization(types []string) []string {
+	return slices.DeleteFunc(types, func(t string) bool { return !SupportsNestedVirtualization(t) })
+}
+
+// verifyKVM fails when /dev/kvm is not available on the host of login, an instance of
+// instanceType. instanceType is empty when the placement of the instance could not be looked up.
+func verifyKVM(ctx context.Context, login hostLogin, instanceType string) error {
+	if instanceType == "" {
+		instanceType = "unknown"
+	}
+	if _, err := runHostScript(ctx, login, verifyKVMScript); err != nil {
+		return fmt.Errorf("KVM is not available on host %s, instance type %s, although nestedVirtualizationEnabled is set: %w",
+			login.Host, instanceType, err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package clusters
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+var _ = Describe("nested virtualization", func() {
+	DescribeTable("decides whether an instance type supports nested virtualization",
+		func(instanceType string, expected bool) {
+			Expect(SupportsNestedVirtualization(instanceType)).To(Equal(expected))
+		},
+		Entry("a nested virtualization capable family", "c8i.2xlarge", true),
+		Entry("another nested virtualization capable family", "m8i.4xlarge", true),
+		Entry("a bare metal instance type", "m5.metal", true),
+		Entry("a bare metal instance type with a size", "c7i.metal-24xl", true),
+		Entry("a virtual instance type", "m5.2xlarge", false),
+		Entry("a family prefixed by a capable one", "c8id.2xlarge", false),
+		Entry("a GPU instance type", "g6.xlarge", false),
+	)
+
+	// nestedVirt returns a machine requiring nested virtualization on instanceTypes.
+	nestedVirt := func(instanceTypes ...string) v1alpha1.MachineConfig {
+		return v1alpha1.MachineConfig{CPUs: 8, MemoryGiB: 32, InstanceTypes: instanceTypes, NestedVirtualizationEnabled: true}
+	}
+
+	DescribeTable("filters the candidate instance types",
+		func(machine v1alpha1.MachineConfig, expected []string) {
+			Expect(CandidateInstanceTypes(machine)).To(Equal(expected))
+		},
+		Entry("keeping the capable instance types in order",
+			nestedVirt("m5.2xlarge", "m8i.2xlarge", "m5.metal", "c8i.2xlarge"), []string{"m8i.2xlarge", "m5.metal", "c8i.2xlarge"}),
+		Entry("keeping every instance type when they all are capable",
+			nestedVirt("c8i.2xlarge", "r8i.xlarge"), []string{"c8i.2xlarge", "r8i.xlarge"}),
+		Entry("after removing the excluded instance types",
+			func() v1alpha1.MachineConfig {
+				machine := nestedVirt("m8i.2xlarge", "c8i.2xlarge", "m5.2xlarge")
+				machine.ExcludedInstanceTypes = []string{"m8i.2xlarge"}
+				return machine
+			}(), []string{"c8i.2xlarge"}),
+		Entry("leaving the instance types to derive from cpus and memoryGiB", nestedVirt(), nil),
+		Entry("without nested virtualization",
+			v1alpha1.MachineConfig{InstanceTypes: []string{"m5.2xlarge", "m8i.2xlarge"}}, []string{"m5.2xlarge", "m8i.2xlarge"}),
+	)
+
+	DescribeTable("rejects machines without capable instance types",
+		func(machine v1alpha1.MachineConfig, expected string) {
+			_, err := CandidateInstanceTypes(machine)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("among the allowed instance types",
+			nestedVirt("m5.2xlarge", "c7i.2xlarge"), "none of the instance types [m5.2xlarge c7i.2xlarge] supports nested virtualization"),
+		Entry("once the excluded instance types are removed",
+			func() v1alpha1.MachineConfig {
+				machine := nestedVirt("m5.2xlarge", "m8i.2xlarge")
+				machine.ExcludedInstanceTypes = []string{"m8i.2xlarge"}
+				return machine
+			}(), "none of the instance types [m5.2xlarge] supports nested virtualization"),
+		Entry("among the GPU instance types",
+			func() v1alpha1.MachineConfig {
+				machine := nestedVirt("g6.xlarge")
+				machine.CPUs, machine.MemoryGiB, machine.GPU = 4, 16, true
+				return machine
+			}(), "none of the instance types [g6.xlarge] supports nested virtualization"),
+	)
+
+	It("names the instance type of a host without KVM", func() {
+		// The private key cannot be parsed, so the check fails without connecting to the host.
+		login := hostLogin{Host: "198.51.100.7", Username: "fedora"}
+		err := verifyKVM(context.Background(), login, "c8i.2xlarge")
+		Expect(err).To(MatchError(ContainSubstring(
+			"KVM is not available on host 198.51.100.7, instance type c8i.2xlarge, although nestedVirtualizationEnabled is set")))
+	})
+})
@@ -0,0 +1,182 @@
+package clusters
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// WorkspaceOperation names the provisioning operation a workspace is used for.
+type WorkspaceOperation string
+
+const (
+	WorkspaceProvision   WorkspaceOperation = "provision"
+	WorkspaceDeprovision WorkspaceOperation = "deprovision"
+)
+
+// ErrWorkspaceInUse is returned when an operation is already running in the requested workspace.
+var ErrWorkspaceInUse = errors.New("workspace is already in use")
+
+// WorkspaceManager hands out scratch directories for provisioning operations under a single base
+// directory, typically an emptyDir or PVC mounted in the operator pod. Each operation gets its own
+// directory, keyed by cluster type, ProvisionId and operation, which is removed once it completes.
+type WorkspaceManager struct {
+	baseDir  string
+	maxBytes int64
+
+	mu     sync.Mutex
+	active map[string]struct{}
+}
+
+// Workspace is a scratch directory owned by a single provisioning operation.
+type Workspace struct {
+	// Dir is the absolute path of the workspace directory.
+	Dir string
+
+	manager *WorkspaceManager
+}
+
+// NewWorkspaceManager returns a WorkspaceManager rooted at baseDir, creating it if needed.
+// maxBytes caps the disk usage of all workspaces; zero or lower disables the limit.
+func NewWorkspaceManager(baseDir string, maxBytes int64) (*WorkspaceManager, error) {
+	if baseDir == "" {
+		return nil, fmt.Errorf("workspace base directory must not be empty")
+	}
+	abs, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace base directory %s: %w", baseDir, err)
+	}
+	if err := os.MkdirAll(abs, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create workspace base directory %s: %w", abs, err)
+	}
+	return &WorkspaceManager{
+		baseDir:  abs,
+		maxBytes: maxBytes,
+		active:   map[string]struct{}{},
+	}, nil
+}
+
+var (
+	defaultWorkspacesMu sync.Mutex
+	defaultWorkspaces   *WorkspaceManager
+)
+
+// ConfigureWorkspaces sets the WorkspaceManager used by provisioners created with
+// NewGenericMaptProvisioner. It must be called before the controllers start.
+func ConfigureWorkspaces(baseDir string, maxBytes int64) error {
+	m, err := NewWorkspaceManager(baseDir, maxBytes)
+	if err != nil {
+		return err
+	}
+	defaultWorkspacesMu.Lock()
+	defer defaultWorkspacesMu.Unlock()
+	defaultWorkspaces = m
+	return nil
+}
+
+// DefaultWorkspaces returns the WorkspaceManager used by provisioners created with
+// NewGenericMaptProvisioner. Unless configured otherwise, workspaces live in the temporary directory.
+func DefaultWorkspaces() (*WorkspaceManager, error) {
+	defaultWorkspacesMu.Lock()
+	defer defaultWorkspacesMu.Unlock()
+	if defaultWorkspaces == nil {
+		m, err := NewWorkspaceManager(filepath.Join(os.TempDir(), "mapt-workspaces"), 0)
+		if err != nil {
+			return nil, err
+		}
+		defaultWorkspaces = m
+	}
+	return defaultWorkspaces, nil
+}
+
+// Acquire creates the workspace of an operation on the given cluster. Leftovers of a previous
+// attempt with the same ProvisionId are discarded, so retries always start from an empty directory.
+// The caller must Release the workspace once the operation completes.
+func (m *WorkspaceManager) Acquire(clusterType ClusterType, provisionID string, op WorkspaceOperation) (*Workspace, error) {
+	if provisionID == "" || provisionID == "." || provisionID == ".." || filepath.Base(provisionID) != provisionID {
+		return nil, fmt.Errorf("invalid ProvisionId %q for workspace", provisionID)
+	}
+	dir := filepath.Join(m.baseDir, string(clusterType), provisionID, string(op))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.active[dir]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceInUse, dir)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clean stale workspace %s: %w", dir, err)
+	}
+	if m.maxBytes > 0 {
+		used, err := m.Usage()
+		if err != nil {
+			return nil, err
+		}
+		if used >= m.maxBytes {
+			return nil, fmt.Errorf("workspace disk usage %d bytes reached the limit of %d bytes", used, m.maxBytes)
+		}
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create workspace %s: %w", dir, err)
+	}
+
+	m.active[dir] = struct{}{}
+	return &Workspace{Dir: dir, manager: m}, nil
+}
+
+// Usage returns the number of bytes used by all workspaces.
+func (m *WorkspaceManager) Usage() (int64, error) {
+	var total int64
+	err := filepath.WalkDir(m.baseDir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Workspaces may be removed concurrently while walking.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute workspace disk usage: %w", err)
+	}
+	return total, nil
+}
+
+// Release removes the workspace directory, and the directory of its ProvisionId once no other
+// operation uses it.
+func (w *Workspace) Release() error {
+	m := w.manager
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.active, w.Dir)
+
+	if err := os.RemoveAll(w.Dir); err != nil {
+		return fmt.Errorf("failed to remove workspace %s: %w", w.Dir, err)
+	}
+	// Remove fails on non-empty directories, which is expected while another operation is running.
+	_ = os.Remove(filepath.Dir(w.Dir))
+	return nil
+}
+
+// cleanup releases the workspace, logging failures instead of failing the completed operation.
+func (w *Workspace) cleanup() {
+	if err := w.Release(); err != nil {
+		log.Log.WithName("workspaces").Error(err, "Failed to clean up workspace", "dir", w.Dir)
+	}
+}
@@ -0,0 +1,136 @@
+package clusters
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WorkspaceManager", func() {
+	var manager *WorkspaceManager
+
+	BeforeEach(func() {
+		var err error
+		manager, err = NewWorkspaceManager(GinkgoT().TempDir(), 0)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	// acquired is an operation holding its workspace before the one under test.
+	type acquired struct {
+		clusterType ClusterType
+		provisionID string
+		op          WorkspaceOperation
+	}
+
+	DescribeTable("acquires a workspace",
+		func(held []acquired, clusterType ClusterType, provisionID string, op WorkspaceOperation) {
+			for _, h := range held {
+				_, err := manager.Acquire(h.clusterType, h.provisionID, h.op)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			ws, err := manager.Acquire(clusterType, provisionID, op)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ws.Dir).To(Equal(filepath.Join(manager.baseDir, string(clusterType), provisionID, string(op))))
+			Expect(ws.Dir).To(BeADirectory())
+		},
+		Entry("for a new cluster", nil, KindClusterType, "kind-1", WorkspaceProvision),
+		Entry("while another cluster is provisioned",
+			[]acquired{{KindClusterType, "kind-1", WorkspaceProvision}}, KindClusterType, "kind-2", WorkspaceProvision),
+		Entry("while the same operation runs for another cluster type",
+			[]acquired{{KindClusterType, "cluster-1", WorkspaceProvision}}, OpenshiftClusterType, "cluster-1", WorkspaceProvision),
+		Entry("to deprovision a cluster still being provisioned",
+			[]acquired{{KindClusterType, "kind-1", WorkspaceProvision}}, KindClusterType, "kind-1", WorkspaceDeprovision),
+	)
+
+	DescribeTable("refuses to acquire a workspace",
+		func(held []acquired, provisionID string, op WorkspaceOperation, expected string) {
+			for _, h := range held {
+				_, err := manager.Acquire(h.clusterType, h.provisionID, h.op)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, err := manager.Acquire(KindClusterType, provisionID, op)
+			Expect(err).To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("already in use",
+			[]acquired{{KindClusterType, "kind-1", WorkspaceProvision}}, "kind-1", WorkspaceProvision, ErrWorkspaceInUse.Error()),
+		Entry("without ProvisionId", nil, "", WorkspaceProvision, `invalid ProvisionId ""`),
+		Entry("for the current directory", nil, ".", WorkspaceProvision, `invalid ProvisionId "."`),
+		Entry("for the parent directory", nil, "..", WorkspaceProvision, `invalid ProvisionId ".."`),
+		Entry("outside of the base directory", nil, "../kind-1", WorkspaceProvision, `invalid ProvisionId "../kind-1"`),
+	)
+
+	It("discards the leftovers of a previous attempt", func() {
+		ws, err := manager.Acquire(KindClusterType, "kind-1", WorkspaceProvision)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(ws.Dir, "state"), []byte("stale"), 0o600)).To(Succeed())
+		// The operator restarted without releasing the workspace.
+		restarted, err := NewWorkspaceManager(manager.baseDir, 0)
+		Expect(err).NotTo(HaveOccurred())
+
+		ws, err = restarted.Acquire(KindClusterType, "kind-1", WorkspaceProvision)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filepath.Join(ws.Dir, "state")).NotTo(BeAnExistingFile())
+	})
+
+	It("refuses workspaces once the disk usage limit is reached", func() {
+		limited, err := NewWorkspaceManager(GinkgoT().TempDir(), 4)
+		Expect(err).NotTo(HaveOccurred())
+		ws, err := limited.Acquire(KindClusterType, "kind-1", WorkspaceProvision)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(ws.Dir, "state"), []byte("state"), 0o600)).To(Succeed())
+
+		_, err = limited.Acquire(KindClusterType, "kind-2", WorkspaceProvision)
+		Expect(err).To(MatchError(ContainSubstring("reached the limit of 4 bytes")))
+	})
+
+	DescribeTable("releases workspaces",
+		func(held []WorkspaceOperation, released []WorkspaceOperation, clusterDirRemoved bool) {
+			workspaces := map[WorkspaceOperation]*Workspace{}
+			for _, op := range held {
+				ws, err := manager.Acquire(KindClusterType, "kind-1", op)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.WriteFile(filepath.Join(ws.Dir, "state"), []byte("state"), 0o600)).To(Succeed())
+				workspaces[op] = ws
+			}
+			for _, op := range released {
+				Expect(workspaces[op].Release()).To(Succeed())
+				Expect(workspaces[op].Dir).NotTo(BeADirectory())
+			}
+
+			clusterDir := filepath.Join(manager.baseDir, string(KindClusterType), "kind-1")
+			if clusterDirRemoved {
+				Expect(clusterDir).NotTo(BeADirectory())
+			} else {
+				Expect(clusterDir).To(BeADirectory())
+			}
+			// Released workspaces can be acquired again, the others are still in use.
+			for _, op := range held {
+				ws, err := manager.Acquire(KindClusterType, "kind-1", op)
+				if slices.Contains(released, op) {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(ws.Release()).To(Succeed())
+				} else {
+					Expect(err).To(MatchError(ErrWorkspaceInUse))
+				}
+			}
+		},
+		Entry("removing the cluster directory once it is provisioned",
+			[]WorkspaceOperation{WorkspaceProvision}, []WorkspaceOperation{WorkspaceProvision}, true),
+		Entry("removing the cluster directory once it is deleted",
+			[]WorkspaceOperation{WorkspaceDeprovision}, []WorkspaceOperation{WorkspaceDeprovision}, true),
+		Entry("keeping the cluster directory while it is deleted during its provisioning",
+			[]WorkspaceOperation{WorkspaceProvision, WorkspaceDeprovision}, []WorkspaceOperation{WorkspaceProvision}, false),
+		Entry("removing the cluster directory once it is deleted after an abandoned provisioning",
+			[]WorkspaceOperation{WorkspaceProvision, WorkspaceDeprovision},
+			[]WorkspaceOperation{WorkspaceProvision, WorkspaceDeprovision}, true),
+	)
+})
+
+func TestClusters(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Clusters Suite")
+}
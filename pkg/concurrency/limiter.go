@@ -0,0 +1,223 @@
+// Package concurrency limits how many provisioning operations run against the cloud at once.
+//
+// Every Provision and Deprovision call must hold a slot of the Limiter. Slots are bounded both
+// globally and per cloud account (credentials Secret). Clusters that cannot get a slot wait in a
+// queue ordered by priority and arrival time, so capacity is handed out fairly instead of to
+// whichever reconcile worker happens to run first.
+package concurrency
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// MaxConcurrentOperationsAnnotation can be set on a credentials Secret to override the
+	// per-account limit for the account it holds.
+	MaxConcurrentOperationsAnnotation = "mapt.redhat.com/max-concurrent-operations"
+
+	// waiterTTL is how long a queued request is kept without being polled again. Clusters that
+	// were deleted or stopped polling leave the queue after this period.
+	waiterTTL = 5 * time.Minute
+)
+
+// Request identifies a cluster asking for a provisioning slot.
+type Request struct {
+	// ID uniquely identifies the cluster, usually its UID.
+	ID string
+	// Account identifies the cloud account the operation runs against.
+	Account string
+	// AccountLimit overrides the default per-account limit when greater than zero.
+	AccountLimit int
+	// Priority orders the queue; higher values are served first.
+	Priority int32
+	// CreatedAt orders requests with the same priority; older requests are served first.
+	CreatedAt time.Time
+}
+
+type waiter struct {
+	req      Request
+	seq      uint64
+	lastSeen time.Time
+}
+
+// Limiter hands out provisioning slots. It is safe for concurrent use.
+type Limiter struct {
+	mu         sync.Mutex
+	global     int
+	perAccount int
+	running    map[string]Request
+	waiting    map[string]*waiter
+	seq        uint64
+	now        func() time.Time
+}
+
+// NewLimiter returns a Limiter allowing at most global operations in total and perAccount
+// operations per cloud account. A limit lower than or equal to zero disables that limit.
+func NewLimiter(global, perAccount int) *Limiter {
+	return &Limiter{
+		global:     global,
+		perAccount: perAccount,
+		running:    map[string]Request{},
+		waiting:    map[string]*waiter{},
+		now:        time.Now,
+	}
+}
+
+var (
+	sharedMu sync.Mutex
+	shared   = NewLimiter(0, 0)
+)
+
+// ConfigureShared replaces the limiter shared by all controllers of the operator.
+// It must be called before the controllers start.
+func ConfigureShared(global, perAccount int) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	shared = NewLimiter(global, perAccount)
+}
+
+// Shared returns the limiter shared by all controllers of the operator.
+// Unless configured otherwise, it does not limit anything.
+func Shared() *Limiter {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	return shared
+}
+
+// Acquire tries to reserve a slot for req. When no slot is available the request is queued and
+// its 1-based position in the queue is returned. Acquire is idempotent: a request already holding
+// a slot is granted again, and a queued request keeps its place while it keeps polling.
+func (l *Limiter) Acquire(req Request) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.running[req.ID]; ok {
+		return true, 0
+	}
+
+	now := l.now()
+	l.pruneWaiters(now)
+	w, ok := l.waiting[req.ID]
+	if !ok {
+		l.seq++
+		w = &waiter{seq: l.seq}
+		l.waiting[req.ID] = w
+	}
+	w.req = req
+	w.lastSeen = now
+
+	queue := l.queue()
+	position := 0
+	for i, q := range queue {
+		if q.req.ID == req.ID {
+			position = i + 1
+			break
+		}
+	}
+
+	free := len(l.running)
+	if l.global > 0 {
+		free = l.global - len(l.running)
+		if free <= 0 {
+			return false, position
+		}
+	}
+
+	// Grant the slot only if req is among the first eligible waiters. Waiters whose account is
+	// saturated are skipped so that a busy account does not block the others.
+	accounts := l.runningPerAccount()
+	for _, q := range queue {
+		if l.global > 0 && free == 0 {
+			break
+		}
+		if !l.accountHasCapacity(q.req, accounts) {
+			continue
+		}
+		if q.req.ID == req.ID {
+			delete(l.waiting, req.ID)
+			l.running[req.ID] = req
+			return true, 0
+		}
+		accounts[q.req.Account]++
+		free--
+	}
+	return false, position
+}
+
+// Release frees the slot held by the request with the given id, if any.
+func (l *Limiter) Release(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.running, id)
+}
+
+// Forget removes the request with the given id from the queue, e.g. when its cluster is deleted.
+func (l *Limiter) Forget(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.waiting, id)
+}
+
+// queue returns the waiters ordered by priority, creation time and arrival.
+func (l *Limiter) queue() []*waiter {
+	queue := make([]*waiter, 0, len(l.waiting))
+	for _, w := range l.waiting {
+		queue = append(queue, w)
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		a, b := queue[i], queue[j]
+		if a.req.Priority != b.req.Priority {
+			return a.req.Priority > b.req.Priority
+		}
+		if !a.req.CreatedAt.Equal(b.req.CreatedAt) {
+			return a.req.CreatedAt.Before(b.req.CreatedAt)
+		}
+		return a.seq < b.seq
+	})
+	return queue
+}
+
+func (l *Limiter) runningPerAccount() map[string]int {
+	accounts := map[string]int{}
+	for _, r := range l.running {
+		accounts[r.Account]++
+	}
+	return accounts
+}
+
+func (l *Limiter) accountHasCapacity(req Request, accounts map[string]int) bool {
+	limit := l.perAccount
+	if req.AccountLimit > 0 {
+		limit = req.AccountLimit
+	}
+	return limit <= 0 || accounts[req.Account] < limit
+}
+
+func (l *Limiter) pruneWaiters(now time.Time) {
+	for id, w := range l.waiting {
+		if now.Sub(w.lastSeen) > waiterTTL {
+			delete(l.waiting, id)
+		}
+	}
+}
+
+// AccountLimit returns the per-account limit configured on the credentials Secret through
+// MaxConcurrentOperationsAnnotation, or zero when the Secret does not override it.
+func AccountLimit(ctx context.Context, c client.Reader, key client.ObjectKey) int {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, key, secret); err != nil {
+		return 0
+	}
+	limit, err := strconv.Atoi(secret.Annotations[MaxConcurrentOperationsAnnotation])
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,166 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Limiter", func() {
+	var (
+		limiter *Limiter
+		now     time.Time
+		base    time.Time
+	)
+
+	request := func(id, account string, priority int32, age time.Duration) Request {
+		return Request{ID: id, Account: account, Priority: priority, CreatedAt: base.Add(-age)}
+	}
+
+	BeforeEach(func() {
+		base = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		now = base
+		limiter = NewLimiter(1, 0)
+		limiter.now = func() time.Time { return now }
+	})
+
+	It("grants slots up to the global limit", func() {
+		granted, _ := limiter.Acquire(request("a", "acct", 0, 0))
+		Expect(granted).To(BeTrue())
+
+		granted, position := limiter.Acquire(request("b", "acct", 0, 0))
+		Expect(granted).To(BeFalse())
+		Expect(position).To(Equal(1))
+	})
+
+	It("is idempotent for requests already holding a slot", func() {
+		Expect(limiter.Acquire(request("a", "acct", 0, 0))).To(BeTrue())
+		granted, _ := limiter.Acquire(request("a", "acct", 0, 0))
+		Expect(granted).To(BeTrue())
+	})
+
+	It("admits waiters in creation order once a slot is released", func() {
+		limiter.Acquire(request("running", "acct", 0, 0))
+		_, youngPos := limiter.Acquire(request("young", "acct", 0, time.Minute))
+		_, oldPos := limiter.Acquire(request("old", "acct", 0, time.Hour))
+		Expect(youngPos).To(Equal(1))
+		Expect(oldPos).To(Equal(1))
+
+		limiter.Release("running")
+
+		granted, position := limiter.Acquire(request("young", "acct", 0, time.Minute))
+		Expect(granted).To(BeFalse())
+		Expect(position).To(Equal(2))
+
+		granted, _ = limiter.Acquire(request("old", "acct", 0, time.Hour))
+		Expect(granted).To(BeTrue())
+	})
+
+	It("admits higher priority waiters first", func() {
+		limiter.Acquire(request("running", "acct", 0, 0))
+		limiter.Acquire(request("old", "acct", 0, time.Hour))
+		limiter.Acquire(request("urgent", "acct", 10, 0))
+
+		limiter.Release("running")
+
+		granted, position := limiter.Acquire(request("old", "acct", 0, time.Hour))
+		Expect(granted).To(BeFalse())
+		Expect(position).To(Equal(2))
+
+		granted, _ = limiter.Acquire(request("urgent", "acct", 10, 0))
+		Expect(granted).To(BeTrue())
+	})
+
+	It("does not let a saturated account block other accounts", func() {
+		limiter = NewLimiter(2, 1)
+		limiter.now = func() time.Time { return now }
+
+		Expect(limiter.Acquire(request("a1", "a", 0, time.Hour))).To(BeTrue())
+		granted, _ := limiter.Acquire(request("a2", "a", 0, time.Hour))
+		Expect(granted).To(BeFalse())
+
+		granted, _ = limiter.Acquire(request("b1", "b", 0, 0))
+		Expect(granted).To(BeTrue())
+	})
+
+	It("honours the per-account override of a request", func() {
+		limiter = NewLimiter(0, 1)
+		limiter.now = func() time.Time { return now }
+
+		req := func(id string) Request {
+			r := request(id, "a", 0, 0)
+			r.AccountLimit = 2
+			return r
+		}
+		Expect(limiter.Acquire(req("a1"))).To(BeTrue())
+		Expect(limiter.Acquire(req("a2"))).To(BeTrue())
+		granted, _ := limiter.Acquire(req("a3"))
+		Expect(granted).To(BeFalse())
+	})
+
+	It("drops waiters that stopped polling", func() {
+		limiter.Acquire(request("running", "acct", 0, 0))
+		limiter.Acquire(request("gone", "acct", 0, time.Hour))
+
+		now = now.Add(waiterTTL + time.Second)
+		limiter.Release("running")
+
+		granted, _ := limiter.Acquire(request("new", "acct", 0, 0))
+		Expect(granted).To(BeTrue())
+	})
+
+	It("removes forgotten waiters from the queue", func() {
+		limiter.Acquire(request("running", "acct", 0, 0))
+		limiter.Acquire(request("deleted", "acct", 0, time.Hour))
+		limiter.Forget("deleted")
+
+		_, position := limiter.Acquire(request("next", "acct", 0, 0))
+		Expect(position).To(Equal(1))
+	})
+})
+
+var _ = Describe("AccountLimit", func() {
+	var (
+		ctx context.Context
+		key client.ObjectKey
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		key = client.ObjectKey{Namespace: "default", Name: "aws-credentials"}
+	})
+
+	secretWith := func(annotations map[string]string) *corev1.Secret {
+		return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name: key.Name, Namespace: key.Namespace, Annotations: annotations,
+		}}
+	}
+
+	It("reads the limit from the Secret annotation", func() {
+		c := fake.NewClientBuilder().WithObjects(secretWith(map[string]string{MaxConcurrentOperationsAnnotation: "3"})).Build()
+		Expect(AccountLimit(ctx, c, key)).To(Equal(3))
+	})
+
+	It("returns zero for invalid annotations", func() {
+		c := fake.NewClientBuilder().WithObjects(secretWith(map[string]string{MaxConcurrentOperationsAnnotation: "many"})).Build()
+		Expect(AccountLimit(ctx, c, key)).To(BeZero())
+	})
+
+	It("returns zero when the Secret does not exist", func() {
+		c := fake.NewClientBuilder().Build()
+		Expect(AccountLimit(ctx, c, key)).To(BeZero())
+	})
+})
+
+func TestConcurrency(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Concurrency Suite")
+}
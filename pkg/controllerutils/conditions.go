@@ -0,0 +1,133 @@
+package controllerutils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExpiringWindow is how long before its expiration a cluster reports the Expiring condition.
+var ExpiringWindow = time.Hour
+
+// readyDependencies are the conditions the Ready condition aggregates, in the order they are
+// reported when they are not met.
+var readyDependencies = []string{
+	v1alpha1.ConditionInfrastructureProvisioned,
+	v1alpha1.ConditionAccessSecretReady,
+	v1alpha1.ConditionHealthy,
+}
+
+// optionalReadyDependencies are conditions the Ready condition only aggregates when they are
+// reported, e.g. by clusters with add-ons or a GPU stack.
+var optionalReadyDependencies = []string{
+	v1alpha1.ConditionGPUReady,
+	v1alpha1.ConditionAddonsReady,
+}
+
+// LifecycleState classifies the phase of a cluster for the kstatus conditions.
+type LifecycleState int
+
+const (
+	// LifecycleInProgress means the controller is working towards the desired state.
+	LifecycleInProgress LifecycleState = iota
+	// LifecycleCurrent means the cluster matches the desired state.
+	LifecycleCurrent
+	// LifecycleStalled means the controller cannot make progress without user intervention.
+	LifecycleStalled
+)
+
+// SetCondition sets a condition with meta.SetStatusCondition semantics, recording the generation
+// it was computed for. LastTransitionTime only changes when the status does.
+func SetCondition(conds *[]metav1.Condition, generation int64, condType string, status metav1.ConditionStatus, reason, msg string) {
+	meta.SetStatusCondition(conds, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            msg,
+	})
+}
+
+// SyncAggregateConditions derives Ready, Reconciling and Stalled from the detailed conditions and
+// the lifecycle state of a cluster. reason and msg describe the current phase; they are used for
+// Reconciling and Stalled. Duplicate conditions left behind by earlier operator versions are
+// dropped, keeping the most recent entry of each type.
+func SyncAggregateConditions(conds *[]metav1.Condition, generation int64, state LifecycleState, reason, msg string) {
+	dedupeConditions(conds)
+
+	infra := meta.FindStatusCondition(*conds, v1alpha1.ConditionInfrastructureProvisioned)
+	if infra == nil || infra.Status != metav1.ConditionTrue {
+		SetCondition(conds, generation, v1alpha1.ConditionHealthy, metav1.ConditionUnknown,
+			"InfrastructureNotProvisioned", "Health is assessed once the cluster infrastructure is provisioned.")
+	}
+
+	ready := metav1.Condition{Status: metav1.ConditionTrue, Reason: "Ready", Message: "The cluster is ready for use."}
+	for _, t := range readyDependencies {
+		c := meta.FindStatusCondition(*conds, t)
+		if c == nil {
+			ready = metav1.Condition{Status: metav1.ConditionFalse, Reason: "Pending", Message: fmt.Sprintf("%s has not been reported yet.", t)}
+			break
+		}
+		if c.Status != metav1.ConditionTrue {
+			ready = metav1.Condition{Status: metav1.ConditionFalse, Reason: c.Reason, Message: c.Message}
+			break
+		}
+	}
+	for _, t := range optionalReadyDependencies {
+		if ready.Status != metav1.ConditionTrue {
+			break
+		}
+		if c := meta.FindStatusCondition(*conds, t); c != nil && c.Status != metav1.ConditionTrue {
+			ready = metav1.Condition{Status: metav1.ConditionFalse, Reason: c.Reason, Message: c.Message}
+		}
+	}
+	SetCondition(conds, generation, v1alpha1.ConditionReady, ready.Status, ready.Reason, ready.Message)
+
+	switch state {
+	case LifecycleInProgress:
+		SetCondition(conds, generation, v1alpha1.ConditionReconciling, metav1.ConditionTrue, reason, msg)
+		meta.RemoveStatusCondition(conds, v1alpha1.ConditionStalled)
+	case LifecycleStalled:
+		SetCondition(conds, generation, v1alpha1.ConditionStalled, metav1.ConditionTrue, reason, msg)
+		meta.RemoveStatusCondition(conds, v1alpha1.ConditionReconciling)
+	default:
+		meta.RemoveStatusCondition(conds, v1alpha1.ConditionReconciling)
+		meta.RemoveStatusCondition(conds, v1alpha1.ConditionStalled)
+	}
+}
+
+// ExpiringCondition returns the status, reason and message of the Expiring condition of a cluster
+// that expires at expiration, or never when expiration is nil.
+func ExpiringCondition(expiration *metav1.Time, now time.Time) (metav1.ConditionStatus, string, string) {
+	if expiration == nil {
+		return metav1.ConditionFalse, "NoExpiration", "The cluster has no expiration."
+	}
+	msg := fmt.Sprintf("The cluster expires at %s.", expiration.UTC().Format(time.RFC3339))
+	if expiration.Sub(now) <= ExpiringWindow {
+		return metav1.ConditionTrue, "ExpirationApproaching", msg
+	}
+	return metav1.ConditionFalse, "ExpirationScheduled", msg
+}
+
+// dedupeConditions keeps the last condition of each type, preserving the order of first appearance.
+func dedupeConditions(conds *[]metav1.Condition) {
+	last := map[string]metav1.Condition{}
+	var order []string
+	for _, c := range *conds {
+		if _, seen := last[c.Type]; !seen {
+			order = append(order, c.Type)
+		}
+		last[c.Type] = c
+	}
+	if len(order) == len(*conds) {
+		return
+	}
+	deduped := make([]metav1.Condition, 0, len(order))
+	for _, t := range order {
+		deduped = append(deduped, last[t])
+	}
+	*conds = deduped
+}
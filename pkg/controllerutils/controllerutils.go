@@ -55,6 +55,20 @@ func RequeueAfter(d time.Duration) reconcile.Result {
 	return reconcile.Result{RequeueAfter: d}
 }
 
+// RequeueBy shortens the requeue delay of result so that the object is reconciled again by at,
+// e.g. when its schedule brings it up or down. Immediate requeues are kept, and a nil at leaves
+// result unchanged.
+func RequeueBy(result reconcile.Result, at *metav1.Time, now time.Time) reconcile.Result {
+	if at == nil || (result.Requeue && result.RequeueAfter == 0) {
+		return result
+	}
+	d := max(at.Sub(now), time.Second)
+	if result.RequeueAfter == 0 || d < result.RequeueAfter {
+		result.RequeueAfter = d
+	}
+	return result
+}
+
 // LogError logs an error with context.
 func LogError(log logr.Logger, err error, msg string) error {
 	if err != nil {
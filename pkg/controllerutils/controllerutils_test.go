@@ -6,17 +6,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
 	. "github.com/mapt-oss/mapt-operator/pkg/controllerutils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 var _ = Describe("FinalizerManager", func() {
@@ -92,6 +95,26 @@ var _ = Describe("RequeueAfter", func() {
 	})
 })
 
+var _ = Describe("RequeueBy", func() {
+	now := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	at := metav1.NewTime(now.Add(time.Hour))
+
+	It("requeues at the given time when it comes first", func() {
+		Expect(RequeueBy(RequeueAfter(10*time.Hour), &at, now).RequeueAfter).To(Equal(time.Hour))
+		Expect(RequeueBy(reconcile.Result{}, &at, now).RequeueAfter).To(Equal(time.Hour))
+	})
+
+	It("keeps earlier and immediate requeues", func() {
+		Expect(RequeueBy(RequeueAfter(time.Minute), &at, now).RequeueAfter).To(Equal(time.Minute))
+		Expect(RequeueBy(reconcile.Result{Requeue: true}, &at, now)).To(Equal(reconcile.Result{Requeue: true}))
+		Expect(RequeueBy(RequeueAfter(time.Minute), nil, now).RequeueAfter).To(Equal(time.Minute))
+	})
+
+	It("requeues shortly when the time has passed", func() {
+		Expect(RequeueBy(reconcile.Result{}, &at, now.Add(2*time.Hour)).RequeueAfter).To(Equal(time.Second))
+	})
+})
+
 var _ = Describe("LogError", func() {
 	It("logs and returns the error", func() {
 		err := errors.New("some error")
@@ -152,7 +175,323 @@ var _ = Describe("FormatPrice", func() {
 	})
 })
 
+var _ = Describe("CalculateCost", func() {
+	start := metav1.NewTime(time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC))
+	now := start.Add(90 * time.Minute)
+	rate := HourlyRate{Instance: 0.5}
+
+	It("reports only the hourly rate when provisioning has not started", func() {
+		cost := CalculateCost(rate, nil, nil, 0, now)
+		Expect(cost.HourlyRateUSD).To(Equal("0.5000"))
+		Expect(cost.RunningHours).To(Equal("0.0000"))
+		Expect(cost.AccruedUSD).To(Equal("0.0000"))
+		Expect(cost.ProjectedTotalUSD).To(BeEmpty())
+	})
+
+	It("computes running hours and accrued cost", func() {
+		cost := CalculateCost(rate, &start, nil, 0, now)
+		Expect(cost.RunningHours).To(Equal("1.5000"))
+		Expect(cost.AccruedUSD).To(Equal("0.7500"))
+		Expect(cost.ProjectedTotalUSD).To(BeEmpty())
+	})
+
+	It("only changes once a minute", func() {
+		cost := CalculateCost(rate, &start, nil, 0, now.Add(59*time.Second))
+		Expect(cost).To(Equal(CalculateCost(rate, &start, nil, 0, now)))
+		Expect(CalculateCost(rate, &start, nil, 0, now.Add(time.Minute)).RunningHours).To(Equal("1.5167"))
+	})
+
+	It("projects the total cost at expiration", func() {
+		expiration := metav1.NewTime(start.Add(4 * time.Hour))
+		cost := CalculateCost(rate, &start, &expiration, 0, now)
+		Expect(cost.ProjectedTotalUSD).To(Equal("2.0000"))
+	})
+
+	It("excludes hibernated time", func() {
+		expiration := metav1.NewTime(start.Add(4 * time.Hour))
+		cost := CalculateCost(rate, &start, &expiration, time.Hour, now)
+		Expect(cost.RunningHours).To(Equal("0.5000"))
+		Expect(cost.AccruedUSD).To(Equal("0.2500"))
+		Expect(cost.ProjectedTotalUSD).To(Equal("1.5000"))
+	})
+
+	It("includes the price of the root volume in the hourly rate", func() {
+		cost := CalculateCost(HourlyRate{Instance: 0.5, Storage: 0.25}, &start, nil, 0, now)
+		Expect(cost.HourlyRateUSD).To(Equal("0.7500"))
+		Expect(cost.StorageHourlyRateUSD).To(Equal("0.2500"))
+		Expect(cost.AccruedUSD).To(Equal("1.1250"))
+	})
+
+	It("keeps charging the root volume while hibernated", func() {
+		expiration := metav1.NewTime(start.Add(4 * time.Hour))
+		cost := CalculateCost(HourlyRate{Instance: 0.5, Storage: 0.25}, &start, &expiration, time.Hour, now)
+		Expect(cost.RunningHours).To(Equal("0.5000"))
+		// 0.5 running hours of the instance and 1.5 hours of the root volume.
+		Expect(cost.AccruedUSD).To(Equal("0.6250"))
+		// 3 running hours of the instance and 4 hours of the root volume.
+		Expect(cost.ProjectedTotalUSD).To(Equal("2.5000"))
+	})
+})
+
+var _ = Describe("AddUsage", func() {
+	It("adds the usage of the previous infrastructure", func() {
+		previous := CarryOver(&v1alpha1.ClusterCost{HourlyRateUSD: "0.5000", RunningHours: "2.0000", AccruedUSD: "1.0000"})
+		cost := AddUsage(&v1alpha1.ClusterCost{
+			HourlyRateUSD:     "0.2000",
+			RunningHours:      "1.0000",
+			AccruedUSD:        "0.2000",
+			ProjectedTotalUSD: "0.8000",
+		}, previous)
+		Expect(cost.HourlyRateUSD).To(Equal("0.2000"))
+		Expect(cost.RunningHours).To(Equal("3.0000"))
+		Expect(cost.AccruedUSD).To(Equal("1.2000"))
+		Expect(cost.ProjectedTotalUSD).To(Equal("1.8000"))
+	})
+
+	It("leaves the cost of a cluster without previous infrastructure unchanged", func() {
+		cost := &v1alpha1.ClusterCost{HourlyRateUSD: "0.2000", RunningHours: "1.0000", AccruedUSD: "0.2000"}
+		Expect(AddUsage(cost.DeepCopy(), nil)).To(Equal(cost))
+	})
+})
+
+var _ = Describe("ParseHourlyRate", func() {
+	DescribeTable("parses the hourly rate of a cluster cost",
+		func(cost *v1alpha1.ClusterCost, expected HourlyRate) {
+			Expect(ParseHourlyRate(cost)).To(Equal(expected))
+		},
+		Entry("without cost", nil, HourlyRate{}),
+		Entry("without root volume price", &v1alpha1.ClusterCost{HourlyRateUSD: "0.5000"}, HourlyRate{Instance: 0.5}),
+		Entry("with the root volume price",
+			&v1alpha1.ClusterCost{HourlyRateUSD: "0.7500", StorageHourlyRateUSD: "0.2500"}, HourlyRate{Instance: 0.5, Storage: 0.25}),
+	)
+
+	It("round-trips the rate reported by CalculateCost", func() {
+		rate := HourlyRate{Instance: 0.5, Storage: 0.25}
+		Expect(ParseHourlyRate(CalculateCost(rate, nil, nil, 0, time.Now()))).To(Equal(rate))
+	})
+
+	It("rejects malformed amounts", func() {
+		_, err := ParseHourlyRate(&v1alpha1.ClusterCost{HourlyRateUSD: "0.7500", StorageHourlyRateUSD: "$0.25"})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("ExpirationTime", func() {
+	start := metav1.NewTime(time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	It("prefers an explicit expiration timestamp", func() {
+		expiration := metav1.NewTime(start.Add(time.Hour))
+		ttl := int64(7200)
+		Expect(ExpirationTime(&expiration, &start, &v1alpha1.TerminationPolicy{DeleteAfterSeconds: &ttl})).To(Equal(&expiration))
+	})
+
+	It("derives the expiration from the termination policy", func() {
+		ttl := int64(7200)
+		result := ExpirationTime(nil, &start, &v1alpha1.TerminationPolicy{DeleteAfterSeconds: &ttl})
+		Expect(result).NotTo(BeNil())
+		Expect(result.Time).To(Equal(start.Add(2 * time.Hour)))
+	})
+
+	It("returns nil without a TTL", func() {
+		Expect(ExpirationTime(nil, &start, nil)).To(BeNil())
+	})
+})
+
+var _ = Describe("Lifetime", func() {
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	ttl := int64(7200)
+
+	It("adds the extensions to the TTL", func() {
+		lifetime := Lifetime{
+			Policy:     &v1alpha1.TerminationPolicy{DeleteAfterSeconds: &ttl, ExtendBy: &metav1.Duration{Duration: time.Hour}},
+			Extensions: 30 * time.Minute,
+		}
+		Expect(lifetime.Expiration(start).Time).To(Equal(start.Add(3*time.Hour + 30*time.Minute)))
+	})
+
+	It("expires an inactive cluster before its TTL", func() {
+		lastActivity := start.Add(30 * time.Minute)
+		lifetime := Lifetime{
+			Policy:       &v1alpha1.TerminationPolicy{DeleteAfterSeconds: &ttl, InactivityTimeout: &metav1.Duration{Duration: time.Hour}},
+			LastActivity: &lastActivity,
+		}
+		Expect(lifetime.Expiration(start).Time).To(Equal(start.Add(90 * time.Minute)))
+	})
+
+	It("caps the lifetime, extensions included", func() {
+		maxLifetime := 4 * time.Hour
+		lifetime := Lifetime{
+			Policy:     &v1alpha1.TerminationPolicy{DeleteAfterSeconds: &ttl},
+			Extensions: 24 * time.Hour,
+			Max:        &maxLifetime,
+		}
+		Expect(lifetime.Expiration(start).Time).To(Equal(start.Add(maxLifetime)))
+		Expect(Lifetime{Max: &maxLifetime}.Expiration(start).Time).To(Equal(start.Add(maxLifetime)))
+	})
+
+	It("returns nil when nothing bounds the lifetime", func() {
+		Expect(Lifetime{}.Expiration(start)).To(BeNil())
+		Expect(Lifetime{Policy: &v1alpha1.TerminationPolicy{InactivityTimeout: &metav1.Duration{Duration: time.Hour}}}.Expiration(start)).To(BeNil())
+	})
+})
+
+var _ = Describe("ParseAmount", func() {
+	It("parses amounts rendered by FormatAmount", func() {
+		value, err := ParseAmount(FormatAmount(0.123456))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(BeNumerically("~", 0.1235, 0.00001))
+	})
+})
+
 func TestControllerUtils(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "ControllerUtils Suite")
 }
+
+var _ = Describe("ProvisioningDeadline", func() {
+	start := metav1.NewTime(time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC))
+
+	It("adds the timeout to the start time", func() {
+		deadline := ProvisioningDeadline(&start, &metav1.Duration{Duration: 30 * time.Minute})
+		Expect(deadline).To(HaveValue(Equal(start.Add(30 * time.Minute))))
+	})
+
+	It("returns nil without a timeout or a start time", func() {
+		Expect(ProvisioningDeadline(&start, nil)).To(BeNil())
+		Expect(ProvisioningDeadline(nil, &metav1.Duration{Duration: time.Minute})).To(BeNil())
+	})
+})
+
+var _ = Describe("ProvisioningContext", func() {
+	var (
+		ctx        context.Context
+		testObj    *corev1.ConfigMap
+		fakeClient client.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		testObj = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: "default"}}
+		fakeClient = fake.NewClientBuilder().WithObjects(testObj).Build()
+
+		interval := DeletionPollInterval
+		DeletionPollInterval = 10 * time.Millisecond
+		DeferCleanup(func() { DeletionPollInterval = interval })
+	})
+
+	It("is cancelled with ErrProvisioningTimedOut once the deadline passes", func() {
+		deadline := time.Now().Add(-time.Second)
+		runCtx, cancel := ProvisioningContext(ctx, fakeClient, testObj, &deadline)
+		defer cancel()
+
+		Eventually(runCtx.Done()).Should(BeClosed())
+		Expect(context.Cause(runCtx)).To(MatchError(ErrProvisioningTimedOut))
+	})
+
+	It("is cancelled with ErrObjectDeleted once the object is deleted", func() {
+		runCtx, cancel := ProvisioningContext(ctx, fakeClient, testObj, nil)
+		defer cancel()
+
+		Consistently(runCtx.Done(), 50*time.Millisecond).ShouldNot(BeClosed())
+		Expect(fakeClient.Delete(ctx, testObj)).To(Succeed())
+		Eventually(runCtx.Done()).Should(BeClosed())
+		Expect(context.Cause(runCtx)).To(MatchError(ErrObjectDeleted))
+	})
+})
+
+var _ = Describe("SyncAggregateConditions", func() {
+	var conds []metav1.Condition
+
+	BeforeEach(func() {
+		conds = []metav1.Condition{}
+	})
+
+	It("is Ready once every dependency is True", func() {
+		for _, t := range []string{v1alpha1.ConditionInfrastructureProvisioned, v1alpha1.ConditionAccessSecretReady, v1alpha1.ConditionHealthy} {
+			SetCondition(&conds, 2, t, metav1.ConditionTrue, "Done", "done")
+		}
+		SyncAggregateConditions(&conds, 2, LifecycleCurrent, "Running", "running")
+
+		ready := meta.FindStatusCondition(conds, v1alpha1.ConditionReady)
+		Expect(ready).NotTo(BeNil())
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		Expect(ready.ObservedGeneration).To(Equal(int64(2)))
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionReconciling)).To(BeNil())
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionStalled)).To(BeNil())
+	})
+
+	It("waits for the add-ons when they are reported", func() {
+		for _, t := range []string{v1alpha1.ConditionInfrastructureProvisioned, v1alpha1.ConditionAccessSecretReady, v1alpha1.ConditionHealthy} {
+			SetCondition(&conds, 1, t, metav1.ConditionTrue, "Done", "done")
+		}
+		SetCondition(&conds, 1, v1alpha1.ConditionAddonsReady, metav1.ConditionFalse, "AddonsInstalling", "installing")
+		SyncAggregateConditions(&conds, 1, LifecycleInProgress, "Running", "running")
+
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionReady)).To(And(
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", "AddonsInstalling"),
+		))
+	})
+
+	It("reports the first unmet dependency and marks the cluster as reconciling", func() {
+		SetCondition(&conds, 1, v1alpha1.ConditionInfrastructureProvisioned, metav1.ConditionFalse, "ProvisioningStarted", "in progress")
+		SyncAggregateConditions(&conds, 1, LifecycleInProgress, "Provisioning", "provisioning")
+
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionReady)).To(And(
+			HaveField("Status", metav1.ConditionFalse),
+			HaveField("Reason", "ProvisioningStarted"),
+		))
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionHealthy)).To(HaveField("Status", metav1.ConditionUnknown))
+		Expect(meta.IsStatusConditionTrue(conds, v1alpha1.ConditionReconciling)).To(BeTrue())
+	})
+
+	It("replaces Reconciling with Stalled on failure", func() {
+		SyncAggregateConditions(&conds, 1, LifecycleInProgress, "Provisioning", "provisioning")
+		SyncAggregateConditions(&conds, 1, LifecycleStalled, "Failed", "provisioning failed")
+
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionReconciling)).To(BeNil())
+		Expect(meta.FindStatusCondition(conds, v1alpha1.ConditionStalled)).To(HaveField("Message", "provisioning failed"))
+	})
+
+	It("drops duplicate conditions, keeping the latest", func() {
+		conds = []metav1.Condition{
+			{Type: v1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "ProvisioningStarted"},
+			{Type: v1alpha1.ConditionReady, Status: metav1.ConditionTrue, Reason: "Provisioned"},
+			{Type: v1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "Deleting"},
+		}
+		SyncAggregateConditions(&conds, 1, LifecycleInProgress, "Deleting", "deleting")
+
+		var ready int
+		for _, c := range conds {
+			if c.Type == v1alpha1.ConditionReady {
+				ready++
+			}
+		}
+		Expect(ready).To(Equal(1))
+	})
+})
+
+var _ = Describe("ExpiringCondition", func() {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	It("is False without an expiration", func() {
+		status, reason, _ := ExpiringCondition(nil, now)
+		Expect(status).To(Equal(metav1.ConditionFalse))
+		Expect(reason).To(Equal("NoExpiration"))
+	})
+
+	It("is True within the expiring window", func() {
+		expiration := metav1.NewTime(now.Add(30 * time.Minute))
+		status, reason, msg := ExpiringCondition(&expiration, now)
+		Expect(status).To(Equal(metav1.ConditionTrue))
+		Expect(reason).To(Equal("ExpirationApproaching"))
+		Expect(msg).To(ContainSubstring("2025-01-01T12:30:00Z"))
+	})
+
+	It("is False while the expiration is further away", func() {
+		expiration := metav1.NewTime(now.Add(5 * time.Hour))
+		status, reason, _ := ExpiringCondition(&expiration, now)
+		Expect(status).To(Equal(metav1.ConditionFalse))
+		Expect(reason).To(Equal("ExpirationScheduled"))
+	})
+})
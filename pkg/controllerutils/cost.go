@@ -0,0 +1,181 @@
+package controllerutils
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// costResolution is the precision of the running time of a cluster. Truncating it keeps the cost
+// stable between reconciles, so refreshing it patches the status at most once per period instead
+// of on every reconcile, each patch triggering the next one.
+const costResolution = time.Minute
+
+// HourlyRate is the hourly price paid for a cluster, in USD.
+type HourlyRate struct {
+	// Instance is the price of the instance, which is not paid while the cluster is hibernated.
+	Instance float64
+	// Storage is the price of the EBS root volume of the instance, which is paid until the cluster
+	// is deleted.
+	Storage float64
+}
+
+// Total returns the hourly price of the running cluster.
+func (r HourlyRate) Total() float64 {
+	return r.Instance + r.Storage
+}
+
+// ParseHourlyRate parses the hourly rate reported in cost, which is zero when cost is nil.
+func ParseHourlyRate(cost *v1alpha1.ClusterCost) (HourlyRate, error) {
+	if cost == nil {
+		return HourlyRate{}, nil
+	}
+	total, err := ParseAmount(cost.HourlyRateUSD)
+	if err != nil {
+		return HourlyRate{}, err
+	}
+	// Costs reported before the storage price was split out only have a total.
+	if cost.StorageHourlyRateUSD == "" {
+		return HourlyRate{Instance: total}, nil
+	}
+	storage, err := ParseAmount(cost.StorageHourlyRateUSD)
+	if err != nil {
+		return HourlyRate{}, err
+	}
+	return HourlyRate{Instance: total - storage, Storage: storage}, nil
+}
+
+// CalculateCost computes the cost of a cluster from its hourly rate and lifecycle timestamps.
+// The running time is measured from startTime up to now, truncated to costResolution, less the
+// time paused while the cluster was hibernated; the projected total is only set when an expiration
+// is known. startTime is when provisioning started: the instance is billed while the cluster is
+// being provisioned. The root volume is billed for the paused time too.
+func CalculateCost(rate HourlyRate, startTime, expiration *metav1.Time, paused time.Duration, now time.Time) *v1alpha1.ClusterCost {
+	cost := &v1alpha1.ClusterCost{
+		HourlyRateUSD: FormatAmount(rate.Total()),
+		RunningHours:  FormatAmount(0),
+		AccruedUSD:    FormatAmount(0),
+	}
+	if rate.Storage > 0 {
+		cost.StorageHourlyRateUSD = FormatAmount(rate.Storage)
+	}
+	if startTime == nil {
+		return cost
+	}
+
+	now = now.Truncate(costResolution)
+	running := hoursBetween(startTime.Time.Add(paused), now)
+	cost.RunningHours = FormatAmount(running)
+	cost.AccruedUSD = FormatAmount(rate.Instance*running + rate.Storage*hoursBetween(startTime.Time, now))
+
+	if expiration != nil {
+		cost.ProjectedTotalUSD = FormatAmount(rate.Instance*hoursBetween(startTime.Time.Add(paused), expiration.Time) +
+			rate.Storage*hoursBetween(startTime.Time, expiration.Time))
+	}
+	return cost
+}
+
+// CarryOver returns the running hours and accrued cost reported in cost, to be carried over once
+// the cluster no longer runs on the infrastructure they were computed for.
+func CarryOver(cost *v1alpha1.ClusterCost) *v1alpha1.ClusterUsage {
+	if cost == nil {
+		return &v1alpha1.ClusterUsage{RunningHours: FormatAmount(0), AccruedUSD: FormatAmount(0)}
+	}
+	return &v1alpha1.ClusterUsage{RunningHours: cost.RunningHours, AccruedUSD: cost.AccruedUSD}
+}
+
+// AddUsage adds the usage of the previous infrastructure of a cluster to its cost. Malformed
+// amounts, which CarryOver never reports, are ignored.
+func AddUsage(cost *v1alpha1.ClusterCost, previous *v1alpha1.ClusterUsage) *v1alpha1.ClusterCost {
+	if previous == nil {
+		return cost
+	}
+	add := func(amount *string, extra string) {
+		a, errA := ParseAmount(*amount)
+		b, errB := ParseAmount(extra)
+		if errA == nil && errB == nil {
+			*amount = FormatAmount(a + b)
+		}
+	}
+	add(&cost.RunningHours, previous.RunningHours)
+	add(&cost.AccruedUSD, previous.AccruedUSD)
+	if cost.ProjectedTotalUSD != "" {
+		add(&cost.ProjectedTotalUSD, previous.AccruedUSD)
+	}
+	return cost
+}
+
+// ExpirationTime returns the time at which a cluster is expected to expire. An explicit
+// expiration timestamp takes precedence over one derived from the termination policy.
+func ExpirationTime(expiration, startTime *metav1.Time, policy *v1alpha1.TerminationPolicy) *metav1.Time {
+	if expiration != nil {
+		return expiration
+	}
+	if startTime == nil || policy == nil || policy.DeleteAfterSeconds == nil {
+		return nil
+	}
+	t := metav1.NewTime(startTime.Add(time.Duration(*policy.DeleteAfterSeconds) * time.Second))
+	return &t
+}
+
+// Lifetime gathers what bounds the lifetime of a cluster.
+type Lifetime struct {
+	// Policy is the termination policy of the cluster; nil means it has none.
+	Policy *v1alpha1.TerminationPolicy
+	// Extensions is the sum of the extensions requested on top of Policy.ExtendBy.
+	Extensions time.Duration
+	// LastActivity is when the activity Lease of the cluster was last renewed; nil means unknown.
+	LastActivity *time.Time
+	// Max caps the lifetime of the cluster; nil means it is not capped.
+	Max *time.Duration
+}
+
+// Expiration returns when a cluster whose provisioning started at startTime expires: the earliest
+// of the end of its TTL and extensions, its inactivity timeout and its maximum lifetime. It returns
+// nil when nothing bounds the lifetime of the cluster.
+func (l Lifetime) Expiration(startTime time.Time) *metav1.Time {
+	var expiration *time.Time
+	bound := func(t time.Time) {
+		if expiration == nil || t.Before(*expiration) {
+			expiration = &t
+		}
+	}
+	if p := l.Policy; p != nil && p.DeleteAfterSeconds != nil {
+		ttl := time.Duration(*p.DeleteAfterSeconds)*time.Second + l.Extensions
+		if p.ExtendBy != nil {
+			ttl += p.ExtendBy.Duration
+		}
+		bound(startTime.Add(ttl))
+	}
+	if p := l.Policy; p != nil && p.InactivityTimeout != nil && l.LastActivity != nil {
+		bound(l.LastActivity.Add(p.InactivityTimeout.Duration))
+	}
+	if l.Max != nil {
+		bound(startTime.Add(*l.Max))
+	}
+	if expiration == nil {
+		return nil
+	}
+	t := metav1.NewTime(*expiration)
+	return &t
+}
+
+// ParseAmount parses an amount previously rendered by FormatAmount.
+func ParseAmount(amount string) (float64, error) {
+	return strconv.ParseFloat(amount, 64)
+}
+
+// FormatAmount formats a float as a plain decimal string suitable for machine parsing.
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 4, 64)
+}
+
+// hoursBetween returns the number of hours from start to end, never negative.
+func hoursBetween(start, end time.Time) float64 {
+	if end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
@@ -0,0 +1,67 @@
+package controllerutils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var (
+	// ErrProvisioningTimedOut is the cause of a provisioning context whose timeout elapsed.
+	ErrProvisioningTimedOut = errors.New("provisioning timed out")
+
+	// ErrObjectDeleted is the cause of a provisioning context cancelled because its object is being deleted.
+	ErrObjectDeleted = errors.New("object is being deleted")
+)
+
+// DeletionPollInterval is how often a running provisioning checks whether its object is being deleted.
+var DeletionPollInterval = 10 * time.Second
+
+// ProvisioningDeadline returns when provisioning started at start must be done, or nil if it is not bounded.
+func ProvisioningDeadline(start *metav1.Time, timeout *metav1.Duration) *time.Time {
+	if start == nil || timeout == nil || timeout.Duration <= 0 {
+		return nil
+	}
+	deadline := start.Add(timeout.Duration)
+	return &deadline
+}
+
+// ProvisioningContext derives the context of a provisioning run of obj from ctx. Besides ctx being
+// done, it is cancelled once the deadline passes, with ErrProvisioningTimedOut as cause, and as soon as
+// obj is deleted or gets a deletion timestamp, with ErrObjectDeleted as cause. A nil deadline does not
+// bound the run.
+func ProvisioningContext(ctx context.Context, c client.Reader, obj client.Object, deadline *time.Time) (context.Context, context.CancelFunc) {
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+	cancelDeadline := context.CancelFunc(func() {})
+	if deadline != nil {
+		runCtx, cancelDeadline = context.WithDeadlineCause(runCtx, *deadline, ErrProvisioningTimedOut)
+	}
+
+	key := client.ObjectKeyFromObject(obj)
+	go func() {
+		ticker := time.NewTicker(DeletionPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				current := obj.DeepCopyObject().(client.Object)
+				err := c.Get(runCtx, key, current)
+				if apierrors.IsNotFound(err) || (err == nil && current.GetDeletionTimestamp() != nil) {
+					cancelRun(ErrObjectDeleted)
+					return
+				}
+			}
+		}
+	}()
+
+	return runCtx, func() {
+		cancelDeadline()
+		cancelRun(context.Canceled)
+	}
+}
@@ -0,0 +1,404 @@
+// Package gpustack renders the NVIDIA software making the GPUs of a cluster schedulable. On
+// Openshift, the Node Feature Discovery and NVIDIA GPU operators are subscribed to from OperatorHub
+// and configured with a ClusterPolicy; on Kind, whose host already runs the NVIDIA container
+// toolkit, the NVIDIA device plugin is deployed. The stack is installed in stages, each applied
+// once the previous one is ready, since the custom resources of a stage are only served once the
+// operators of the previous one are installed.
+package gpustack
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"text/template"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"github.com/mapt-oss/mapt-operator/pkg/addons"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResourceName is the extended resource pods request GPUs with.
+const ResourceName corev1.ResourceName = "nvidia.com/gpu"
+
+// Platform selects the flavour of the GPU stack.
+type Platform string
+
+const (
+	// Kind installs the NVIDIA device plugin.
+	Kind Platform = "Kind"
+	// Openshift installs the Node Feature Discovery and NVIDIA GPU operators.
+	Openshift Platform = "Openshift"
+)
+
+// defaultVersions are installed when spec.gpuStack.version is empty: the channel of the GPU
+// operator on Openshift and the release of the device plugin on Kind.
+var defaultVersions = map[Platform]string{
+	Kind:      "v0.17.2",
+	Openshift: "v25.3",
+}
+
+// Stage is a set of resources applied together.
+type Stage struct {
+	// Name identifies the stage in the status of the cluster.
+	Name string
+	// Objects are the resources of the stage.
+	Objects []*unstructured.Unstructured
+}
+
+// Version returns the release of the GPU stack installed for cfg.
+func Version(platform Platform, cfg v1alpha1.GPUStack) string {
+	if cfg.Version != "" {
+		return cfg.Version
+	}
+	return defaultVersions[platform]
+}
+
+// Validate rejects the configurations the platform does not support.
+func Validate(platform Platform, cfg v1alpha1.GPUStack) error {
+	switch {
+	case cfg.TimeSlicing != nil && cfg.MIG != nil:
+		return fmt.Errorf("timeSlicing and mig are mutually exclusive")
+	case cfg.MIG != nil && platform != Openshift:
+		return fmt.Errorf("mig is only supported on Openshift clusters")
+	}
+	return nil
+}
+
+// Stages returns the stages installing the GPU stack configured by cfg, in order.
+func Stages(platform Platform, cfg v1alpha1.GPUStack) ([]Stage, error) {
+	if err := Validate(platform, cfg); err != nil {
+		return nil, err
+	}
+
+	values := struct {
+		Version    string
+		Replicas   int32
+		MIGProfile string
+		ConfigHash string
+	}{Version: Version(platform, cfg)}
+	if cfg.TimeSlicing != nil {
+		values.Replicas = cfg.TimeSlicing.Replicas
+	}
+	if cfg.MIG != nil {
+		values.MIGProfile = cfg.MIG.Profile
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d/%s", values.Replicas, values.MIGProfile))
+	values.ConfigHash = hex.EncodeToString(sum[:8])
+
+	var stages []Stage
+	for _, s := range manifests[platform] {
+		var buf bytes.Buffer
+		if err := s.manifest.Execute(&buf, values); err != nil {
+			return nil, fmt.Errorf("failed to render stage %s: %w", s.name, err)
+		}
+		objs, err := addons.Parse(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("stage %s: %w", s.name, err)
+		}
+		stages = append(stages, Stage{Name: s.name, Objects: objs})
+	}
+	return stages, nil
+}
+
+// Ready reports whether the resources of stage are ready on the cluster of c: workloads are
+// available, operator subscriptions are installed and the operands report they are ready. When
+// they are not, it returns a message naming the first resource that is not.
+func Ready(ctx context.Context, c client.Reader, stage Stage) (bool, string, error) {
+	if ready, message, err := addons.Ready(ctx, c, stage.Objects); err != nil || !ready {
+		return ready, message, err
+	}
+
+	for _, obj := range stage.Objects {
+		check, ok := operands[obj.GetKind()]
+		if !ok {
+			continue
+		}
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+			return false, "", fmt.Errorf("failed to get %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+		}
+		if !check(live) {
+			return false, fmt.Sprintf("%s %s is not ready yet.", obj.GetKind(), client.ObjectKeyFromObject(obj)), nil
+		}
+	}
+	return true, "", nil
+}
+
+// AllocatableGPUs returns the number of GPUs the nodes of the cluster of c can allocate.
+func AllocatableGPUs(ctx context.Context, c client.Reader) (int64, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return 0, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var total int64
+	for _, node := range nodes.Items {
+		if q, ok := node.Status.Allocatable[ResourceName]; ok {
+			total += q.Value()
+		}
+	}
+	return total, nil
+}
+
+// operands checks the readiness of the resources that are not workloads, by kind.
+var operands = map[string]func(obj *unstructured.Unstructured) bool{
+	"Subscription": func(obj *unstructured.Unstructured) bool {
+		state, _, _ := unstructured.NestedString(obj.Object, "status", "state")
+		return state == "AtLatestKnown"
+	},
+	"NodeFeatureDiscovery": func(obj *unstructured.Unstructured) bool {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if ok && condition["type"] == "Available" {
+				return condition["status"] == string(metav1.ConditionTrue)
+			}
+		}
+		return false
+	},
+	"ClusterPolicy": func(obj *unstructured.Unstructured) bool {
+		state, _, _ := unstructured.NestedString(obj.Object, "status", "state")
+		return state == "ready"
+	},
+}
+
+// IsNotServed reports whether err was returned because the cluster does not serve the kind of a
+// resource yet, e.g. because the operator defining it is still being installed.
+func IsNotServed(err error) bool {
+	return meta.IsNoMatchError(err)
+}
+
+type stageManifest struct {
+	name     string
+	manifest *template.Template
+}
+
+func stage(name, manifest string) stageManifest {
+	return stageManifest{name: name, manifest: template.Must(template.New(name).Parse(manifest))}
+}
+
+// manifests holds the stages of each platform, in order.
+var manifests = map[Platform][]stageManifest{
+	Kind: {
+		stage("nvidia-device-plugin", devicePluginManifest),
+	},
+	Openshift: {
+		stage("node-feature-discovery-operator", nfdOperatorManifest),
+		stage("gpu-operator", gpuOperatorManifest),
+		stage("node-feature-discovery", nfdManifest),
+		stage("cluster-policy", clusterPolicyManifest),
+	},
+}
+
+const devicePluginManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: nvidia-device-plugin-config
+  namespace: kube-system
+data:
+  config.yaml: |
+    version: v1
+    flags:
+      migStrategy: none
+      failOnInitError: false
+      plugin:
+        deviceListStrategy: volume-mounts
+{{- if .Replicas }}
+    sharing:
+      timeSlicing:
+        resources:
+        - name: nvidia.com/gpu
+          replicas: {{ .Replicas }}
+{{- end }}
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: nvidia-device-plugin-daemonset
+  namespace: kube-system
+spec:
+  selector:
+    matchLabels:
+      name: nvidia-device-plugin-ds
+  updateStrategy:
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        name: nvidia-device-plugin-ds
+      annotations:
+        mapt.redhat.com/gpu-config-hash: "{{ .ConfigHash }}"
+    spec:
+      priorityClassName: system-node-critical
+      tolerations:
+      - key: nvidia.com/gpu
+        operator: Exists
+        effect: NoSchedule
+      - key: node-role.kubernetes.io/control-plane
+        operator: Exists
+        effect: NoSchedule
+      containers:
+      - name: nvidia-device-plugin-ctr
+        image: nvcr.io/nvidia/k8s-device-plugin:{{ .Version }}
+        env:
+        - name: CONFIG_FILE
+          value: /etc/nvidia-device-plugin/config.yaml
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop: ["ALL"]
+        volumeMounts:
+        - name: device-plugin
+          mountPath: /var/lib/kubelet/device-plugins
+        - name: config
+          mountPath: /etc/nvidia-device-plugin
+      volumes:
+      - name: device-plugin
+        hostPath:
+          path: /var/lib/kubelet/device-plugins
+      - name: config
+        configMap:
+          name: nvidia-device-plugin-config
+`
+
+const nfdOperatorManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: openshift-nfd
+---
+apiVersion: operators.coreos.com/v1
+kind: OperatorGroup
+metadata:
+  name: openshift-nfd
+  namespace: openshift-nfd
+spec:
+  targetNamespaces:
+  - openshift-nfd
+---
+apiVersion: operators.coreos.com/v1alpha1
+kind: Subscription
+metadata:
+  name: nfd
+  namespace: openshift-nfd
+spec:
+  channel: stable
+  installPlanApproval: Automatic
+  name: nfd
+  source: redhat-operators
+  sourceNamespace: openshift-marketplace
+`
+
+const gpuOperatorManifest = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: nvidia-gpu-operator
+---
+apiVersion: operators.coreos.com/v1
+kind: OperatorGroup
+metadata:
+  name: nvidia-gpu-operator
+  namespace: nvidia-gpu-operator
+spec:
+  targetNamespaces:
+  - nvidia-gpu-operator
+---
+apiVersion: operators.coreos.com/v1alpha1
+kind: Subscription
+metadata:
+  name: gpu-operator-certified
+  namespace: nvidia-gpu-operator
+spec:
+  channel: "{{ .Version }}"
+  installPlanApproval: Automatic
+  name: gpu-operator-certified
+  source: certified-operators
+  sourceNamespace: openshift-marketplace
+`
+
+const nfdManifest = `apiVersion: nfd.openshift.io/v1
+kind: NodeFeatureDiscovery
+metadata:
+  name: nfd-instance
+  namespace: openshift-nfd
+spec:
+  operand:
+    servicePort: 12000
+  workerConfig:
+    configData: |
+      sources:
+        pci:
+          deviceClassWhitelist:
+          - "0300"
+          - "0302"
+          deviceLabelFields:
+          - vendor
+`
+
+const clusterPolicyManifest = `{{- if .Replicas -}}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: device-plugin-config
+  namespace: nvidia-gpu-operator
+data:
+  any: |
+    version: v1
+    flags:
+      migStrategy: none
+    sharing:
+      timeSlicing:
+        resources:
+        - name: nvidia.com/gpu
+          replicas: {{ .Replicas }}
+---
+{{ end -}}
+apiVersion: nvidia.com/v1
+kind: ClusterPolicy
+metadata:
+  name: gpu-cluster-policy
+spec:
+  operator:
+    defaultRuntime: crio
+    use_ocp_driver_toolkit: true
+  daemonsets:
+    updateStrategy: RollingUpdate
+  driver:
+    enabled: true
+  toolkit:
+    enabled: true
+  devicePlugin:
+    enabled: true
+{{- if .Replicas }}
+    config:
+      name: device-plugin-config
+      default: any
+{{- end }}
+  dcgm:
+    enabled: true
+  dcgmExporter:
+    enabled: true
+  gfd:
+    enabled: true
+  nodeStatusExporter:
+    enabled: true
+  mig:
+    strategy: single
+  migManager:
+    enabled: true
+{{- if .MIGProfile }}
+    config:
+      name: default-mig-parted-config
+      default: "{{ .MIGProfile }}"
+{{- end }}
+  validator:
+    plugin:
+      env:
+      - name: WITH_WORKLOAD
+        value: "false"
+`
@@ -0,0 +1,139 @@
+package gpustack
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func find(stages []Stage, kind, name string) *unstructured.Unstructured {
+	for _, s := range stages {
+		for _, obj := range s.Objects {
+			if obj.GetKind() == kind && obj.GetName() == name {
+				return obj
+			}
+		}
+	}
+	return nil
+}
+
+func field(obj *unstructured.Unstructured, path ...string) string {
+	value, _, _ := unstructured.NestedString(obj.Object, path...)
+	return value
+}
+
+var _ = Describe("Stages", func() {
+	It("deploys the device plugin on Kind", func() {
+		stages, err := Stages(Kind, v1alpha1.GPUStack{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stages).To(HaveLen(1))
+		Expect(stages[0].Name).To(Equal("nvidia-device-plugin"))
+
+		ds := find(stages, "DaemonSet", "nvidia-device-plugin-daemonset")
+		Expect(ds).NotTo(BeNil())
+		containers, _, _ := unstructured.NestedSlice(ds.Object, "spec", "template", "spec", "containers")
+		Expect(containers[0]).To(HaveKeyWithValue("image", "nvcr.io/nvidia/k8s-device-plugin:"+defaultVersions[Kind]))
+
+		cm := find(stages, "ConfigMap", "nvidia-device-plugin-config")
+		Expect(field(cm, "data", "config.yaml")).NotTo(ContainSubstring("timeSlicing"))
+	})
+
+	It("shares GPUs with time-slicing", func() {
+		stages, err := Stages(Kind, v1alpha1.GPUStack{Version: "v0.16.0", TimeSlicing: &v1alpha1.GPUTimeSlicing{Replicas: 4}})
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := find(stages, "ConfigMap", "nvidia-device-plugin-config")
+		Expect(field(cm, "data", "config.yaml")).To(ContainSubstring("replicas: 4"))
+
+		plain, err := Stages(Kind, v1alpha1.GPUStack{Version: "v0.16.0"})
+		Expect(err).NotTo(HaveOccurred())
+		hash := func(stages []Stage) string {
+			ds := find(stages, "DaemonSet", "nvidia-device-plugin-daemonset")
+			return field(ds, "spec", "template", "metadata", "annotations", "mapt.redhat.com/gpu-config-hash")
+		}
+		Expect(hash(stages)).NotTo(Equal(hash(plain)))
+	})
+
+	It("installs the operators before their operands on Openshift", func() {
+		stages, err := Stages(Openshift, v1alpha1.GPUStack{MIG: &v1alpha1.GPUMIG{Profile: "all-1g.10gb"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stages).To(HaveEach(HaveField("Objects", Not(BeEmpty()))))
+		Expect(stages).To(HaveExactElements(
+			HaveField("Name", "node-feature-discovery-operator"),
+			HaveField("Name", "gpu-operator"),
+			HaveField("Name", "node-feature-discovery"),
+			HaveField("Name", "cluster-policy"),
+		))
+
+		subscription := find(stages, "Subscription", "gpu-operator-certified")
+		Expect(field(subscription, "spec", "channel")).To(Equal(defaultVersions[Openshift]))
+		policy := find(stages, "ClusterPolicy", "gpu-cluster-policy")
+		Expect(field(policy, "spec", "migManager", "config", "default")).To(Equal("all-1g.10gb"))
+		Expect(find(stages, "ConfigMap", "device-plugin-config")).To(BeNil())
+	})
+
+	It("configures time-slicing through the ClusterPolicy on Openshift", func() {
+		stages, err := Stages(Openshift, v1alpha1.GPUStack{TimeSlicing: &v1alpha1.GPUTimeSlicing{Replicas: 8}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(find(stages, "ConfigMap", "device-plugin-config")).NotTo(BeNil())
+		policy := find(stages, "ClusterPolicy", "gpu-cluster-policy")
+		Expect(field(policy, "spec", "devicePlugin", "config", "name")).To(Equal("device-plugin-config"))
+	})
+
+	It("rejects MIG on Kind", func() {
+		_, err := Stages(Kind, v1alpha1.GPUStack{MIG: &v1alpha1.GPUMIG{Profile: "all-1g.10gb"}})
+		Expect(err).To(MatchError(ContainSubstring("only supported on Openshift")))
+	})
+})
+
+var _ = Describe("Ready", func() {
+	It("waits for the subscriptions of the operators", func() {
+		stages, err := Stages(Openshift, v1alpha1.GPUStack{})
+		Expect(err).NotTo(HaveOccurred())
+		subscription := stages[1].Objects[2].DeepCopy()
+		c := fake.NewClientBuilder().WithObjects(subscription).Build()
+
+		ready, message, err := Ready(context.Background(), c, stages[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeFalse())
+		Expect(message).To(ContainSubstring("Subscription nvidia-gpu-operator/gpu-operator-certified"))
+
+		Expect(unstructured.SetNestedField(subscription.Object, "AtLatestKnown", "status", "state")).To(Succeed())
+		Expect(c.Update(context.Background(), subscription)).To(Succeed())
+		ready, _, err = Ready(context.Background(), c, stages[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ready).To(BeTrue())
+	})
+})
+
+var _ = Describe("AllocatableGPUs", func() {
+	It("sums the GPUs of every node", func() {
+		node := func(name string, gpus int64) *corev1.Node {
+			n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+			if gpus > 0 {
+				n.Status.Allocatable = corev1.ResourceList{ResourceName: *resource.NewQuantity(gpus, resource.DecimalSI)}
+			}
+			return n
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).
+			WithObjects(node("control-plane", 0), node("worker", 4), node("worker2", 2)).Build()
+
+		gpus, err := AllocatableGPUs(context.Background(), c)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gpus).To(Equal(int64(6)))
+	})
+})
+
+func TestGPUStack(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GPU Stack Suite")
+}
@@ -0,0 +1,212 @@
+// Package kindconfig renders the kind Cluster configuration of Kind clusters. The configuration
+// starts from the raw kindConfig of the cluster, if any, and the structured fields of its
+// KindClusterConfig are applied on top of it.
+package kindconfig
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion and Kind identify kind Cluster configurations.
+	APIVersion = "kind.x-k8s.io/v1alpha4"
+	Kind       = "Cluster"
+
+	roleControlPlane = "control-plane"
+	roleWorker       = "worker"
+
+	// gpuDevicesPath is where a volume mount makes the NVIDIA container toolkit of the host
+	// inject every GPU into a node container.
+	gpuDevicesPath = "/var/run/nvidia-container-devices/all"
+)
+
+// certSANsPatch makes the certificate of the API server valid for the public address of the host.
+// The patch replaces the SANs kind sets by default, so they are repeated.
+const certSANsPatch = `kind: ClusterConfiguration
+apiServer:
+  certSANs:
+  - localhost
+  - 127.0.0.1
+  - %q
+`
+
+// Customized reports whether cfg asks for more than the single node cluster mapt creates.
+func Customized(cfg v1alpha1.KindClusterConfig) bool {
+	return cfg.Nodes != nil || len(cfg.FeatureGates) > 0 || len(cfg.RuntimeConfig) > 0 ||
+		cfg.Networking != nil || cfg.KindConfig != ""
+}
+
+// Validate rejects configurations that cannot be rendered.
+func Validate(cfg v1alpha1.KindClusterConfig) error {
+	_, err := build(cfg)
+	return err
+}
+
+// Render returns the kind configuration of a cluster on host. Its API server is published on every
+// address of the host at port, with a certificate valid for host. With gpu, the GPUs of the host
+// are handed to the first worker node, or to the first control-plane node without workers.
+func Render(cfg v1alpha1.KindClusterConfig, host string, port int32, gpu bool) ([]byte, error) {
+	doc, err := build(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if gpu {
+		mountGPUs(doc)
+	}
+
+	networking := section(doc, "networking")
+	networking["apiServerAddress"] = "0.0.0.0"
+	networking["apiServerPort"] = port
+	patches, _ := doc["kubeadmConfigPatches"].([]interface{})
+	doc["kubeadmConfigPatches"] = append(patches, fmt.Sprintf(certSANsPatch, host))
+	return yaml.Marshal(doc)
+}
+
+func build(cfg v1alpha1.KindClusterConfig) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if cfg.KindConfig != "" {
+		if err := yaml.Unmarshal([]byte(cfg.KindConfig), &doc); err != nil {
+			return nil, fmt.Errorf("invalid kindConfig: %w", err)
+		}
+		if doc == nil {
+			doc = map[string]interface{}{}
+		}
+		if doc["apiVersion"] != APIVersion || doc["kind"] != Kind {
+			return nil, fmt.Errorf("invalid kindConfig: expected kind %s and apiVersion %s", Kind, APIVersion)
+		}
+	}
+	doc["apiVersion"] = APIVersion
+	doc["kind"] = Kind
+
+	nodes, err := buildNodes(doc["nodes"], cfg.Nodes)
+	if err != nil {
+		return nil, err
+	}
+	if nodes != nil {
+		doc["nodes"] = nodes
+	}
+
+	if len(cfg.FeatureGates) > 0 {
+		gates := section(doc, "featureGates")
+		for name, enabled := range cfg.FeatureGates {
+			if name == "" {
+				return nil, fmt.Errorf("invalid featureGates: empty feature gate name")
+			}
+			gates[name] = enabled
+		}
+	}
+	if len(cfg.RuntimeConfig) > 0 {
+		runtimeConfig := section(doc, "runtimeConfig")
+		for key, value := range cfg.RuntimeConfig {
+			if key == "" {
+				return nil, fmt.Errorf("invalid runtimeConfig: empty key")
+			}
+			runtimeConfig[key] = value
+		}
+	}
+
+	if n := cfg.Networking; n != nil {
+		networking := section(doc, "networking")
+		for field, cidr := range map[string]string{"podSubnet": n.PodSubnet, "serviceSubnet": n.ServiceSubnet} {
+			if cidr == "" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("invalid networking.%s %q: %w", field, cidr, err)
+			}
+			networking[field] = cidr
+		}
+		if n.DisableDefaultCNI {
+			networking["disableDefaultCNI"] = true
+		}
+	}
+	return doc, nil
+}
+
+// buildNodes returns the nodes of the cluster. Without counts, the nodes of the raw configuration
+// are kept as they are. With counts, the nodes of each role in the raw configuration are used, in
+// order, as the first nodes of that role, e.g. to give the first control-plane node extra port
+// mappings; missing nodes are added and extra ones dropped.
+func buildNodes(raw interface{}, counts *v1alpha1.KindNodes) ([]interface{}, error) {
+	var nodes []interface{}
+	if raw != nil {
+		var ok bool
+		if nodes, ok = raw.([]interface{}); !ok {
+			return nil, fmt.Errorf("invalid kindConfig: nodes must be a list")
+		}
+	}
+
+	byRole := map[string][]interface{}{}
+	for i, n := range nodes {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid kindConfig: node %d must be an object", i)
+		}
+		role := roleControlPlane
+		if r, set := node["role"]; set {
+			role, _ = r.(string)
+		}
+		if role != roleControlPlane && role != roleWorker {
+			return nil, fmt.Errorf("invalid kindConfig: node %d has unknown role %v", i, node["role"])
+		}
+		byRole[role] = append(byRole[role], node)
+	}
+
+	if counts == nil {
+		if len(nodes) > 0 && len(byRole[roleControlPlane]) == 0 {
+			return nil, fmt.Errorf("invalid kindConfig: at least one control-plane node is required")
+		}
+		return nil, nil
+	}
+
+	var result []interface{}
+	for _, group := range []struct {
+		role  string
+		count int32
+	}{{roleControlPlane, max(counts.ControlPlanes, 1)}, {roleWorker, counts.Workers}} {
+		for i := range int(group.count) {
+			node := map[string]interface{}{}
+			if i < len(byRole[group.role]) {
+				node = byRole[group.role][i].(map[string]interface{})
+			}
+			node["role"] = group.role
+			result = append(result, node)
+		}
+	}
+	return result, nil
+}
+
+// mountGPUs adds the mount requesting every GPU of the host to the node running GPU workloads.
+func mountGPUs(doc map[string]interface{}) {
+	nodes, _ := doc["nodes"].([]interface{})
+	if len(nodes) == 0 {
+		nodes = []interface{}{map[string]interface{}{"role": roleControlPlane}}
+		doc["nodes"] = nodes
+	}
+	target := nodes[0].(map[string]interface{})
+	for _, n := range nodes {
+		if node := n.(map[string]interface{}); node["role"] == roleWorker {
+			target = node
+			break
+		}
+	}
+	mounts, _ := target["extraMounts"].([]interface{})
+	target["extraMounts"] = append(mounts, map[string]interface{}{
+		"hostPath":      "/dev/null",
+		"containerPath": gpuDevicesPath,
+	})
+}
+
+// section returns the object stored under key in doc, creating it if needed.
+func section(doc map[string]interface{}, key string) map[string]interface{} {
+	if s, ok := doc[key].(map[string]interface{}); ok {
+		return s
+	}
+	s := map[string]interface{}{}
+	doc[key] = s
+	return s
+}
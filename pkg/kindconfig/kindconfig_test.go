@@ -0,0 +1,146 @@
+package kindconfig
+
+import (
+	"testing"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Render", func() {
+	render := func(cfg v1alpha1.KindClusterConfig) map[string]interface{} {
+		data, err := Render(cfg, "203.0.113.10", 6443, false)
+		Expect(err).NotTo(HaveOccurred())
+		doc := map[string]interface{}{}
+		Expect(yaml.Unmarshal(data, &doc)).To(Succeed())
+		return doc
+	}
+	roles := func(doc map[string]interface{}) []string {
+		var result []string
+		for _, n := range doc["nodes"].([]interface{}) {
+			result = append(result, n.(map[string]interface{})["role"].(string))
+		}
+		return result
+	}
+
+	It("publishes the API server on the host", func() {
+		doc := render(v1alpha1.KindClusterConfig{KubernetesVersion: "v1.32"})
+		Expect(doc).To(HaveKeyWithValue("apiVersion", APIVersion))
+		Expect(doc).To(HaveKeyWithValue("kind", Kind))
+		Expect(doc["networking"]).To(HaveKeyWithValue("apiServerAddress", "0.0.0.0"))
+		Expect(doc["networking"]).To(HaveKeyWithValue("apiServerPort", BeNumerically("==", 6443)))
+		Expect(doc["kubeadmConfigPatches"]).To(ConsistOf(ContainSubstring(`"203.0.113.10"`)))
+		Expect(doc).NotTo(HaveKey("nodes"))
+	})
+
+	It("renders the structured fields", func() {
+		doc := render(v1alpha1.KindClusterConfig{
+			Nodes:         &v1alpha1.KindNodes{ControlPlanes: 1, Workers: 2},
+			FeatureGates:  map[string]bool{"InPlacePodVerticalScaling": true},
+			RuntimeConfig: map[string]string{"api/alpha": "true"},
+			Networking: &v1alpha1.KindNetworking{
+				PodSubnet:         "10.244.0.0/16",
+				ServiceSubnet:     "10.96.0.0/16",
+				DisableDefaultCNI: true,
+			},
+		})
+		Expect(roles(doc)).To(Equal([]string{"control-plane", "worker", "worker"}))
+		Expect(doc["featureGates"]).To(HaveKeyWithValue("InPlacePodVerticalScaling", true))
+		Expect(doc["runtimeConfig"]).To(HaveKeyWithValue("api/alpha", "true"))
+		Expect(doc["networking"]).To(HaveKeyWithValue("podSubnet", "10.244.0.0/16"))
+		Expect(doc["networking"]).To(HaveKeyWithValue("serviceSubnet", "10.96.0.0/16"))
+		Expect(doc["networking"]).To(HaveKeyWithValue("disableDefaultCNI", true))
+	})
+
+	It("applies the structured fields on top of the raw configuration", func() {
+		doc := render(v1alpha1.KindClusterConfig{
+			Nodes:        &v1alpha1.KindNodes{ControlPlanes: 1, Workers: 1},
+			FeatureGates: map[string]bool{"A": false},
+			KindConfig: `apiVersion: kind.x-k8s.io/v1alpha4
+kind: Cluster
+featureGates:
+  A: true
+  B: true
+networking:
+  apiServerPort: 1234
+  ipFamily: dual
+containerdConfigPatches:
+- |-
+  [plugins."io.containerd.grpc.v1.cri".registry.mirrors."docker.io"]
+    endpoint = ["http://mirror:5000"]
+nodes:
+- role: control-plane
+  extraPortMappings:
+  - containerPort: 80
+    hostPort: 80
+- role: control-plane
+`,
+		})
+		Expect(roles(doc)).To(Equal([]string{"control-plane", "worker"}))
+		Expect(doc["nodes"].([]interface{})[0]).To(HaveKey("extraPortMappings"))
+		Expect(doc["featureGates"]).To(Equal(map[string]interface{}{"A": false, "B": true}))
+		Expect(doc["networking"]).To(HaveKeyWithValue("ipFamily", "dual"))
+		Expect(doc["networking"]).To(HaveKeyWithValue("apiServerPort", BeNumerically("==", 6443)))
+		Expect(doc["containerdConfigPatches"]).To(HaveLen(1))
+	})
+
+	It("keeps the nodes of the raw configuration without counts", func() {
+		doc := render(v1alpha1.KindClusterConfig{KindConfig: `apiVersion: kind.x-k8s.io/v1alpha4
+kind: Cluster
+nodes:
+- role: control-plane
+- role: worker
+- role: worker
+`})
+		Expect(roles(doc)).To(Equal([]string{"control-plane", "worker", "worker"}))
+	})
+
+	It("hands the GPUs of the host to a single node", func() {
+		mounts := func(doc map[string]interface{}) []interface{} {
+			var result []interface{}
+			for _, n := range doc["nodes"].([]interface{}) {
+				extra, _ := n.(map[string]interface{})["extraMounts"].([]interface{})
+				result = append(result, len(extra))
+			}
+			return result
+		}
+		gpuRender := func(cfg v1alpha1.KindClusterConfig) map[string]interface{} {
+			data, err := Render(cfg, "203.0.113.10", 6443, true)
+			Expect(err).NotTo(HaveOccurred())
+			doc := map[string]interface{}{}
+			Expect(yaml.Unmarshal(data, &doc)).To(Succeed())
+			return doc
+		}
+
+		doc := gpuRender(v1alpha1.KindClusterConfig{})
+		Expect(roles(doc)).To(Equal([]string{"control-plane"}))
+		Expect(doc["nodes"].([]interface{})[0]).To(HaveKeyWithValue("extraMounts", ConsistOf(
+			HaveKeyWithValue("containerPath", "/var/run/nvidia-container-devices/all"))))
+
+		doc = gpuRender(v1alpha1.KindClusterConfig{Nodes: &v1alpha1.KindNodes{ControlPlanes: 1, Workers: 2}})
+		Expect(mounts(doc)).To(Equal([]interface{}{0, 1, 0}))
+	})
+
+	DescribeTable("rejects invalid configurations",
+		func(cfg v1alpha1.KindClusterConfig, message string) {
+			Expect(Validate(cfg)).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("malformed raw configuration", v1alpha1.KindClusterConfig{KindConfig: "nodes: ["}, "invalid kindConfig"),
+		Entry("other kind", v1alpha1.KindClusterConfig{KindConfig: "apiVersion: v1\nkind: ConfigMap\n"}, "expected kind Cluster"),
+		Entry("unknown node role", v1alpha1.KindClusterConfig{KindConfig: "apiVersion: kind.x-k8s.io/v1alpha4\nkind: Cluster\nnodes:\n- role: master\n"}, "unknown role"),
+		Entry("no control-plane node", v1alpha1.KindClusterConfig{KindConfig: "apiVersion: kind.x-k8s.io/v1alpha4\nkind: Cluster\nnodes:\n- role: worker\n"}, "control-plane node is required"),
+		Entry("invalid pod subnet", v1alpha1.KindClusterConfig{Networking: &v1alpha1.KindNetworking{PodSubnet: "10.244.0.0"}}, "networking.podSubnet"),
+	)
+
+	It("reports whether a configuration is customized", func() {
+		Expect(Customized(v1alpha1.KindClusterConfig{KubernetesVersion: "v1.32"})).To(BeFalse())
+		Expect(Customized(v1alpha1.KindClusterConfig{Nodes: &v1alpha1.KindNodes{Workers: 1}})).To(BeTrue())
+	})
+})
+
+func TestKindConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "KindConfig Suite")
+}
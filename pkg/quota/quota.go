@@ -0,0 +1,238 @@
+// Package quota evaluates MaptQuota limits against the clusters of a namespace.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Scope selects which clusters are counted towards the usage of a namespace.
+type Scope int
+
+const (
+	// AllClusters counts every cluster that has not failed and is not being deleted.
+	// It is used at admission time, so queued clusters also hold their share of the quota.
+	AllClusters Scope = iota
+	// ActiveClusters counts only clusters holding cloud capacity, including hibernated clusters,
+	// which keep their disks and may resume at any time.
+	// It is used by the reconcile-time fallback to decide when a queued cluster can start.
+	ActiveClusters
+)
+
+// Usage is the amount of resources requested by one or more clusters.
+type Usage struct {
+	Clusters    int32
+	CPUs        int32
+	MemoryGiB   int32
+	GPUClusters int32
+}
+
+// Add returns the sum of both usages.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		Clusters:    u.Clusters + other.Clusters,
+		CPUs:        u.CPUs + other.CPUs,
+		MemoryGiB:   u.MemoryGiB + other.MemoryGiB,
+		GPUClusters: u.GPUClusters + other.GPUClusters,
+	}
+}
+
+// Within reports whether the usage does not exceed other in any resource.
+func (u Usage) Within(other Usage) bool {
+	return u.Clusters <= other.Clusters && u.CPUs <= other.CPUs &&
+		u.MemoryGiB <= other.MemoryGiB && u.GPUClusters <= other.GPUClusters
+}
+
+// ToAPI converts the usage into its API representation.
+func (u Usage) ToAPI() v1alpha1.QuotaUsage {
+	return v1alpha1.QuotaUsage{
+		Clusters:    u.Clusters,
+		CPUs:        u.CPUs,
+		MemoryGiB:   u.MemoryGiB,
+		GPUClusters: u.GPUClusters,
+	}
+}
+
+// UsageFor returns the usage of a single cluster requesting the given machine.
+func UsageFor(machine v1alpha1.MachineConfig) Usage {
+	usage := Usage{
+		Clusters:  1,
+		CPUs:      machine.CPUs,
+		MemoryGiB: machine.MemoryGiB,
+	}
+	if machine.GPU {
+		usage.GPUClusters = 1
+	}
+	return usage
+}
+
+// ExceededError is returned when admitting a cluster would exceed a MaptQuota.
+type ExceededError struct {
+	// Quota is the name of the MaptQuota that would be exceeded.
+	Quota string
+	// Violations describes every limit that would be exceeded.
+	Violations []string
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("exceeded quota %q: %s", e.Quota, strings.Join(e.Violations, ", "))
+}
+
+// IsExceeded reports whether err is caused by an exceeded quota.
+func IsExceeded(err error) bool {
+	var exceeded *ExceededError
+	return errors.As(err, &exceeded)
+}
+
+// Check verifies that adding the cluster obj, requesting the given machine, keeps every MaptQuota
+// of its namespace within limits. The cluster itself is never counted twice.
+func Check(ctx context.Context, c client.Reader, obj client.Object, machine v1alpha1.MachineConfig, scope Scope) error {
+	quotas := &v1alpha1.MaptQuotaList{}
+	if err := c.List(ctx, quotas, client.InNamespace(obj.GetNamespace())); err != nil {
+		return fmt.Errorf("failed to list quotas: %w", err)
+	}
+	if len(quotas.Items) == 0 {
+		return nil
+	}
+
+	used, err := NamespaceUsage(ctx, c, obj.GetNamespace(), scope, obj)
+	if err != nil {
+		return err
+	}
+	requested := used.Add(UsageFor(machine))
+
+	for i := range quotas.Items {
+		if err := Evaluate(&quotas.Items[i], requested); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate returns an ExceededError when the requested usage does not fit in the quota.
+func Evaluate(q *v1alpha1.MaptQuota, requested Usage) error {
+	var violations []string
+	check := func(name string, limit *int32, value int32) {
+		if limit != nil && value > *limit {
+			violations = append(violations, fmt.Sprintf("%s: requested %d, limit %d", name, value, *limit))
+		}
+	}
+	check("clusters", q.Spec.MaxClusters, requested.Clusters)
+	check("cpus", q.Spec.MaxCPUs, requested.CPUs)
+	check("memoryGiB", q.Spec.MaxMemoryGiB, requested.MemoryGiB)
+	check("gpuClusters", q.Spec.MaxGPUClusters, requested.GPUClusters)
+
+	if len(violations) > 0 {
+		return &ExceededError{Quota: q.Name, Violations: violations}
+	}
+	return nil
+}
+
+// MaxLifetime returns the smallest maxLifetime of the MaptQuotas of a namespace, or nil when none
+// of them caps the lifetime of clusters.
+func MaxLifetime(ctx context.Context, c client.Reader, namespace string) (*time.Duration, error) {
+	quotas := &v1alpha1.MaptQuotaList{}
+	if err := c.List(ctx, quotas, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list quotas: %w", err)
+	}
+	var lifetime *time.Duration
+	for _, q := range quotas.Items {
+		if q.Spec.MaxLifetime != nil && (lifetime == nil || q.Spec.MaxLifetime.Duration < *lifetime) {
+			lifetime = &q.Spec.MaxLifetime.Duration
+		}
+	}
+	return lifetime, nil
+}
+
+// NamespaceUsage sums the usage of the Kind and Openshift clusters of a namespace matching scope.
+// The exclude object, if not nil, is skipped.
+func NamespaceUsage(ctx context.Context, c client.Reader, namespace string, scope Scope, exclude client.Object) (Usage, error) {
+	var usage Usage
+
+	kinds := &v1alpha1.KindList{}
+	if err := c.List(ctx, kinds, client.InNamespace(namespace)); err != nil {
+		return usage, fmt.Errorf("failed to list Kind clusters: %w", err)
+	}
+	for i := range kinds.Items {
+		k := &kinds.Items[i]
+		if isExcluded(k, exclude) || !counts(k, string(k.Status.Phase), scope) {
+			continue
+		}
+		usage = usage.Add(UsageFor(k.Spec.MachineConfig))
+	}
+
+	openshifts := &v1alpha1.OpenshiftList{}
+	if err := c.List(ctx, openshifts, client.InNamespace(namespace)); err != nil {
+		return usage, fmt.Errorf("failed to list Openshift clusters: %w", err)
+	}
+	for i := range openshifts.Items {
+		o := &openshifts.Items[i]
+		if isExcluded(o, exclude) || !counts(o, string(o.Status.Phase), scope) {
+			continue
+		}
+		usage = usage.Add(UsageFor(o.Spec.MachineConfig))
+	}
+
+	return usage, nil
+}
+
+// QueuedClusters returns the number of clusters of a namespace waiting in the Queued phase.
+func QueuedClusters(ctx context.Context, c client.Reader, namespace string) (int32, error) {
+	var queued int32
+
+	kinds := &v1alpha1.KindList{}
+	if err := c.List(ctx, kinds, client.InNamespace(namespace)); err != nil {
+		return 0, fmt.Errorf("failed to list Kind clusters: %w", err)
+	}
+	for _, k := range kinds.Items {
+		if k.Status.Phase == v1alpha1.KindPhaseQueued {
+			queued++
+		}
+	}
+
+	openshifts := &v1alpha1.OpenshiftList{}
+	if err := c.List(ctx, openshifts, client.InNamespace(namespace)); err != nil {
+		return 0, fmt.Errorf("failed to list Openshift clusters: %w", err)
+	}
+	for _, o := range openshifts.Items {
+		if o.Status.Phase == v1alpha1.OpenshiftSncPhaseQueued {
+			queued++
+		}
+	}
+	return queued, nil
+}
+
+// counts reports whether a cluster in the given phase is counted for scope.
+// Kind and Openshift share the same phase names, so the phase is compared as a string.
+func counts(obj client.Object, phase string, scope Scope) bool {
+	if scope == AllClusters {
+		return obj.GetDeletionTimestamp() == nil && phase != string(v1alpha1.KindPhaseFailed)
+	}
+	// Clusters being deleted keep their instance until deprovisioning completes.
+	switch phase {
+	case string(v1alpha1.KindPhaseProvisioning), string(v1alpha1.KindPhaseRunning),
+		string(v1alpha1.KindPhaseHibernated), string(v1alpha1.KindPhaseDeleting):
+		return true
+	default:
+		return false
+	}
+}
+
+// isExcluded reports whether obj is the excluded object.
+func isExcluded(obj, exclude client.Object) bool {
+	if exclude == nil {
+		return false
+	}
+	if exclude.GetUID() != "" && obj.GetUID() == exclude.GetUID() {
+		return true
+	}
+	// Objects being admitted have no UID yet, so fall back to comparing type and name.
+	return reflect.TypeOf(obj) == reflect.TypeOf(exclude) && obj.GetName() == exclude.GetName()
+}
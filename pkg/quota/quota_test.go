@@ -0,0 +1,108 @@
+package quota
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mapt-oss/mapt-operator/api/v1alpha1"
+)
+
+func newKind(name string, phase v1alpha1.ClusterPhase, cpus int32) *v1alpha1.Kind {
+	return &v1alpha1.Kind{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1alpha1.KindSpec{MachineConfig: v1alpha1.MachineConfig{CPUs: cpus, MemoryGiB: 16}},
+		Status:     v1alpha1.KindStatus{ClusterStatus: v1alpha1.ClusterStatus{Phase: phase}},
+	}
+}
+
+var _ = Describe("counts", func() {
+	deleting := func(phase v1alpha1.ClusterPhase) *v1alpha1.Kind {
+		kind := newKind("deleting", phase, 4)
+		kind.DeletionTimestamp = ptr.To(metav1.Now())
+		kind.Finalizers = []string{"mapt.redhat.com/finalizer"}
+		return kind
+	}
+
+	DescribeTable("decides whether a cluster holds its share of the quota",
+		func(kind *v1alpha1.Kind, scope Scope, expected bool) {
+			Expect(counts(kind, string(kind.Status.Phase), scope)).To(Equal(expected))
+		},
+		Entry("all: a new cluster", newKind("new", "", 4), AllClusters, true),
+		Entry("all: a queued cluster", newKind("queued", v1alpha1.ClusterPhaseQueued, 4), AllClusters, true),
+		Entry("all: a hibernated cluster", newKind("hibernated", v1alpha1.ClusterPhaseHibernated, 4), AllClusters, true),
+		Entry("all: a failed cluster", newKind("failed", v1alpha1.ClusterPhaseFailed, 4), AllClusters, false),
+		Entry("all: a cluster being deleted", deleting(v1alpha1.ClusterPhaseRunning), AllClusters, false),
+		Entry("active: a queued cluster", newKind("queued", v1alpha1.ClusterPhaseQueued, 4), ActiveClusters, false),
+		Entry("active: a provisioning cluster", newKind("provisioning", v1alpha1.ClusterPhaseProvisioning, 4), ActiveClusters, true),
+		Entry("active: a running cluster", newKind("running", v1alpha1.ClusterPhaseRunning, 4), ActiveClusters, true),
+		Entry("active: a hibernated cluster", newKind("hibernated", v1alpha1.ClusterPhaseHibernated, 4), ActiveClusters, true),
+		Entry("active: a cluster being deprovisioned", deleting(v1alpha1.ClusterPhaseDeleting), ActiveClusters, true),
+		Entry("active: a failed cluster", newKind("failed", v1alpha1.ClusterPhaseFailed, 4), ActiveClusters, false),
+	)
+})
+
+var _ = Describe("Check", func() {
+	quota := &v1alpha1.MaptQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-quota", Namespace: "default"},
+		Spec: v1alpha1.MaptQuotaSpec{
+			MaxClusters:    ptr.To[int32](2),
+			MaxCPUs:        ptr.To[int32](16),
+			MaxGPUClusters: ptr.To[int32](0),
+		},
+	}
+
+	check := func(existing []client.Object, cluster *v1alpha1.Kind, scope Scope) error {
+		scheme := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing...).Build()
+		return Check(context.Background(), c, cluster, cluster.Spec.MachineConfig, scope)
+	}
+
+	DescribeTable("admits clusters fitting in every quota",
+		func(existing []client.Object, cluster *v1alpha1.Kind, scope Scope) {
+			Expect(check(existing, cluster, scope)).To(Succeed())
+		},
+		Entry("without quotas", []client.Object{newKind("a", v1alpha1.ClusterPhaseRunning, 64)}, newKind("b", "", 64), AllClusters),
+		Entry("within the limits", []client.Object{quota, newKind("a", v1alpha1.ClusterPhaseRunning, 8)}, newKind("b", "", 8), AllClusters),
+		Entry("ignoring failed clusters", []client.Object{quota, newKind("a", v1alpha1.ClusterPhaseFailed, 16)}, newKind("b", "", 16), AllClusters),
+		Entry("without counting the cluster itself", []client.Object{quota, newKind("a", v1alpha1.ClusterPhaseQueued, 16)}, newKind("a", v1alpha1.ClusterPhaseQueued, 16), ActiveClusters),
+		Entry("ignoring queued clusters once active", []client.Object{quota, newKind("a", v1alpha1.ClusterPhaseQueued, 16)}, newKind("b", v1alpha1.ClusterPhaseQueued, 16), ActiveClusters),
+	)
+
+	DescribeTable("rejects clusters exceeding a quota",
+		func(existing []client.Object, cluster *v1alpha1.Kind, scope Scope, violation string) {
+			err := check(existing, cluster, scope)
+			Expect(IsExceeded(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring(violation)))
+		},
+		Entry("over the cluster count",
+			[]client.Object{quota, newKind("a", v1alpha1.ClusterPhaseRunning, 2), newKind("b", v1alpha1.ClusterPhaseQueued, 2)},
+			newKind("c", "", 2), AllClusters, "clusters: requested 3, limit 2"),
+		Entry("over the vCPU limit",
+			[]client.Object{quota, newKind("a", v1alpha1.ClusterPhaseRunning, 12)},
+			newKind("b", "", 8), AllClusters, "cpus: requested 20, limit 16"),
+		Entry("over the vCPU limit held by a hibernated cluster",
+			[]client.Object{quota, newKind("a", v1alpha1.ClusterPhaseHibernated, 12)},
+			newKind("b", v1alpha1.ClusterPhaseQueued, 8), ActiveClusters, "cpus: requested 20, limit 16"),
+		Entry("with a GPU when none are allowed",
+			[]client.Object{quota},
+			func() *v1alpha1.Kind {
+				kind := newKind("gpu", "", 4)
+				kind.Spec.MachineConfig.GPU = true
+				return kind
+			}(), AllClusters, "gpuClusters: requested 1, limit 0"),
+	)
+})
+
+func TestQuota(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Quota Suite")
+}
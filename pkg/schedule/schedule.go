@@ -0,0 +1,213 @@
+// Package schedule evaluates the availability windows of clusters. A window opens when its start
+// cron expression fires and closes when its stop expression fires; both are evaluated in the time
+// zone of the schedule.
+//
+// Expressions use the standard five fields: minute, hour, day of month, month and day of week.
+// Fields accept *, values, ranges (1-5), steps (*/15, 8-18/2) and comma separated lists. Months
+// and days of the week also accept their three letter English names (JAN, MON-FRI), and Sunday is
+// either 0 or 7. As in cron, when both the day of month and the day of week are restricted, a day
+// matches if either of them does.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchLimit bounds how far ahead Next looks for a matching time, so that expressions that never
+// fire, such as "0 0 30 2 *", do not loop forever.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Expression is a parsed cron expression.
+type Expression struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are set when the day of month or the day of week field is *.
+	domAny, dowAny bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12,
+		names: []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}}
+	dowField = field{name: "day of week", min: 0, max: 7,
+		names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}}
+)
+
+// Parse parses a five field cron expression.
+func Parse(expr string) (*Expression, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	e := &Expression{
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+	for i, target := range []struct {
+		bits *uint64
+		f    field
+	}{
+		{&e.minute, minuteField}, {&e.hour, hourField}, {&e.dom, domField}, {&e.month, monthField}, {&e.dow, dowField},
+	} {
+		bits, err := parseField(fields[i], target.f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		*target.bits = bits
+	}
+	// Sunday may be written as 7.
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+	return e, nil
+}
+
+// Next returns the first time strictly after t, at minute precision, the expression fires at in
+// the location of t. It returns the zero time if the expression does not fire within five years.
+func (e *Expression) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(searchLimit)
+
+	for t.Before(limit) {
+		switch {
+		case e.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !e.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case e.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case e.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (e *Expression) dayMatches(t time.Time) bool {
+	dom := e.dom&(1<<uint(t.Day())) != 0
+	dow := e.dow&(1<<uint(t.Weekday())) != 0
+	if e.domAny || e.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			loPart, hiPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(loPart); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(hiPart); err != nil {
+				return 0, err
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			var err error
+			if lo, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if hasStep {
+				hi = f.max
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in %s field, expected %d-%d", s, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Window is a recurring availability window.
+type Window struct {
+	start, stop *Expression
+	loc         *time.Location
+}
+
+// NewWindow returns the window opened by the start expression and closed by the stop expression,
+// evaluated in timeZone. An empty time zone means UTC.
+func NewWindow(start, stop, timeZone string) (*Window, error) {
+	startExpr, err := Parse(start)
+	if err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	stopExpr, err := Parse(stop)
+	if err != nil {
+		return nil, fmt.Errorf("stop: %w", err)
+	}
+	loc := time.UTC
+	if timeZone != "" {
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+		}
+	}
+	return &Window{start: startExpr, stop: stopExpr, loc: loc}, nil
+}
+
+// At reports whether the window is open at now, and when it next opens or closes. The window is
+// open when the stop expression fires before the start expression does. The next transition is
+// the zero time if neither expression fires again.
+func (w *Window) At(now time.Time) (bool, time.Time) {
+	now = now.In(w.loc)
+	nextStart := w.start.Next(now)
+	nextStop := w.stop.Next(now)
+
+	switch {
+	case nextStop.IsZero():
+		return false, nextStart
+	case nextStart.IsZero():
+		return true, nextStop
+	case nextStop.Before(nextStart):
+		return true, nextStop
+	default:
+		return false, nextStart
+	}
+}
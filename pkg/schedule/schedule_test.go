@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Expression", func() {
+	// 2025-01-06 is a Monday.
+	monday := time.Date(2025, 1, 6, 7, 30, 0, 0, time.UTC)
+
+	next := func(expr string, t time.Time) time.Time {
+		e, err := Parse(expr)
+		Expect(err).NotTo(HaveOccurred())
+		return e.Next(t)
+	}
+
+	It("finds the next time of a daily expression", func() {
+		Expect(next("0 8 * * *", monday)).To(Equal(time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)))
+		Expect(next("0 8 * * *", monday.Add(time.Hour))).To(Equal(time.Date(2025, 1, 7, 8, 0, 0, 0, time.UTC)))
+	})
+
+	It("never returns the given time itself", func() {
+		at := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+		Expect(next("0 8 * * *", at)).To(Equal(at.AddDate(0, 0, 1)))
+	})
+
+	It("supports day names, ranges and steps", func() {
+		friday := time.Date(2025, 1, 10, 20, 0, 0, 0, time.UTC)
+		Expect(next("0 8 * * MON-FRI", friday)).To(Equal(time.Date(2025, 1, 13, 8, 0, 0, 0, time.UTC)))
+		Expect(next("*/20 9-17/4 * * *", monday)).To(Equal(time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)))
+		Expect(next("0 0 * * 7", monday)).To(Equal(time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)))
+		Expect(next("0 0 1 jan,jul *", monday)).To(Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("matches either restricted day field", func() {
+		// The 15th of the month, or any Sunday.
+		Expect(next("0 0 15 * SUN", monday)).To(Equal(time.Date(2025, 1, 12, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("evaluates the expression in the location of the given time", func() {
+		loc, err := time.LoadLocation("Europe/Madrid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(next("0 8 * * *", monday.In(loc))).To(Equal(time.Date(2025, 1, 7, 8, 0, 0, 0, loc)))
+	})
+
+	It("returns the zero time for expressions that never fire", func() {
+		Expect(next("0 0 30 2 *", monday)).To(BeZero())
+	})
+
+	DescribeTable("rejects invalid expressions",
+		func(expr string) {
+			_, err := Parse(expr)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("too few fields", "0 8 * *"),
+		Entry("out of range value", "0 24 * * *"),
+		Entry("unknown name", "0 8 * * MOO"),
+		Entry("reversed range", "0 18-8 * * *"),
+		Entry("zero step", "*/0 * * * *"),
+	)
+})
+
+var _ = Describe("Window", func() {
+	window := func(tz string) *Window {
+		w, err := NewWindow("0 8 * * MON-FRI", "0 19 * * MON-FRI", tz)
+		Expect(err).NotTo(HaveOccurred())
+		return w
+	}
+
+	It("is open during working hours", func() {
+		open, next := window("").At(time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(next).To(Equal(time.Date(2025, 1, 6, 19, 0, 0, 0, time.UTC)))
+	})
+
+	It("is closed over the weekend", func() {
+		open, next := window("").At(time.Date(2025, 1, 11, 12, 0, 0, 0, time.UTC))
+		Expect(open).To(BeFalse())
+		Expect(next).To(Equal(time.Date(2025, 1, 13, 8, 0, 0, 0, time.UTC)))
+	})
+
+	It("is evaluated in its time zone", func() {
+		// 07:30 UTC is 08:30 in Madrid in winter.
+		open, next := window("Europe/Madrid").At(time.Date(2025, 1, 6, 7, 30, 0, 0, time.UTC))
+		Expect(open).To(BeTrue())
+		Expect(next.UTC()).To(Equal(time.Date(2025, 1, 6, 18, 0, 0, 0, time.UTC)))
+	})
+
+	It("rejects an unknown time zone", func() {
+		_, err := NewWindow("0 8 * * *", "0 19 * * *", "Mars/Olympus")
+		Expect(err).To(MatchError(ContainSubstring("invalid time zone")))
+	})
+})
+
+func TestSchedule(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schedule Suite")
+}
@@ -36,6 +36,9 @@ var (
 	skipCertManagerInstall = os.Getenv("CERT_MANAGER_INSTALL_SKIP") == "true"
 	// isCertManagerAlreadyInstalled will be set true when CertManager CRDs be found on the cluster
 	isCertManagerAlreadyInstalled = false
+	// - E2E_PROVISIONER=simulated: Deploys the operator with the simulated provisioner and runs the
+	// tests creating clusters, which need no cloud access then.
+	simulatedProvisioner = os.Getenv("E2E_PROVISIONER") == "simulated"
 
 	// projectImage is the name of the image which will be build and loaded
 	// with the code source changes to be tested.
@@ -22,6 +22,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -42,6 +43,12 @@ const metricsServiceName = "mapt-operator-controller-manager-metrics-service"
 // metricsRoleBindingName is the name of the RBAC that will be created to allow get the metrics data
 const metricsRoleBindingName = "mapt-operator-metrics-binding"
 
+// kindClusterName is the name of the Kind cluster created by the simulated provisioner
+const kindClusterName = "e2e-kind"
+
+// kindKubeconfigSecretName is the name of the Secret holding the kubeconfig of the Kind cluster
+const kindKubeconfigSecretName = "e2e-kind-kubeconfig"
+
 var _ = Describe("Manager", Ordered, func() {
 	var controllerPodName string
 
@@ -66,7 +73,11 @@ var _ = Describe("Manager", Ordered, func() {
 		Expect(err).NotTo(HaveOccurred(), "Failed to install CRDs")
 
 		By("deploying the controller-manager")
-		cmd = exec.Command("make", "deploy", fmt.Sprintf("IMG=%s", projectImage))
+		deployTarget := "deploy"
+		if simulatedProvisioner {
+			deployTarget = "deploy-simulated"
+		}
+		cmd = exec.Command("make", deployTarget, fmt.Sprintf("IMG=%s", projectImage))
 		_, err = utils.Run(cmd)
 		Expect(err).NotTo(HaveOccurred(), "Failed to deploy the controller-manager")
 	})
@@ -78,6 +89,11 @@ var _ = Describe("Manager", Ordered, func() {
 		cmd := exec.Command("kubectl", "delete", "pod", "curl-metrics", "-n", namespace)
 		_, _ = utils.Run(cmd)
 
+		By("cleaning up the Kind cluster")
+		cmd = exec.Command("kubectl", "delete", "kinds.mapt.redhat.com", kindClusterName,
+			"-n", namespace, "--ignore-not-found")
+		_, _ = utils.Run(cmd)
+
 		By("undeploying the controller-manager")
 		cmd = exec.Command("make", "undeploy")
 		_, _ = utils.Run(cmd)
@@ -258,6 +274,74 @@ var _ = Describe("Manager", Ordered, func() {
 
 		// +kubebuilder:scaffold:e2e-webhooks-checks
 
+		It("should provision and delete a Kind cluster", func() {
+			if !simulatedProvisioner {
+				Skip("creating clusters requires the simulated provisioner, run make test-e2e-simulated")
+			}
+
+			By("creating a Kind cluster")
+			cmd := exec.Command("kubectl", "apply", "-n", namespace, "-f", "-")
+			cmd.Stdin = strings.NewReader(fmt.Sprintf(`
+apiVersion: mapt.redhat.com/v1alpha1
+kind: Kind
+metadata:
+  name: %s
+spec:
+  cloudConfig:
+    provider: AWS
+    credentialsSecretRef:
+      name: aws-creds-secret
+  machineConfig:
+    architecture: x86_64
+    cpus: 4
+    memoryGiB: 16
+  kindClusterConfig:
+    kubernetesVersion: v1.32
+  outputKubeconfigSecretName: %s
+`, kindClusterName, kindKubeconfigSecretName))
+			_, err := utils.Run(cmd)
+			Expect(err).NotTo(HaveOccurred(), "Failed to create the Kind cluster")
+
+			By("waiting for the Kind cluster to be running")
+			verifyClusterRunning := func(g Gomega) {
+				cmd := exec.Command("kubectl", "get", "kinds.mapt.redhat.com", kindClusterName,
+					"-o", "jsonpath={.status.phase}", "-n", namespace)
+				output, err := utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(output).To(Equal("Running"), "Kind cluster in wrong phase")
+			}
+			Eventually(verifyClusterRunning).Should(Succeed())
+
+			By("validating that the kubeconfig Secret of the cluster is written")
+			cmd = exec.Command("kubectl", "get", "secret", kindKubeconfigSecretName,
+				"-o", "jsonpath={.data.kubeconfig}", "-n", namespace)
+			output, err := utils.Run(cmd)
+			Expect(err).NotTo(HaveOccurred(), "Kubeconfig Secret should exist")
+			Expect(output).NotTo(BeEmpty(), "Kubeconfig Secret should hold a kubeconfig")
+
+			By("deleting the Kind cluster")
+			cmd = exec.Command("kubectl", "delete", "kinds.mapt.redhat.com", kindClusterName,
+				"-n", namespace, "--wait=false")
+			_, err = utils.Run(cmd)
+			Expect(err).NotTo(HaveOccurred(), "Failed to delete the Kind cluster")
+
+			By("waiting for the Kind cluster and its kubeconfig Secret to be removed")
+			verifyClusterRemoved := func(g Gomega) {
+				cmd := exec.Command("kubectl", "get", "kinds.mapt.redhat.com", kindClusterName,
+					"-n", namespace, "--ignore-not-found", "-o", "name")
+				output, err := utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(output).To(BeEmpty(), "Kind cluster not yet removed")
+
+				cmd = exec.Command("kubectl", "get", "secret", kindKubeconfigSecretName,
+					"-n", namespace, "--ignore-not-found", "-o", "name")
+				output, err = utils.Run(cmd)
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(output).To(BeEmpty(), "Kubeconfig Secret not yet removed")
+			}
+			Eventually(verifyClusterRemoved).Should(Succeed())
+		})
+
 		// TODO: Customize the e2e test suite with scenarios specific to your project.
 		// Consider applying sample/CR(s) and check their status and/or verifying
 		// the reconciliation by using the metrics, i.e.: